@@ -77,6 +77,41 @@ func (canvas *Canvas) DrawLine(color color.Color, offsetX, offsetY, length float
 	canvas.context.Stroke()
 }
 
+// DrawPath 绘制折线
+func (canvas *Canvas) DrawPath(points [][2]float64, c color.Color, lineWidth float64) {
+	if len(points) < 2 {
+		return
+	}
+	canvas.context.MoveTo(points[0][0], points[0][1])
+	for _, point := range points[1:] {
+		canvas.context.LineTo(point[0], point[1])
+	}
+	canvas.context.SetLineWidth(lineWidth)
+	canvas.context.SetColor(c)
+	canvas.context.Stroke()
+}
+
+// DrawPolygon 绘制实心多边形
+func (canvas *Canvas) DrawPolygon(points [][2]float64, c color.Color) {
+	if len(points) < 3 {
+		return
+	}
+	canvas.context.MoveTo(points[0][0], points[0][1])
+	for _, point := range points[1:] {
+		canvas.context.LineTo(point[0], point[1])
+	}
+	canvas.context.ClosePath()
+	canvas.context.SetColor(c)
+	canvas.context.Fill()
+}
+
+// DrawCircle 绘制实心圆
+func (canvas *Canvas) DrawCircle(x, y, r float64, c color.Color) {
+	canvas.context.SetColor(c)
+	canvas.context.DrawCircle(x, y, r)
+	canvas.context.Fill()
+}
+
 // DrawRect 绘制矩形
 func (canvas *Canvas) DrawRect(x, y, w, h float64, c color.Color) {
 	canvas.context.SetColor(c)
@@ -137,3 +172,8 @@ func (canvas *Canvas) SavePNG(path string) error {
 
 	return nil
 }
+
+// Image 画布图片
+func (canvas *Canvas) Image() image.Image {
+	return canvas.context.Image()
+}
@@ -6,7 +6,9 @@ import (
 	"path"
 
 	"github.com/fogleman/gg"
+	"golang.org/x/image/draw"
 	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
 )
 
 var fontCacheMap = map[string]*font.Face{}
@@ -28,7 +30,12 @@ func LoadLocalImage(imagePath string) (image.Image, error) {
 }
 
 // LoadLocalFont 字体缓存在内存中
+// fontPath 为空时使用内置的 7x13 点阵字体，字号不生效
 func LoadLocalFont(fontPath string, fontSize float64) (font.Face, error) {
+	if fontPath == "" {
+		return basicfont.Face7x13, nil
+	}
+
 	fontKey := fmt.Sprintf("%s:%f", fontPath, fontSize)
 	if font, cached := fontCacheMap[fontKey]; cached {
 		return *font, nil
@@ -44,12 +51,15 @@ func LoadLocalFont(fontPath string, fontSize float64) (font.Face, error) {
 	return font, nil
 }
 
-// ImageResize 图片缩放
+// ImageResize 图片缩放，双线性插值
 func ImageResize(input image.Image, width uint, height uint) image.Image {
-	_ = input.Bounds()
-	// TODO
+	if input.Bounds().Dx() == int(width) && input.Bounds().Dy() == int(height) {
+		return input
+	}
+	output := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+	draw.BiLinear.Scale(output, output.Bounds(), input, input.Bounds(), draw.Src, nil)
 
-	return input
+	return output
 }
 
 // ImageRound 图片变圆
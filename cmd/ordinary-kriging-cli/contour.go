@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/contour"
+	"github.com/lvisei/go-kriging/pkg/raster"
+	"github.com/spf13/cobra"
+)
+
+var contourFlags = struct {
+	input     string
+	output    string
+	levels    []float64
+	intervals int
+	bands     bool
+	defaults  bool
+	polygon   string
+	tolerance float64
+	smooth    int
+}{}
+
+var contourCmd = &cobra.Command{
+	Use:   "contour",
+	Short: "Trace isolines or filled isobands of a JSON grid written by grid to GeoJSON",
+	Example: `  ordinary-kriging-cli contour -i grid.json --intervals 10 -o isolines.geojson
+  ordinary-kriging-cli contour -i grid.json --levels 0,5,10,15 -o isolines.geojson
+  ordinary-kriging-cli contour -i grid.json --bands --default-levels --polygon yn.json --tolerance 0.005 --smooth 2 -o isobands.geojson`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		grid, err := readGrid(contourFlags.input)
+		if err != nil {
+			return err
+		}
+		r := grid.raster()
+		if contourFlags.bands {
+			return writeBands(r)
+		}
+
+		levels := contourFlags.levels
+		if len(levels) == 0 {
+			if contourFlags.intervals < 2 {
+				return fmt.Errorf("--levels or --intervals of at least 2 is required")
+			}
+			levels = contour.Levels(r.Zlim(), contourFlags.intervals)
+		}
+		isolines, err := contour.Lines(r, levels)
+		if err != nil {
+			return err
+		}
+		return writeJSON(contourFlags.output, contour.LinesFeatureCollection(isolines))
+	},
+}
+
+// writeBands 输出等值面，--levels 为区间分界值
+func writeBands(r *raster.Raster) error {
+	breaks := contourFlags.levels
+	switch {
+	case contourFlags.defaults:
+		breaks = contour.GridLevelColorBreaks(ordinarykriging.DefaultGridLevelColor)
+	case len(breaks) == 0:
+		if contourFlags.intervals < 1 {
+			return fmt.Errorf("--levels, --default-levels or --intervals is required")
+		}
+		breaks = contour.Breaks(r.Zlim(), contourFlags.intervals)
+	}
+
+	opt := &contour.BandOptions{Tolerance: contourFlags.tolerance, Smooth: contourFlags.smooth}
+	if contourFlags.polygon != "" {
+		polygon, err := readPolygon(contourFlags.polygon)
+		if err != nil {
+			return err
+		}
+		opt.Clip = polygon
+	}
+	isobands, err := contour.Bands(r, breaks, opt)
+	if err != nil {
+		return err
+	}
+	return writeJSON(contourFlags.output, contour.BandsFeatureCollection(isobands))
+}
+
+func init() {
+	contourCmd.Flags().StringVarP(&contourFlags.input, "input", "i", "", "JSON grid written by grid")
+	contourCmd.Flags().StringVarP(&contourFlags.output, "output", "o", "", "output GeoJSON, stdout if empty")
+	contourCmd.Flags().Float64SliceVar(&contourFlags.levels, "levels", nil, "isoline values, or class breaks with --bands")
+	contourCmd.Flags().IntVar(&contourFlags.intervals, "intervals", 10, "number of equal intervals over the value range, instead of --levels")
+	contourCmd.Flags().BoolVar(&contourFlags.bands, "bands", false, "filled isobands as GeoJSON MultiPolygons instead of isolines")
+	contourCmd.Flags().BoolVar(&contourFlags.defaults, "default-levels", false, "class breaks of the default render colors with --bands")
+	contourCmd.Flags().StringVar(&contourFlags.polygon, "polygon", "", "boundary to clip the isobands, the one passed to grid")
+	contourCmd.Flags().Float64Var(&contourFlags.tolerance, "tolerance", 0, "Douglas-Peucker simplification tolerance of the isoband boundaries, shared by adjacent bands")
+	contourCmd.Flags().IntVar(&contourFlags.smooth, "smooth", 0, "Chaikin smoothing iterations of the isoband boundaries")
+	contourCmd.MarkFlagRequired("input")
+}
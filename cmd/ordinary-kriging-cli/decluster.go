@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/spf13/cobra"
+)
+
+var declusterFlags = struct {
+	sampleFlags
+	method   string
+	cellSize float64
+	minSize  float64
+	maxSize  float64
+	count    int
+	offsets  int
+	maximize bool
+	bbox     string
+	polygon  string
+	output   string
+	report   string
+}{}
+
+var declusterCmd = &cobra.Command{
+	Use:   "decluster",
+	Short: "Declustering weights of clustered sample points with declustered statistics",
+	Example: `  ordinary-kriging-cli decluster -i 2045.csv --x Lon --y Lat --value TEM_Avg -o weights.csv --report decluster.json
+  ordinary-kriging-cli decluster -i 2045.csv --x Lon --y Lat --value TEM_Avg --method polygonal --polygon yn.json -o weights.csv`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := declusterFlags.sampleFlags.read(true)
+		if err != nil {
+			return err
+		}
+		method, err := ordinarykriging.ParseDeclusterMethod(declusterFlags.method)
+		if err != nil {
+			return err
+		}
+
+		var declustering *ordinarykriging.Declustering
+		switch method {
+		case ordinarykriging.DeclusterCell:
+			declustering, err = ordinarykriging.CellDecluster(data.Values, data.X, data.Y, &ordinarykriging.CellDeclusterOptions{
+				CellSize: declusterFlags.cellSize,
+				MinSize:  declusterFlags.minSize,
+				MaxSize:  declusterFlags.maxSize,
+				Count:    declusterFlags.count,
+				Offsets:  declusterFlags.offsets,
+				Maximize: declusterFlags.maximize,
+			})
+		case ordinarykriging.DeclusterPolygonal:
+			opt := &ordinarykriging.PolygonalDeclusterOptions{}
+			if declusterFlags.bbox != "" {
+				bbox, err := parseBBox(declusterFlags.bbox)
+				if err != nil {
+					return err
+				}
+				opt.BBox = &bbox
+			}
+			if declusterFlags.polygon != "" {
+				if opt.Polygon, err = readPolygon(declusterFlags.polygon); err != nil {
+					return err
+				}
+			}
+			declustering, err = ordinarykriging.PolygonalDecluster(data.Values, data.X, data.Y, opt)
+		default:
+			return fmt.Errorf("--method is required")
+		}
+		if err != nil {
+			return err
+		}
+
+		naive := ordinarykriging.WeightedStatistics(data.Values, nil)
+		declustered := ordinarykriging.WeightedStatistics(data.Values, declustering.Weights)
+		if declustering.CellSize > 0 {
+			fmt.Printf("cell size: %v\n", declustering.CellSize)
+		}
+		fmt.Printf("%-12s %14s %14s\n", "", "naive", "declustered")
+		for _, row := range []struct {
+			name string
+			a, b float64
+		}{
+			{"mean", naive.Mean, declustered.Mean},
+			{"std dev", naive.StdDev, declustered.StdDev},
+			{"q1", naive.Q1, declustered.Q1},
+			{"median", naive.Median, declustered.Median},
+			{"q3", naive.Q3, declustered.Q3},
+		} {
+			fmt.Printf("%-12s %14.6g %14.6g\n", row.name, row.a, row.b)
+		}
+
+		if declusterFlags.report != "" {
+			if err := writeJSON(declusterFlags.report, declustering); err != nil {
+				return err
+			}
+		}
+		if declusterFlags.output == "" {
+			return nil
+		}
+		out, err := os.Create(declusterFlags.output)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		writer := csv.NewWriter(out)
+		writer.Write([]string{"x", "y", "value", "weight"})
+		for i := range data.Values {
+			writer.Write([]string{
+				strconv.FormatFloat(data.X[i], 'g', -1, 64),
+				strconv.FormatFloat(data.Y[i], 'g', -1, 64),
+				strconv.FormatFloat(data.Values[i], 'g', -1, 64),
+				strconv.FormatFloat(declustering.Weights[i], 'g', -1, 64),
+			})
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		return out.Close()
+	},
+}
+
+// decluster 以默认选项计算模型训练数据的解聚权重，泰森多边形的外边界为样本的范围
+func decluster(variogram *ordinarykriging.Variogram, method ordinarykriging.DeclusterMethod) (*ordinarykriging.Declustering, error) {
+	t, x, y := variogram.TrainingData()
+	if method == ordinarykriging.DeclusterPolygonal {
+		return ordinarykriging.PolygonalDecluster(t, x, y, nil)
+	}
+	return ordinarykriging.CellDecluster(t, x, y, nil)
+}
+
+func init() {
+	declusterFlags.sampleFlags.register(declusterCmd)
+	declusterCmd.Flags().StringVar(&declusterFlags.method, "method", string(ordinarykriging.DeclusterCell), "cell or polygonal")
+	declusterCmd.Flags().Float64Var(&declusterFlags.cellSize, "cell-size", 0, "fixed cell size, searched if 0")
+	declusterCmd.Flags().Float64Var(&declusterFlags.minSize, "min-size", 0, "smallest cell size of the search")
+	declusterCmd.Flags().Float64Var(&declusterFlags.maxSize, "max-size", 0, "largest cell size of the search, half the longer side of the sample extent if 0")
+	declusterCmd.Flags().IntVar(&declusterFlags.count, "sizes", 24, "number of cell sizes of the search")
+	declusterCmd.Flags().IntVar(&declusterFlags.offsets, "offsets", 5, "grid origin offsets averaged for each cell size")
+	declusterCmd.Flags().BoolVar(&declusterFlags.maximize, "maximize", false, "choose the cell size of the largest declustered mean, for samples clustered in low values")
+	declusterCmd.Flags().StringVar(&declusterFlags.bbox, "bbox", "", "polygonal declustering extent minX,minY,maxX,maxY, the sample extent if empty")
+	declusterCmd.Flags().StringVar(&declusterFlags.polygon, "polygon", "", "polygonal declustering boundary, polygon Shapefile or GeoJSON")
+	declusterCmd.Flags().StringVarP(&declusterFlags.output, "output", "o", "", "CSV of the samples and weights, columns x, y, value and weight")
+	declusterCmd.Flags().StringVar(&declusterFlags.report, "report", "", "JSON report with the weights and the searched cell sizes")
+}
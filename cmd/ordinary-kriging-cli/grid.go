@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/asciigrid"
+	"github.com/lvisei/go-kriging/pkg/geotiff"
+	"github.com/lvisei/go-kriging/pkg/raster"
+	"github.com/spf13/cobra"
+)
+
+// 栅格输出格式
+const (
+	formatGeoTIFF = "geotiff"
+	formatASCII   = "ascii"
+	formatJSON    = "json"
+)
+
+// 插值方法
+const (
+	methodKriging = "kriging"
+	methodIDW     = "idw"
+	methodRBF     = "rbf"
+	methodNearest = "nearest"
+)
+
+var gridFlags = struct {
+	modelPath  string
+	bbox       string
+	polygon    string
+	resolution float64
+	width      int
+	format     string
+	output     string
+	epsg       int
+	float32    bool
+	variance   bool
+	deflate    bool
+	tileSize   int
+	method     string
+	power      float64
+	radius     float64
+	neighbors  int
+	kernel     string
+	epsilon    float64
+	smoothing  float64
+}{}
+
+var gridCmd = &cobra.Command{
+	Use:   "grid",
+	Short: "Interpolate a model over a bbox or polygon to GeoTIFF, ESRI ASCII grid or JSON",
+	Example: `  ordinary-kriging-cli grid -f model.json --polygon yn.json --resolution 0.01 -o grid.json
+  ordinary-kriging-cli grid -f model.json --bbox 97,21,107,29.5 --width 800 -o grid.tif
+  ordinary-kriging-cli grid -f model.json --bbox 97,21,107,29.5 --width 2048 --float32 --deflate --tile-size 256 --variance -o cog.tif
+  ordinary-kriging-cli grid -f model.json --polygon yn.json --resolution 0.01 --method idw --power 2 --neighbors 12 -o idw.tif`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		variogram, err := readModel(gridFlags.modelPath)
+		if err != nil {
+			return err
+		}
+		format, err := outputFormat(gridFlags.format, gridFlags.output)
+		if err != nil {
+			return err
+		}
+		interpolator, err := gridInterpolator(variogram)
+		if err != nil {
+			return err
+		}
+
+		var data interface{}
+		var rst *raster.Raster
+		switch {
+		case gridFlags.polygon != "":
+			polygon, err := readPolygon(gridFlags.polygon)
+			if err != nil {
+				return err
+			}
+			if gridFlags.resolution <= 0 {
+				return fmt.Errorf("--resolution is required with --polygon")
+			}
+			gridMatrices := ordinarykriging.GridMultiPolygon(interpolator, polygon, gridFlags.resolution)
+			data, rst = gridMatrices, raster.FromGridMatrices(gridMatrices)
+		case gridFlags.bbox != "":
+			bbox, err := parseBBox(gridFlags.bbox)
+			if err != nil {
+				return err
+			}
+			xWidth := gridFlags.width
+			if xWidth <= 0 {
+				if gridFlags.resolution <= 0 {
+					return fmt.Errorf("--resolution or --width is required")
+				}
+				xWidth = int(math.Ceil((bbox[2] - bbox[0]) / gridFlags.resolution))
+			}
+			yWidth := int(math.Ceil(float64(xWidth) / ((bbox[2] - bbox[0]) / (bbox[3] - bbox[1]))))
+			// 在像元中心插值
+			xResolution := (bbox[2] - bbox[0]) / float64(xWidth)
+			yResolution := (bbox[3] - bbox[1]) / float64(yWidth)
+			contourRectangle := ordinarykriging.ContourWithBBox(interpolator, [4]float64{
+				bbox[0] + xResolution/2,
+				bbox[1] + yResolution/2,
+				bbox[2] + xResolution/2,
+				bbox[3] + yResolution/2,
+			}, float64(xWidth))
+			data, rst = contourRectangle, raster.FromContourRectangle(contourRectangle)
+		default:
+			return fmt.Errorf("--bbox or --polygon is required")
+		}
+
+		if format == formatJSON {
+			return writeJSON(gridFlags.output, data)
+		}
+
+		out := os.Stdout
+		if gridFlags.output != "" {
+			if out, err = os.Create(gridFlags.output); err != nil {
+				return err
+			}
+			defer out.Close()
+		}
+		if format == formatGeoTIFF {
+			opt := &geotiff.Options{EPSG: gridFlags.epsg, TileSize: gridFlags.tileSize}
+			if gridFlags.float32 {
+				opt.DataType = geotiff.Float32
+			}
+			if gridFlags.deflate {
+				opt.Compression = geotiff.Deflate
+			}
+			if gridFlags.variance {
+				opt.Variogram = variogram
+			}
+			return geotiff.Encode(out, rst, opt)
+		}
+		return asciigrid.Encode(out, rst)
+	},
+}
+
+// gridInterpolator --method 指定的插值方法，kriging 以外的方法插值模型文件中的训练样本
+func gridInterpolator(variogram *ordinarykriging.Variogram) (ordinarykriging.Interpolator, error) {
+	method := strings.ToLower(gridFlags.method)
+	if gridFlags.variance && method != methodKriging {
+		return nil, fmt.Errorf("--variance is only available with --method kriging")
+	}
+	t, x, y := variogram.TrainingData()
+	switch method {
+	case methodKriging:
+		return variogram, nil
+	case methodIDW:
+		return ordinarykriging.NewIDW(t, x, y, &ordinarykriging.IDWOptions{
+			Power:     gridFlags.power,
+			Radius:    gridFlags.radius,
+			Neighbors: gridFlags.neighbors,
+		})
+	case methodRBF:
+		kernel, err := ordinarykriging.ParseRBFKernel(gridFlags.kernel)
+		if err != nil {
+			return nil, err
+		}
+		return ordinarykriging.NewRBF(t, x, y, &ordinarykriging.RBFOptions{
+			Kernel:    kernel,
+			Epsilon:   gridFlags.epsilon,
+			Smoothing: gridFlags.smoothing,
+		})
+	case methodNearest:
+		return ordinarykriging.NewNearestNeighbor(t, x, y)
+	}
+	return nil, fmt.Errorf("unknown interpolation method %q", gridFlags.method)
+}
+
+// outputFormat 栅格输出格式，未指定时由文件扩展名推断
+func outputFormat(format, output string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(output)) {
+		case ".tif", ".tiff":
+			return formatGeoTIFF, nil
+		case ".asc":
+			return formatASCII, nil
+		default:
+			return formatJSON, nil
+		}
+	}
+	switch format = strings.ToLower(format); format {
+	case formatGeoTIFF, formatASCII, formatJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported format %q", format)
+}
+
+func init() {
+	gridCmd.Flags().StringVarP(&gridFlags.modelPath, "model-file", "f", "model.json", "model file written by train")
+	gridCmd.Flags().StringVar(&gridFlags.bbox, "bbox", "", "minX,minY,maxX,maxY")
+	gridCmd.Flags().StringVar(&gridFlags.polygon, "polygon", "", "boundary file to clip the grid, polygon Shapefile or GeoJSON Polygon, MultiPolygon, Feature or FeatureCollection")
+	gridCmd.Flags().Float64Var(&gridFlags.resolution, "resolution", 0, "grid cell size")
+	gridCmd.Flags().IntVar(&gridFlags.width, "width", 0, "number of cells in x, instead of --resolution with --bbox")
+	gridCmd.Flags().StringVar(&gridFlags.format, "format", "", "geotiff, ascii or json, by output extension if empty")
+	gridCmd.Flags().StringVarP(&gridFlags.output, "output", "o", "", "output file, stdout if empty")
+	gridCmd.Flags().IntVar(&gridFlags.epsg, "epsg", geotiff.DefaultEPSG, "GeoTIFF EPSG code of the model coordinates")
+	gridCmd.Flags().BoolVar(&gridFlags.float32, "float32", false, "GeoTIFF Float32 samples instead of Float64")
+	gridCmd.Flags().BoolVar(&gridFlags.variance, "variance", false, "GeoTIFF second band with the kriging variance")
+	gridCmd.Flags().BoolVar(&gridFlags.deflate, "deflate", false, "GeoTIFF deflate compression")
+	gridCmd.Flags().IntVar(&gridFlags.tileSize, "tile-size", 0, "GeoTIFF tile size, a multiple of 16, cloud optimized layout; strips if 0")
+	gridCmd.Flags().StringVar(&gridFlags.method, "method", methodKriging, "interpolation method, kriging, idw, rbf or nearest; the others interpolate the training samples of the model")
+	gridCmd.Flags().Float64Var(&gridFlags.power, "power", 2, "IDW distance power")
+	gridCmd.Flags().Float64Var(&gridFlags.radius, "radius", 0, "IDW search radius, unlimited if 0; cells without samples in the radius are NaN")
+	gridCmd.Flags().IntVar(&gridFlags.neighbors, "neighbors", 0, "IDW nearest samples, all if 0")
+	gridCmd.Flags().StringVar(&gridFlags.kernel, "kernel", string(ordinarykriging.RBFThinPlate), "RBF kernel, thin-plate, multiquadric or gaussian")
+	gridCmd.Flags().Float64Var(&gridFlags.epsilon, "epsilon", 0, "RBF distance scale, the mean sample spacing if 0")
+	gridCmd.Flags().Float64Var(&gridFlags.smoothing, "smoothing", 0, "RBF smoothing, exact interpolation if 0")
+}
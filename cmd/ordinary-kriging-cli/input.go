@@ -0,0 +1,248 @@
+package main
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/dataset"
+	"github.com/lvisei/go-kriging/pkg/geojson"
+	"github.com/lvisei/go-kriging/pkg/json"
+	"github.com/lvisei/go-kriging/pkg/raster"
+	"github.com/lvisei/go-kriging/pkg/shapefile"
+	"github.com/spf13/cobra"
+)
+
+// sampleFlags 读取样本数据文件的参数
+type sampleFlags struct {
+	input       string
+	xColumn     string
+	yColumn     string
+	valueColumn string
+	delimiter   string
+	header      string
+	missing     []string
+	skipInvalid bool
+	where       []string
+}
+
+func (f *sampleFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.input, "input", "i", "", "sample file, CSV/TSV, GeoJSON FeatureCollection of Points or point Shapefile")
+	cmd.Flags().StringVar(&f.xColumn, "x", "x", "CSV column name or 0-based index of x (longitude)")
+	cmd.Flags().StringVar(&f.yColumn, "y", "y", "CSV column name or 0-based index of y (latitude)")
+	cmd.Flags().StringVar(&f.valueColumn, "value", "value", "CSV column, GeoJSON property or DBF field of the measured value")
+	cmd.Flags().StringVar(&f.delimiter, "delimiter", "", "CSV field delimiter, detected from the first line if empty")
+	cmd.Flags().StringVar(&f.header, "header", "auto", "CSV header row, auto, yes or no")
+	cmd.Flags().StringSliceVar(&f.missing, "missing", dataset.DefaultMissingValues, "missing value markers, rows with missing coordinates or value are skipped")
+	cmd.Flags().BoolVar(&f.skipInvalid, "skip-invalid", false, "skip rows that can not be parsed instead of failing")
+	cmd.Flags().StringSliceVar(&f.where, "where", nil, "GeoJSON property or DBF field filter property=value, features matching all filters are read")
+	cmd.MarkFlagRequired("input")
+}
+
+// read 读取样本，withValue 为 false 时只读取坐标
+func (f *sampleFlags) read(withValue bool) (*dataset.Samples, error) {
+	var data *dataset.Samples
+	var err error
+	switch ext := filepath.Ext(f.input); {
+	case strings.EqualFold(ext, ".geojson") || strings.EqualFold(ext, ".json"):
+		data, err = f.readGeoJSON(withValue)
+	case strings.EqualFold(ext, ".shp"):
+		data, err = f.readShapefile(withValue)
+	default:
+		data, err = f.readCSV(withValue)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, skipped := range data.Skipped {
+		fmt.Fprintf(os.Stderr, "%s: skipped %v\n", f.input, skipped)
+	}
+	return data, nil
+}
+
+func (f *sampleFlags) readCSV(withValue bool) (*dataset.Samples, error) {
+	opt := &dataset.CSVOptions{
+		X:             f.xColumn,
+		Y:             f.yColumn,
+		Value:         f.valueColumn,
+		MissingValues: f.missing,
+		SkipInvalid:   f.skipInvalid,
+	}
+	if !withValue {
+		opt.Value = dataset.NoValue
+	}
+	if f.delimiter != "" {
+		opt.Delimiter = []rune(f.delimiter)[0]
+		if f.delimiter == `\t` {
+			opt.Delimiter = '\t'
+		}
+	}
+	switch strings.ToLower(f.header) {
+	case "yes", "true":
+		opt.Header = dataset.HeaderYes
+	case "no", "false":
+		opt.Header = dataset.HeaderNo
+	}
+	return dataset.ReadCSVFile(f.input, opt)
+}
+
+func (f *sampleFlags) readGeoJSON(withValue bool) (*dataset.Samples, error) {
+	opt, err := f.featureOptions(withValue)
+	if err != nil {
+		return nil, err
+	}
+	return dataset.ReadGeoJSONFile(f.input, opt)
+}
+
+func (f *sampleFlags) readShapefile(withValue bool) (*dataset.Samples, error) {
+	opt, err := f.featureOptions(withValue)
+	if err != nil {
+		return nil, err
+	}
+	return dataset.ReadShapefile(f.input, opt)
+}
+
+// featureOptions GeoJSON 与 Shapefile 点要素的读取参数
+func (f *sampleFlags) featureOptions(withValue bool) (*dataset.GeoJSONOptions, error) {
+	opt := &dataset.GeoJSONOptions{
+		Value:         f.valueColumn,
+		MissingValues: f.missing,
+		SkipInvalid:   f.skipInvalid,
+	}
+	if !withValue {
+		opt.Value = dataset.NoValue
+	}
+	if len(f.where) > 0 {
+		opt.Where = map[string]string{}
+		for _, condition := range f.where {
+			i := strings.Index(condition, "=")
+			if i <= 0 {
+				return nil, fmt.Errorf("invalid --where %q, want property=value", condition)
+			}
+			opt.Where[condition[:i]] = condition[i+1:]
+		}
+	}
+	return opt, nil
+}
+
+// readModel 读取 train 保存的模型文件
+func readModel(path string) (*ordinarykriging.Variogram, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	variogram := &ordinarykriging.Variogram{}
+	if err := json.Unmarshal(content, variogram); err != nil {
+		return nil, fmt.Errorf("%s: invalid model: %v", path, err)
+	}
+	if variogram.Model == "" || variogram.N == 0 {
+		return nil, fmt.Errorf("%s: not a trained model", path)
+	}
+	return variogram, nil
+}
+
+// readPolygon 读取边界文件，支持 Shapefile 面要素与 GeoJSON Polygon、MultiPolygon、Feature、FeatureCollection
+func readPolygon(path string) (ordinarykriging.MultiPolygonCoordinates, error) {
+	if strings.EqualFold(filepath.Ext(path), ".shp") {
+		s, err := shapefile.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		return s.MultiPolygon()
+	}
+
+	fc, err := geojson.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	multiPolygon, err := fc.MultiPolygon()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return multiPolygon, nil
+}
+
+// gridFile grid 命令输出的 JSON，GridMatrices 或 ContourRectangle
+type gridFile struct {
+	ordinarykriging.GridMatrices
+	Contour []float64 `json:"contour"`
+	XWidth  int       `json:"xWidth"`
+	YWidth  int       `json:"yWidth"`
+}
+
+// readGrid 读取 grid 命令输出的 JSON
+func readGrid(path string) (*gridFile, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	grid := &gridFile{}
+	if err := json.Unmarshal(content, grid); err != nil {
+		return nil, fmt.Errorf("%s: invalid json: %v", path, err)
+	}
+	if len(grid.Data) == 0 && len(grid.Contour) == 0 {
+		return nil, fmt.Errorf("%s: neither GridMatrices nor ContourRectangle", path)
+	}
+	return grid, nil
+}
+
+// contourRectangle ContourRectangle 形式的格网，分辨率由范围与宽高计算
+func (grid *gridFile) contourRectangle() *ordinarykriging.ContourRectangle {
+	return &ordinarykriging.ContourRectangle{
+		Contour:     grid.Contour,
+		XWidth:      grid.XWidth,
+		YWidth:      grid.YWidth,
+		Xlim:        grid.Xlim,
+		Ylim:        grid.Ylim,
+		Zlim:        grid.Zlim,
+		XResolution: (grid.Xlim[1] - grid.Xlim[0]) / float64(grid.XWidth),
+		YResolution: (grid.Ylim[1] - grid.Ylim[0]) / float64(grid.YWidth),
+	}
+}
+
+// raster 栅格形式的格网
+func (grid *gridFile) raster() *raster.Raster {
+	if len(grid.Data) > 0 {
+		return raster.FromGridMatrices(&grid.GridMatrices)
+	}
+	return raster.FromContourRectangle(grid.contourRectangle())
+}
+
+// parseBBox 解析 minX,minY,maxX,maxY
+func parseBBox(value string) ([4]float64, error) {
+	var bbox [4]float64
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return bbox, fmt.Errorf("invalid bbox %q", value)
+	}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return bbox, fmt.Errorf("invalid bbox %q", value)
+		}
+		bbox[i] = v
+	}
+	if bbox[0] >= bbox[2] || bbox[1] >= bbox[3] {
+		return bbox, fmt.Errorf("invalid bbox %q", value)
+	}
+	return bbox, nil
+}
+
+// writeJSON 写 JSON 文件，path 为空时输出到标准输出
+func writeJSON(path string, v interface{}) error {
+	// GeoJSON 属性为 map，使用标准库编码
+	content, err := stdjson.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		_, err = os.Stdout.Write(append(content, '\n'))
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0644)
+}
@@ -28,10 +28,15 @@ var cmd = &cobra.Command{
 			Complete documentation is available at https://github.com/lvisei/go-kriging`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Printf("Go Kriging Version: v%s \n", version)
-		// TODO:
+		cmd.Help()
 	},
 }
 
+func init() {
+	cmd.SilenceUsage = true
+	cmd.AddCommand(trainCmd, predictCmd, gridCmd, renderCmd, contourCmd, variogramCmd, validateCmd, simulateCmd, screenCmd, declusterCmd)
+}
+
 func execute() {
 	if err := cmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
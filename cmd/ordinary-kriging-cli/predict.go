@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var predictFlags = struct {
+	sampleFlags
+	modelPath string
+	output    string
+}{}
+
+var predictCmd = &cobra.Command{
+	Use:     "predict",
+	Short:   "Predict values and variance at points with a trained model",
+	Example: `  ordinary-kriging-cli predict -f model.json -i points.csv --x Lon --y Lat -o predicted.csv`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		variogram, err := readModel(predictFlags.modelPath)
+		if err != nil {
+			return err
+		}
+		data, err := predictFlags.sampleFlags.read(false)
+		if err != nil {
+			return err
+		}
+
+		out := os.Stdout
+		if predictFlags.output != "" {
+			if out, err = os.Create(predictFlags.output); err != nil {
+				return err
+			}
+			defer out.Close()
+		}
+
+		writer := csv.NewWriter(out)
+		writer.Write([]string{"x", "y", "value", "variance"})
+		for i := range data.X {
+			writer.Write([]string{
+				strconv.FormatFloat(data.X[i], 'g', -1, 64),
+				strconv.FormatFloat(data.Y[i], 'g', -1, 64),
+				strconv.FormatFloat(variogram.Predict(data.X[i], data.Y[i]), 'g', -1, 64),
+				strconv.FormatFloat(variogram.Variance(data.X[i], data.Y[i]), 'g', -1, 64),
+			})
+		}
+		writer.Flush()
+		return writer.Error()
+	},
+}
+
+func init() {
+	predictFlags.sampleFlags.register(predictCmd)
+	predictCmd.Flags().StringVarP(&predictFlags.modelPath, "model-file", "f", "model.json", "model file written by train")
+	predictCmd.Flags().StringVarP(&predictFlags.output, "output", "o", "", "output CSV, stdout if empty")
+}
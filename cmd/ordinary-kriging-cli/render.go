@@ -0,0 +1,307 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lvisei/go-kriging/canvas"
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/contour"
+	"github.com/lvisei/go-kriging/pkg/layout"
+	"github.com/lvisei/go-kriging/pkg/palette"
+	"github.com/lvisei/go-kriging/pkg/raster"
+	"github.com/lvisei/go-kriging/pkg/render"
+	"github.com/lvisei/go-kriging/pkg/vector"
+	"github.com/spf13/cobra"
+)
+
+// renderOptions render 命令的参数
+type renderOptions struct {
+	input      string
+	output     string
+	width      int
+	height     int
+	title      string
+	legend     bool
+	worldFile  bool
+	isolines   bool
+	polygon    string
+	modelPath  string
+	palette    string
+	classes    int
+	classify   string
+	breaks     []float64
+	nodata     string
+	subtitle   string
+	credits    string
+	font       string
+	scaleBar   bool
+	northArrow bool
+	projected  bool
+	labels     bool
+	basemap    string
+	resample   string
+	hillshade  bool
+	azimuth    float64
+	altitude   float64
+	zFactor    float64
+	shade      float64
+}
+
+var renderFlags = &renderOptions{}
+
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Render a JSON grid written by grid to PNG, or to vector SVG/PDF isobands, with palette and legend",
+	Example: `  ordinary-kriging-cli render -i grid.json --title "TEM_Avg" --credits "Data: CMA" -o grid.png
+  ordinary-kriging-cli render -i grid.json --polygon yn.json -f model.json --labels --basemap basemap.png -o grid.png
+  ordinary-kriging-cli render -i grid.json --polygon yn.json -f model.json --isolines --title "TEM_Avg" -o grid.pdf`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		grid, err := readGrid(renderFlags.input)
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(renderFlags.output)) {
+		case ".svg", ".pdf":
+			return renderVector(grid)
+		}
+
+		p, err := renderPalette(grid.raster())
+		if err != nil {
+			return err
+		}
+
+		xlim, ylim := grid.Xlim, grid.Ylim
+		width, height := renderFlags.width, renderFlags.height
+		if height <= 0 {
+			height = int(math.Round(float64(width) * (ylim[1] - ylim[0]) / (xlim[1] - xlim[0])))
+		}
+		l := &layout.Layout{
+			Width:      width,
+			Height:     height,
+			Title:      renderFlags.title,
+			Subtitle:   renderFlags.subtitle,
+			Credits:    renderFlags.credits,
+			FontPath:   renderFlags.font,
+			Palette:    p,
+			Legend:     renderFlags.legend,
+			ScaleBar:   renderFlags.scaleBar,
+			NorthArrow: renderFlags.northArrow,
+			// 范围在经纬度内时按经纬度计算比例尺
+			Geographic: !renderFlags.projected && xlim[0] >= -180 && xlim[1] <= 360 && ylim[0] >= -90 && ylim[1] <= 90,
+		}
+		frame, err := l.Frame(xlim, ylim)
+		if err != nil {
+			return err
+		}
+
+		ctx := canvas.NewCanvas(frame.Dx(), frame.Dy())
+		if renderFlags.basemap != "" {
+			background, err := canvas.LoadLocalImage(renderFlags.basemap)
+			if err != nil {
+				return err
+			}
+			ctx = ordinarykriging.NewBasemapCanvas(background, frame.Dx(), frame.Dy())
+		}
+		variogram := &ordinarykriging.Variogram{}
+		if renderFlags.modelPath != "" {
+			if variogram, err = readModel(renderFlags.modelPath); err != nil {
+				return err
+			}
+		}
+		method, err := raster.ParseMethod(renderFlags.resample)
+		if err != nil {
+			return err
+		}
+		opt := &render.Options{Width: frame.Dx(), Height: frame.Dy(), Xlim: xlim, Ylim: ylim, Method: method, Palette: p}
+		if renderFlags.hillshade {
+			opt.Hillshade = &raster.TerrainOptions{
+				Azimuth:    renderFlags.azimuth,
+				Altitude:   renderFlags.altitude,
+				ZFactor:    renderFlags.zFactor,
+				Geographic: l.Geographic,
+			}
+			opt.ShadeStrength = renderFlags.shade
+		}
+		overlay := &ordinarykriging.Overlay{
+			Points:       renderFlags.modelPath != "",
+			PointPalette: p,
+			Labels:       renderFlags.labels && renderFlags.modelPath != "",
+			FontPath:     renderFlags.font,
+		}
+		if renderFlags.polygon != "" {
+			if overlay.Boundary, err = readPolygon(renderFlags.polygon); err != nil {
+				return err
+			}
+			opt.Clip = overlay.Boundary
+		}
+		img, err := render.Render(grid.raster(), opt)
+		if err != nil {
+			return err
+		}
+		ctx.DrawImage(img, 0, 0)
+		if err := variogram.DrawOverlay(ctx, xlim, ylim, overlay); err != nil {
+			return err
+		}
+		if ctx, err = l.Compose(ctx.Image(), xlim, ylim); err != nil {
+			return err
+		}
+
+		if err := ctx.SavePNG(renderFlags.output); err != nil {
+			return err
+		}
+		if renderFlags.worldFile {
+			wf, err := l.WorldFile(xlim, ylim)
+			if err != nil {
+				return err
+			}
+			_, err = wf.WriteFile(renderFlags.output)
+			return err
+		}
+		return nil
+	},
+}
+
+// renderVector 以等值面绘制矢量地图，页面宽高为 --width、--height
+func renderVector(grid *gridFile) error {
+	r := grid.raster()
+	p, err := renderPalette(r)
+	if err != nil {
+		return err
+	}
+	breaks := p.Breaks
+	if !p.Classified() {
+		// 连续色带以 --classes 个等值面近似
+		breaks = contour.Breaks(p.Domain, renderFlags.classesOrDefault())
+	}
+	opt := &contour.BandOptions{}
+	if renderFlags.polygon != "" {
+		polygon, err := readPolygon(renderFlags.polygon)
+		if err != nil {
+			return err
+		}
+		opt.Clip = polygon
+	}
+	isobands, err := contour.Bands(r, breaks, opt)
+	if err != nil {
+		return err
+	}
+
+	m := &vector.Map{
+		Width:    float64(renderFlags.width),
+		Height:   float64(renderFlags.height),
+		Title:    renderFlags.title,
+		Isobands: isobands,
+		Palette:  p,
+		Legend:   renderFlags.legend,
+	}
+	if m.Height <= 0 {
+		m.Height = m.Width * 0.75
+	}
+	if renderFlags.isolines {
+		if m.Isolines, err = contour.Lines(r, breaks[1:len(breaks)-1]); err != nil {
+			return err
+		}
+	}
+	if renderFlags.modelPath != "" {
+		variogram, err := readModel(renderFlags.modelPath)
+		if err != nil {
+			return err
+		}
+		_, x, y := variogram.TrainingData()
+		for i := range x {
+			m.Samples = append(m.Samples, ordinarykriging.Point{x[i], y[i]})
+		}
+	}
+
+	out, err := os.Create(renderFlags.output)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if strings.EqualFold(filepath.Ext(renderFlags.output), ".pdf") {
+		err = vector.EncodePDF(out, m)
+	} else {
+		err = vector.EncodeSVG(out, m)
+	}
+	if err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// renderPalette 由 --palette、--classes、--classify、--breaks 生成 palette
+func renderPalette(r *raster.Raster) (*palette.Palette, error) {
+	ramp, err := palette.ParseRamp(renderFlags.palette)
+	if err != nil {
+		return nil, err
+	}
+	p := palette.New(ramp, r.Zlim())
+	switch {
+	case len(renderFlags.breaks) > 0:
+		p.Breaks = renderFlags.breaks
+	case renderFlags.classes > 0:
+		var values []float64
+		for _, value := range r.Data {
+			if !r.IsNodata(value) {
+				values = append(values, value)
+			}
+		}
+		if p.Breaks, err = palette.Classify(palette.Method(renderFlags.classify), values, renderFlags.classes); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if renderFlags.nodata != "" {
+		if p.Nodata, err = palette.ParseColor(renderFlags.nodata); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// classesOrDefault 连续色带时图例与等值面的级数
+func (f *renderOptions) classesOrDefault() int {
+	if f.classes > 0 {
+		return f.classes
+	}
+	return 10
+}
+
+func init() {
+	renderCmd.Flags().StringVarP(&renderFlags.input, "input", "i", "", "JSON grid written by grid")
+	renderCmd.Flags().StringVarP(&renderFlags.output, "output", "o", "grid.png", "output PNG, or vector SVG or PDF by extension")
+	renderCmd.Flags().IntVar(&renderFlags.width, "width", 800, "image width, page width in points for PDF")
+	renderCmd.Flags().IntVar(&renderFlags.height, "height", 0, "image height, by the grid aspect ratio if 0")
+	renderCmd.Flags().StringVar(&renderFlags.title, "title", "", "map title")
+	renderCmd.Flags().BoolVar(&renderFlags.legend, "legend", true, "draw legend")
+	renderCmd.Flags().BoolVar(&renderFlags.worldFile, "world-file", false, "write a world file (.pgw) next to the PNG for GIS")
+	renderCmd.Flags().BoolVar(&renderFlags.isolines, "isolines", false, "SVG/PDF isolines at the class breaks")
+	renderCmd.Flags().StringVar(&renderFlags.polygon, "polygon", "", "boundary passed to grid, clipping and outlined on PNG, clipping the SVG/PDF isobands")
+	renderCmd.Flags().StringVarP(&renderFlags.modelPath, "model-file", "f", "", "model file written by train, its samples are drawn as points")
+	renderCmd.Flags().StringVar(&renderFlags.palette, "palette", "default", "color ramp name ("+strings.Join(palette.Names(), ", ")+"), _r suffix to reverse, or stops like 0:#2892c7,0.5:#fafa64,1:#e81014")
+	renderCmd.Flags().IntVar(&renderFlags.classes, "classes", 10, "number of classes, continuous colors if 0")
+	renderCmd.Flags().StringVar(&renderFlags.classify, "classify", string(palette.EqualInterval), "classification, equal-interval, quantile or jenks")
+	renderCmd.Flags().Float64SliceVar(&renderFlags.breaks, "breaks", nil, "class breaks, instead of --classes")
+	renderCmd.Flags().StringVar(&renderFlags.nodata, "nodata-color", "", "color of nodata cells like #cccccc, transparent if empty")
+	renderCmd.Flags().StringVar(&renderFlags.subtitle, "subtitle", "", "map subtitle")
+	renderCmd.Flags().StringVar(&renderFlags.credits, "credits", "", "data source credits at the bottom right")
+	renderCmd.Flags().StringVar(&renderFlags.font, "font", "", "PNG TrueType font file, built-in bitmap font if empty")
+	renderCmd.Flags().BoolVar(&renderFlags.scaleBar, "scale-bar", true, "PNG scale bar")
+	renderCmd.Flags().BoolVar(&renderFlags.northArrow, "north-arrow", true, "PNG north arrow")
+	renderCmd.Flags().BoolVar(&renderFlags.projected, "projected", false, "coordinates are projected meters, longitude and latitude are detected by the extent otherwise")
+	renderCmd.Flags().BoolVar(&renderFlags.labels, "labels", false, "PNG sample values next to the points of --model-file")
+	renderCmd.Flags().StringVar(&renderFlags.basemap, "basemap", "", "PNG background image covering the grid extent")
+	renderCmd.Flags().StringVar(&renderFlags.resample, "resample", string(raster.Bilinear), "PNG resampling, nearest, bilinear or bicubic")
+	renderCmd.Flags().BoolVar(&renderFlags.hillshade, "hillshade", false, "PNG hillshading for terrain-like values such as elevation or groundwater level")
+	renderCmd.Flags().Float64Var(&renderFlags.azimuth, "sun-azimuth", 315, "hillshade sun azimuth in degrees clockwise from north")
+	renderCmd.Flags().Float64Var(&renderFlags.altitude, "sun-altitude", 45, "hillshade sun altitude in degrees above the horizon")
+	renderCmd.Flags().Float64Var(&renderFlags.zFactor, "z-factor", 1, "hillshade vertical exaggeration, values are meters when coordinates are longitude and latitude")
+	renderCmd.Flags().Float64Var(&renderFlags.shade, "shade-strength", 0.6, "hillshade strength from 0 to 1")
+	renderCmd.MarkFlagRequired("input")
+}
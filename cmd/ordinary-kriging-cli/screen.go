@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/spf13/cobra"
+)
+
+var screenFlags = struct {
+	sampleFlags
+	bbox      string
+	polygon   string
+	outliers  string
+	neighbors int
+	threshold float64
+	model     string
+	sigma2    float64
+	alpha     float64
+	output    string
+	filtered  string
+}{}
+
+var screenCmd = &cobra.Command{
+	Use:   "screen",
+	Short: "Check sample points for non-finite values, points outside a bbox or polygon and spatial outliers",
+	Example: `  ordinary-kriging-cli screen -i 2045.csv --x Lon --y Lat --value TEM_Avg --polygon yn.json -o report.json --filtered clean.csv
+  ordinary-kriging-cli screen -i 2045.csv --x Lon --y Lat --value TEM_Avg --bbox 97,21,107,29.5 --outliers kriging --threshold 5`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := screenFlags.sampleFlags.read(true)
+		if err != nil {
+			return err
+		}
+		method, err := ordinarykriging.ParseOutlierMethod(screenFlags.outliers)
+		if err != nil {
+			return err
+		}
+		opt := &ordinarykriging.ScreenOptions{
+			Outliers:  method,
+			Neighbors: screenFlags.neighbors,
+			Threshold: screenFlags.threshold,
+			Model:     ordinarykriging.ModelType(screenFlags.model),
+			Sigma2:    screenFlags.sigma2,
+			Alpha:     screenFlags.alpha,
+		}
+		if screenFlags.bbox != "" {
+			bbox, err := parseBBox(screenFlags.bbox)
+			if err != nil {
+				return err
+			}
+			opt.BBox = &bbox
+		}
+		if screenFlags.polygon != "" {
+			if opt.Polygon, err = readPolygon(screenFlags.polygon); err != nil {
+				return err
+			}
+		}
+
+		report, err := ordinarykriging.Screen(data.Values, data.X, data.Y, opt)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("n: %d, non-finite: %d, outside bbox: %d, outside polygon: %d, outliers: %d\n",
+			report.N, report.NonFinite, report.OutsideBBox, report.OutsidePolygon, report.Outliers)
+		for _, flag := range report.Flags {
+			if flag.Issue == ordinarykriging.IssueOutlier {
+				fmt.Printf("%d (%v, %v) %v: %s, expected %v, score %.2f\n", flag.Index, flag.X, flag.Y, flag.Value, flag.Issue, flag.Expected, flag.Score)
+			} else {
+				fmt.Printf("%d (%v, %v) %v: %s\n", flag.Index, flag.X, flag.Y, flag.Value, flag.Issue)
+			}
+		}
+
+		if screenFlags.output != "" {
+			if err := writeJSON(screenFlags.output, report); err != nil {
+				return err
+			}
+		}
+		if screenFlags.filtered == "" {
+			return nil
+		}
+		t, x, y := report.Filter(data.Values, data.X, data.Y)
+		return writeSamplesCSV(screenFlags.filtered, t, x, y)
+	},
+}
+
+// writeSamplesCSV 写 x,y,value 三列的样本 CSV
+func writeSamplesCSV(path string, t, x, y []float64) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	writer := csv.NewWriter(out)
+	writer.Write([]string{"x", "y", "value"})
+	for i := range t {
+		writer.Write([]string{
+			strconv.FormatFloat(x[i], 'g', -1, 64),
+			strconv.FormatFloat(y[i], 'g', -1, 64),
+			strconv.FormatFloat(t[i], 'g', -1, 64),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func init() {
+	screenFlags.sampleFlags.register(screenCmd)
+	screenCmd.Flags().StringVar(&screenFlags.bbox, "bbox", "", "expected extent minX,minY,maxX,maxY")
+	screenCmd.Flags().StringVar(&screenFlags.polygon, "polygon", "", "expected boundary, polygon Shapefile or GeoJSON")
+	screenCmd.Flags().StringVar(&screenFlags.outliers, "outliers", string(ordinarykriging.OutlierLocalMedian), "spatial outlier check, local-median, kriging or none")
+	screenCmd.Flags().IntVar(&screenFlags.neighbors, "neighbors", 8, "nearest samples of the local median")
+	screenCmd.Flags().Float64Var(&screenFlags.threshold, "threshold", 3.5, "robust z score of an outlier")
+	screenCmd.Flags().StringVarP(&screenFlags.model, "model", "m", string(ordinarykriging.Exponential), "variogram model of the leave-one-out kriging")
+	screenCmd.Flags().Float64Var(&screenFlags.sigma2, "sigma2", 0, "variance parameter of the leave-one-out kriging")
+	screenCmd.Flags().Float64Var(&screenFlags.alpha, "alpha", 100, "prior of the leave-one-out kriging variogram model")
+	screenCmd.Flags().StringVarP(&screenFlags.output, "output", "o", "", "JSON report")
+	screenCmd.Flags().StringVar(&screenFlags.filtered, "filtered", "", "CSV of the samples without issues, columns x, y and value")
+}
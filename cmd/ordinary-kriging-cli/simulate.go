@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/asciigrid"
+	"github.com/lvisei/go-kriging/pkg/geotiff"
+	"github.com/lvisei/go-kriging/pkg/raster"
+	"github.com/spf13/cobra"
+)
+
+var simulateFlags = struct {
+	modelPath    string
+	bbox         string
+	polygon      string
+	resolution   float64
+	realizations int
+	seed         int64
+	maxNeighbors int
+	radius       float64
+	percentiles  []float64
+	thresholds   []float64
+	all          bool
+	format       string
+	outputDir    string
+	epsg         int
+}{}
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Sequential Gaussian simulation of equiprobable realizations with E-type mean, percentile and exceedance grids",
+	Example: `  ordinary-kriging-cli simulate -f model.json --polygon yn.json --resolution 0.05 -n 100 --seed 1 --threshold 15 -d sgs
+  ordinary-kriging-cli simulate -f model.json --bbox 97,21,107,29.5 --resolution 0.1 -n 20 --realizations --format geotiff -d sgs`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		variogram, err := readModel(simulateFlags.modelPath)
+		if err != nil {
+			return err
+		}
+		format, err := outputFormat(simulateFlags.format, "")
+		if err != nil {
+			return err
+		}
+		if simulateFlags.resolution <= 0 {
+			return fmt.Errorf("--resolution is required")
+		}
+		opt := &ordinarykriging.SimulationOptions{
+			Realizations: simulateFlags.realizations,
+			Seed:         simulateFlags.seed,
+			MaxNeighbors: simulateFlags.maxNeighbors,
+			Radius:       simulateFlags.radius,
+		}
+
+		var simulation *ordinarykriging.Simulation
+		switch {
+		case simulateFlags.polygon != "":
+			polygon, err := readPolygon(simulateFlags.polygon)
+			if err != nil {
+				return err
+			}
+			simulation, err = variogram.Simulate(polygon, simulateFlags.resolution, opt)
+			if err != nil {
+				return err
+			}
+		case simulateFlags.bbox != "":
+			bbox, err := parseBBox(simulateFlags.bbox)
+			if err != nil {
+				return err
+			}
+			simulation, err = variogram.SimulateWithBBox(bbox, simulateFlags.resolution, opt)
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("--bbox or --polygon is required")
+		}
+
+		if err := os.MkdirAll(simulateFlags.outputDir, 0755); err != nil {
+			return err
+		}
+		write := func(name string, gridMatrices *ordinarykriging.GridMatrices) error {
+			return writeSimulationGrid(filepath.Join(simulateFlags.outputDir, name), format, gridMatrices)
+		}
+		if err := write("mean", simulation.Mean()); err != nil {
+			return err
+		}
+		for _, p := range simulateFlags.percentiles {
+			if err := write("p"+strconv.FormatFloat(p, 'g', -1, 64), simulation.Percentile(p)); err != nil {
+				return err
+			}
+		}
+		for _, threshold := range simulateFlags.thresholds {
+			if err := write("exceedance-"+strconv.FormatFloat(threshold, 'g', -1, 64), simulation.Exceedance(threshold)); err != nil {
+				return err
+			}
+		}
+		if simulateFlags.all {
+			for i, realization := range simulation.Realizations {
+				if err := write(fmt.Sprintf("realization-%03d", i+1), realization); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// writeSimulationGrid 按格式写模拟的网格，文件名加上格式的扩展名
+func writeSimulationGrid(path, format string, gridMatrices *ordinarykriging.GridMatrices) error {
+	switch format {
+	case formatJSON:
+		return writeJSON(path+".json", gridMatrices)
+	case formatGeoTIFF:
+		path += ".tif"
+	case formatASCII:
+		path += ".asc"
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	rst := raster.FromGridMatrices(gridMatrices)
+	if format == formatGeoTIFF {
+		err = geotiff.Encode(out, rst, &geotiff.Options{EPSG: simulateFlags.epsg})
+	} else {
+		err = asciigrid.Encode(out, rst)
+	}
+	if err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func init() {
+	simulateCmd.Flags().StringVarP(&simulateFlags.modelPath, "model-file", "f", "model.json", "model file written by train")
+	simulateCmd.Flags().StringVar(&simulateFlags.bbox, "bbox", "", "minX,minY,maxX,maxY")
+	simulateCmd.Flags().StringVar(&simulateFlags.polygon, "polygon", "", "boundary file to clip the grid, polygon Shapefile or GeoJSON")
+	simulateCmd.Flags().Float64Var(&simulateFlags.resolution, "resolution", 0, "grid cell size")
+	simulateCmd.Flags().IntVarP(&simulateFlags.realizations, "number", "n", 10, "number of realizations")
+	simulateCmd.Flags().Int64Var(&simulateFlags.seed, "seed", 1, "random seed, the same seed gives the same realizations")
+	simulateCmd.Flags().IntVar(&simulateFlags.maxNeighbors, "max-neighbors", 16, "maximum samples and simulated nodes in each local kriging")
+	simulateCmd.Flags().Float64Var(&simulateFlags.radius, "radius", 0, "neighbor search radius, the variogram range if 0")
+	simulateCmd.Flags().Float64SliceVar(&simulateFlags.percentiles, "percentile", []float64{10, 50, 90}, "percentile grids, 0 to 100")
+	simulateCmd.Flags().Float64SliceVar(&simulateFlags.thresholds, "threshold", nil, "probability of exceedance grids of the thresholds")
+	simulateCmd.Flags().BoolVar(&simulateFlags.all, "realizations", false, "also write every realization")
+	simulateCmd.Flags().StringVar(&simulateFlags.format, "format", formatJSON, "geotiff, ascii or json")
+	simulateCmd.Flags().StringVarP(&simulateFlags.outputDir, "output-dir", "d", "simulation", "output directory")
+	simulateCmd.Flags().IntVar(&simulateFlags.epsg, "epsg", geotiff.DefaultEPSG, "GeoTIFF EPSG code of the model coordinates")
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/dataset"
+	"github.com/lvisei/go-kriging/pkg/json"
+	"github.com/spf13/cobra"
+)
+
+// modelFlags 训练模型参数
+type modelFlags struct {
+	model     string
+	sigma2    float64
+	alpha     float64
+	transform string
+	shift     float64
+	lambda    float64
+	median    bool
+	tailLower string
+	tailUpper string
+	tailMin   float64
+	tailMax   float64
+
+	duplicates         string
+	duplicateTolerance float64
+	decluster          string
+}
+
+func (f *modelFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.model, "model", "m", string(ordinarykriging.Exponential), "variogram model, gaussian, exponential or spherical")
+	cmd.Flags().Float64Var(&f.sigma2, "sigma2", 0, "variance parameter of the gaussian process")
+	cmd.Flags().Float64Var(&f.alpha, "alpha", 100, "prior of the variogram model")
+	cmd.Flags().StringVar(&f.transform, "transform", "none", "value transform before training, none, log, boxcox or normal-score")
+	cmd.Flags().Float64Var(&f.shift, "shift", 0, "added to values before the log or Box-Cox transform")
+	cmd.Flags().Float64Var(&f.lambda, "lambda", 0, "Box-Cox lambda, estimated from the samples if 0")
+	cmd.Flags().BoolVar(&f.median, "median", false, "back transform to the median instead of the bias-corrected mean")
+	cmd.Flags().StringVar(&f.tailLower, "tail-lower", "", "normal score lower tail, clamp, linear or power")
+	cmd.Flags().StringVar(&f.tailUpper, "tail-upper", "", "normal score upper tail, clamp, linear or power")
+	cmd.Flags().Float64Var(&f.tailMin, "tail-min", 0, "lower bound of the normal score tails")
+	cmd.Flags().Float64Var(&f.tailMax, "tail-max", 0, "upper bound of the normal score tails, the sample range extended by 10% if not above --tail-min")
+	cmd.Flags().StringVar(&f.duplicates, "duplicates", string(ordinarykriging.DuplicateMean), "samples at the same location, mean, median, first, last or error")
+	cmd.Flags().Float64Var(&f.duplicateTolerance, "duplicate-tolerance", 0, "samples within this distance are at the same location, only identical coordinates if 0")
+	cmd.Flags().StringVar(&f.decluster, "decluster", "none", "declustering weights of the normal score transform and simulation, none, cell or polygonal")
+}
+
+// newOrdinary 按参数合并重合的样本，设置解聚权重与变换的模型
+func (f *modelFlags) newOrdinary(data *dataset.Samples) (*ordinarykriging.Variogram, error) {
+	ordinaryKriging := ordinarykriging.NewOrdinary(data.Values, data.X, data.Y)
+	policy, err := ordinarykriging.ParseDuplicatePolicy(f.duplicates)
+	if err != nil {
+		return nil, err
+	}
+	report, err := ordinaryKriging.MergeDuplicates(f.duplicateTolerance, policy)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range report.Groups {
+		fmt.Printf("merged samples %v at (%v, %v), values %v -> %v\n", group.Indexes, group.X, group.Y, group.Values, group.Value)
+	}
+
+	method, err := ordinarykriging.ParseDeclusterMethod(f.decluster)
+	if err != nil {
+		return nil, err
+	}
+	if method != ordinarykriging.DeclusterNone {
+		declustering, err := decluster(ordinaryKriging, method)
+		if err != nil {
+			return nil, err
+		}
+		ordinaryKriging.Weights = declustering.Weights
+		fmt.Printf("%s declustering, mean: %v, declustered mean: %v\n", method, declustering.NaiveMean, declustering.Mean)
+	}
+
+	transformType, err := ordinarykriging.ParseTransformType(f.transform)
+	if err != nil {
+		return nil, err
+	}
+	if transformType == ordinarykriging.TransformNone {
+		return ordinaryKriging, nil
+	}
+	lower, err := ordinarykriging.ParseTail(f.tailLower)
+	if err != nil {
+		return nil, err
+	}
+	upper, err := ordinarykriging.ParseTail(f.tailUpper)
+	if err != nil {
+		return nil, err
+	}
+	ordinaryKriging.Transform = &ordinarykriging.Transform{
+		Type:   transformType,
+		Shift:  f.shift,
+		Lambda: f.lambda,
+		Median: f.median,
+		Tails:  ordinarykriging.Tails{Lower: lower, Upper: upper, Min: f.tailMin, Max: f.tailMax},
+	}
+	return ordinaryKriging, nil
+}
+
+var trainFlags = struct {
+	sampleFlags
+	modelFlags
+	output string
+}{}
+
+var trainCmd = &cobra.Command{
+	Use:     "train",
+	Short:   "Train a model from sample points and save it to a model file",
+	Example: `  ordinary-kriging-cli train -i 2045.csv --x Lon --y Lat --value TEM_Avg -m spherical -o model.json`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := trainFlags.sampleFlags.read(true)
+		if err != nil {
+			return err
+		}
+
+		ordinaryKriging, err := trainFlags.modelFlags.newOrdinary(data)
+		if err != nil {
+			return err
+		}
+		variogram, err := ordinaryKriging.Train(ordinarykriging.ModelType(trainFlags.model), trainFlags.sigma2, trainFlags.alpha)
+		if err != nil {
+			return err
+		}
+
+		content, err := json.Marshal(variogram)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(trainFlags.output, content, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("model: %s, n: %d, nugget: %v, range: %v, sill: %v\n",
+			variogram.Model, variogram.N, variogram.Nugget, variogram.Range, variogram.Sill)
+		if variogram.Transform != nil && variogram.Transform.Type == ordinarykriging.TransformBoxCox {
+			fmt.Printf("box-cox lambda: %v\n", variogram.Transform.BoxCoxLambda())
+		}
+		return nil
+	},
+}
+
+func init() {
+	trainFlags.sampleFlags.register(trainCmd)
+	trainFlags.modelFlags.register(trainCmd)
+	trainCmd.Flags().StringVarP(&trainFlags.output, "output", "o", "model.json", "model file")
+}
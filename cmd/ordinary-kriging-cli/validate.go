@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/spf13/cobra"
+)
+
+var validateFlags = struct {
+	sampleFlags
+	modelFlags
+	folds  int
+	output string
+}{}
+
+var validateCmd = &cobra.Command{
+	Use:     "validate",
+	Short:   "Cross-validate a variogram model on sample points",
+	Example: `  ordinary-kriging-cli validate -i 2045.csv --x Lon --y Lat --value TEM_Avg -m spherical --folds 10`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := validateFlags.sampleFlags.read(true)
+		if err != nil {
+			return err
+		}
+
+		ordinaryKriging, err := validateFlags.modelFlags.newOrdinary(data)
+		if err != nil {
+			return err
+		}
+		report, err := ordinaryKriging.CrossValidate(ordinarykriging.ModelType(validateFlags.model),
+			validateFlags.sigma2, validateFlags.alpha, validateFlags.folds)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("model: %s, n: %d, folds: %d\n", validateFlags.model, report.N, report.Folds)
+		fmt.Printf("mean error: %v\n", report.MeanError)
+		fmt.Printf("mean absolute error: %v\n", report.MeanAbsoluteError)
+		fmt.Printf("root mean square error: %v\n", report.RootMeanSquareError)
+		fmt.Printf("correlation: %v\n", report.Correlation)
+
+		if validateFlags.output == "" {
+			return nil
+		}
+		return writeJSON(validateFlags.output, report)
+	},
+}
+
+func init() {
+	validateFlags.sampleFlags.register(validateCmd)
+	validateFlags.modelFlags.register(validateCmd)
+	validateCmd.Flags().IntVar(&validateFlags.folds, "folds", 0, "number of folds, leave-one-out if 0")
+	validateCmd.Flags().StringVarP(&validateFlags.output, "output", "o", "", "JSON report with every sample")
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/lvisei/go-kriging/canvas"
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/spf13/cobra"
+)
+
+var variogramFlags = struct {
+	modelPath string
+	output    string
+}{}
+
+var variogramCmd = &cobra.Command{
+	Use:     "variogram",
+	Short:   "Print the experimental and fitted variogram of a model, optionally plot it to PNG",
+	Example: `  ordinary-kriging-cli variogram -f model.json -o variogram.png`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		variogram, err := readModel(variogramFlags.modelPath)
+		if err != nil {
+			return err
+		}
+		lag, semi, err := variogram.Experimental()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("model: %s, nugget: %v, range: %v, sill: %v\n", variogram.Model, variogram.Nugget, variogram.Range, variogram.Sill)
+		fmt.Printf("%16s %16s %16s\n", "lag", "experimental", "fitted")
+		for i := range lag {
+			fmt.Printf("%16.6f %16.6f %16.6f\n", lag[i], semi[i], variogram.Semivariance(lag[i]))
+		}
+
+		if variogramFlags.output == "" {
+			return nil
+		}
+		return plotVariogram(variogram, lag, semi).SavePNG(variogramFlags.output)
+	},
+}
+
+// plotVariogram 绘制实验变异函数散点与拟合曲线
+func plotVariogram(variogram *ordinarykriging.Variogram, lag, semi []float64) *canvas.Canvas {
+	const width, height, margin = 640.0, 400.0, 50.0
+	ctx := canvas.NewCanvas(width, height)
+	ctx.DrawRect(0, 0, width, height, color.White)
+
+	const samples = 100
+	maxLag := lag[len(lag)-1]
+	fitted := make([]float64, samples+1)
+	ylim := [2]float64{math.Min(0, variogram.Nugget), 0}
+	for i := range fitted {
+		fitted[i] = variogram.Semivariance(maxLag * float64(i) / samples)
+		ylim[0] = math.Min(ylim[0], fitted[i])
+		ylim[1] = math.Max(ylim[1], fitted[i])
+	}
+	for _, v := range semi {
+		ylim[0] = math.Min(ylim[0], v)
+		ylim[1] = math.Max(ylim[1], v)
+	}
+	if ylim[1] == ylim[0] {
+		ylim[1] = ylim[0] + 1
+	}
+
+	toCanvas := func(h, v float64) [2]float64 {
+		return [2]float64{
+			margin + (width-2*margin)*h/maxLag,
+			height - margin - (height-2*margin)*(v-ylim[0])/(ylim[1]-ylim[0]),
+		}
+	}
+
+	// 坐标轴
+	ctx.DrawPath([][2]float64{{margin, margin}, {margin, height - margin}, {width - margin, height - margin}}, color.Black, 1)
+	ctx.DrawText(&canvas.TextConfig{Text: "lag distance", Color: color.Black, OffsetX: width / 2, OffsetY: height - margin/3, AlignX: 0.5})
+	ctx.DrawText(&canvas.TextConfig{Text: "semivariance", Color: color.Black, OffsetX: margin, OffsetY: margin / 2, AlignX: 0.5})
+	ctx.DrawText(&canvas.TextConfig{Text: fmt.Sprintf("%.4g", maxLag), Color: color.Black, OffsetX: width - margin, OffsetY: height - margin + 15, AlignX: 0.5})
+	ctx.DrawText(&canvas.TextConfig{Text: fmt.Sprintf("%.4g", ylim[1]), Color: color.Black, OffsetX: margin - 5, OffsetY: margin, AlignX: 1, AlignY: 0.5})
+	ctx.DrawText(&canvas.TextConfig{Text: fmt.Sprintf("%.4g", ylim[0]), Color: color.Black, OffsetX: margin - 5, OffsetY: height - margin, AlignX: 1, AlignY: 0.5})
+
+	curve := make([][2]float64, len(fitted))
+	for i, v := range fitted {
+		curve[i] = toCanvas(maxLag*float64(i)/samples, v)
+	}
+	ctx.DrawPath(curve, color.RGBA{R: 232, G: 16, B: 20, A: 255}, 2)
+	for i := range lag {
+		point := toCanvas(lag[i], semi[i])
+		ctx.DrawCircle(point[0], point[1], 4, color.RGBA{R: 40, G: 146, B: 199, A: 255})
+	}
+
+	return ctx
+}
+
+func init() {
+	variogramCmd.Flags().StringVarP(&variogramFlags.modelPath, "model-file", "f", "model.json", "model file written by train")
+	variogramCmd.Flags().StringVarP(&variogramFlags.output, "output", "o", "", "variogram plot PNG")
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lvisei/go-kriging/pkg/asciigrid"
+	"github.com/lvisei/go-kriging/pkg/geojson"
+	"github.com/lvisei/go-kriging/pkg/geotiff"
+	"github.com/lvisei/go-kriging/pkg/json"
+	"github.com/lvisei/go-kriging/pkg/raster"
+)
+
+// 栅格数据下载格式
+const (
+	formatGeoTIFF = "geotiff"
+	formatASCII   = "ascii"
+	formatJSON    = "json"
+)
+
+// coverageHandler 下载插值后的栅格数据
+// GET  /coverage?layer=tem&bbox=minX,minY,maxX,maxY&resolution=0.01&format=geotiff
+// POST /coverage?layer=tem&resolution=0.01&format=ascii 请求体为 GeoJSON 边界，按多面裁剪
+// 坐标参考系为 CRS:84，bbox 缺省时为图层范围，可用 width 指定 x 方向的格网数代替 resolution
+func coverageHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	l, ok := store.get(query.Get("layer"))
+	if !ok {
+		http.Error(w, fmt.Sprintf("layer %q not defined", query.Get("layer")), http.StatusNotFound)
+		return
+	}
+
+	format := strings.ToLower(query.Get("format"))
+	if format == "" {
+		format = formatGeoTIFF
+	}
+	if format != formatGeoTIFF && format != formatASCII && format != formatJSON {
+		http.Error(w, fmt.Sprintf("unsupported format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	bbox := l.BBox
+	if value := query.Get("bbox"); value != "" {
+		var err error
+		if bbox, err = parseBBox(value, crs84); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	resolution, err := coverageResolution(query.Get("resolution"), query.Get("width"), bbox)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var data interface{}
+	var rst *raster.Raster
+	switch r.Method {
+	case http.MethodGet:
+		geoXWidth, geoYWidth := bbox[2]-bbox[0], bbox[3]-bbox[1]
+		if !(geoXWidth > 0 && geoYWidth > 0) {
+			http.Error(w, fmt.Sprintf("empty bbox %v", bbox), http.StatusBadRequest)
+			return
+		}
+		// 在 float64 中检查，过小的 resolution 使格点数溢出 int
+		xWidth := math.Ceil(geoXWidth / resolution)
+		yWidth := math.Ceil(xWidth * geoYWidth / geoXWidth)
+		if !(xWidth*yWidth <= maxMapSize*maxMapSize) {
+			http.Error(w, fmt.Sprintf("coverage too large %.0fx%.0f", xWidth, yWidth), http.StatusBadRequest)
+			return
+		}
+		if err := checkPredictWork(int(xWidth)*int(yWidth), l); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		contourRectangle := predictRectangle(l, crs84, bbox, int(xWidth), int(yWidth))
+		data, rst = contourRectangle, raster.FromContourRectangle(contourRectangle)
+	case http.MethodPost:
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		multiPolygon, err := geojson.ReadMultiPolygon(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// 与 GET 相同限制格点数，GridMultiPolygon 为每个格点启动一个 goroutine
+		extent := multiPolygon.BBox()
+		xWidth := math.Ceil((extent[2]-extent[0])/resolution) + 1
+		yWidth := math.Ceil((extent[3]-extent[1])/resolution) + 1
+		if !(xWidth*yWidth <= maxMapSize*maxMapSize) {
+			http.Error(w, fmt.Sprintf("coverage too large %.0fx%.0f", xWidth, yWidth), http.StatusBadRequest)
+			return
+		}
+		if err := checkPredictWork(int(xWidth)*int(yWidth), l); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		gridMatrices := l.Variogram.GridMultiPolygon(multiPolygon, resolution)
+		data, rst = gridMatrices, raster.FromGridMatrices(gridMatrices)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch format {
+	case formatGeoTIFF:
+		w.Header().Set("Content-Type", "image/tiff")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.tif"`, l.Name))
+		err = geotiff.Encode(w, rst, nil)
+	case formatASCII:
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.asc"`, l.Name))
+		err = asciigrid.Encode(w, rst)
+	case formatJSON:
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(data)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// coverageResolution 格网大小，由 resolution 或 x 方向格网数 width 给出
+func coverageResolution(resolution, width string, bbox [4]float64) (float64, error) {
+	if resolution != "" {
+		v, err := strconv.ParseFloat(resolution, 64)
+		if err != nil || !(v > 0) || math.IsInf(v, 1) {
+			return 0, fmt.Errorf("invalid resolution %q", resolution)
+		}
+		return v, nil
+	}
+	if width != "" {
+		v, err := strconv.Atoi(width)
+		if err != nil || v <= 0 || v > maxMapSize {
+			return 0, fmt.Errorf("invalid width %q", width)
+		}
+		return (bbox[2] - bbox[0]) / float64(v), nil
+	}
+	return 0, fmt.Errorf("missing resolution or width")
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+)
+
+// putTestLayer 以 3 个样本训练的图层
+func putTestLayer(t *testing.T, name string, bbox [4]float64) *layer {
+	variogram := ordinarykriging.NewOrdinary([]float64{1, 2, 3}, []float64{100, 101, 102}, []float64{25, 26, 25.5})
+	if _, err := variogram.Train(ordinarykriging.Exponential, 0, 100); err != nil {
+		t.Fatal(err)
+	}
+	l := &layer{Name: name, Model: ordinarykriging.Exponential, BBox: bbox, Variogram: variogram}
+	store.put(l)
+	return l
+}
+
+func TestCoverageHandler_Size(t *testing.T) {
+	putTestLayer(t, "coverage", [4]float64{100, 25, 102, 26})
+	putTestLayer(t, "flat", [4]float64{100, 25, 102, 25})
+	for _, test := range []struct {
+		query  string
+		status int
+	}{
+		{"layer=coverage&resolution=0.1&format=json", http.StatusOK},
+		{"layer=coverage&resolution=1e-300", http.StatusBadRequest},
+		{"layer=coverage&resolution=NaN", http.StatusBadRequest},
+		{"layer=coverage&resolution=1e-5", http.StatusBadRequest},
+		{"layer=flat&resolution=0.1", http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		coverageHandler(rec, httptest.NewRequest(http.MethodGet, "/coverage?"+test.query, nil))
+		if rec.Code != test.status {
+			t.Errorf("%s: status %d, want %d: %s", test.query, rec.Code, test.status, rec.Body)
+		}
+	}
+}
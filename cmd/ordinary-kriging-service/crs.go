@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 支持的坐标参考系，训练数据默认为经纬度
+const (
+	crs84    = "CRS:84"    // 经度、纬度
+	epsg4326 = "EPSG:4326" // WMS 1.3.0 中轴顺序为纬度、经度
+	epsg3857 = "EPSG:3857" // Web 墨卡托
+)
+
+const earthRadius = 6378137.0
+
+const maxMercatorLatitude = 85.0511287798
+
+var supportedCRS = []string{crs84, epsg4326, epsg3857}
+
+// isSupportedCRS 是否支持的坐标参考系
+func isSupportedCRS(crs string) bool {
+	for _, item := range supportedCRS {
+		if item == crs {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBBox 解析 BBOX 参数为 [minX, minY, maxX, maxY]
+// EPSG:4326 按纬度、经度的轴顺序给出，这里转换为经度、纬度
+func parseBBox(value, crs string) ([4]float64, error) {
+	var bbox [4]float64
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return bbox, fmt.Errorf("invalid bbox %q", value)
+	}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return bbox, fmt.Errorf("invalid bbox %q", value)
+		}
+		bbox[i] = v
+	}
+	if crs == epsg4326 {
+		bbox = [4]float64{bbox[1], bbox[0], bbox[3], bbox[2]}
+	}
+	if bbox[0] >= bbox[2] || bbox[1] >= bbox[3] {
+		return bbox, fmt.Errorf("invalid bbox %q", value)
+	}
+	return bbox, nil
+}
+
+// toLonLat 坐标参考系下的坐标转经纬度
+func toLonLat(crs string, x, y float64) (float64, float64) {
+	if crs == epsg3857 {
+		lon := x / earthRadius * 180 / math.Pi
+		lat := (2*math.Atan(math.Exp(y/earthRadius)) - math.Pi/2) * 180 / math.Pi
+		return lon, lat
+	}
+	return x, y
+}
+
+// fromLonLat 经纬度转坐标参考系下的坐标
+func fromLonLat(crs string, lon, lat float64) (float64, float64) {
+	if crs == epsg3857 {
+		lat = math.Max(-maxMercatorLatitude, math.Min(maxMercatorLatitude, lat))
+		x := lon * math.Pi / 180 * earthRadius
+		y := math.Log(math.Tan(math.Pi/4+lat*math.Pi/360)) * earthRadius
+		return x, y
+	}
+	return lon, lat
+}
+
+// projectBBox 经纬度范围转坐标参考系下的范围
+func projectBBox(crs string, bbox [4]float64) [4]float64 {
+	minX, minY := fromLonLat(crs, bbox[0], bbox[1])
+	maxX, maxY := fromLonLat(crs, bbox[2], bbox[3])
+	return [4]float64{minX, minY, maxX, maxY}
+}
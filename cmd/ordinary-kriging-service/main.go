@@ -5,6 +5,9 @@ import (
 	"log"
 	"net/http"
 	"time"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/json"
 )
 
 func main() {
@@ -12,6 +15,8 @@ func main() {
 	http.HandleFunc("/train", trainHandler)
 	http.HandleFunc("/grid", gridHandler)
 	http.HandleFunc("/grid-png", gridPngHandler)
+	http.HandleFunc("/wms", wmsHandler)
+	http.HandleFunc("/coverage", coverageHandler)
 	server := &http.Server{
 		Addr:           ":8888",
 		ReadTimeout:    10 * time.Second,
@@ -28,9 +33,108 @@ func indexHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Hello, %q", "ordinary-kriging")
 }
 
+// trainRequest 训练模型请求参数
+type trainRequest struct {
+	Name   string                    `json:"name"`
+	Title  string                    `json:"title"`
+	Values []float64                 `json:"values"`
+	X      []float64                 `json:"x"`
+	Y      []float64                 `json:"y"`
+	Model  ordinarykriging.ModelType `json:"model"`
+	Sigma2 float64                   `json:"sigma2"`
+	Alpha  float64                   `json:"alpha"`
+	// Transform 训练前的变换，为空时不变换
+	Transform *ordinarykriging.Transform `json:"transform"`
+	// Duplicates 位置重合的样本的处理方式，为空时取平均
+	Duplicates         ordinarykriging.DuplicatePolicy `json:"duplicates"`
+	DuplicateTolerance float64                         `json:"duplicateTolerance"`
+	// Weights 样本的解聚权重，为空时等权
+	Weights []float64 `json:"weights"`
+}
+
+// trainHandler 训练模型并保存为图层
 func trainHandler(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(w, "Hello, %q", "ordinary-kriging")
-	// TODO:
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request trainRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("invalid json: %v", err), http.StatusBadRequest)
+		return
+	}
+	if request.Name == "" {
+		http.Error(w, "missing name", http.StatusBadRequest)
+		return
+	}
+	if len(request.Values) == 0 || len(request.Values) != len(request.X) || len(request.Values) != len(request.Y) {
+		http.Error(w, "values, x and y must have the same non-zero length", http.StatusBadRequest)
+		return
+	}
+	if request.Model == "" {
+		request.Model = ordinarykriging.Exponential
+	}
+	if request.Alpha == 0 {
+		request.Alpha = 100
+	}
+	if request.Title == "" {
+		request.Title = request.Name
+	}
+
+	ordinaryKriging := ordinarykriging.NewOrdinary(request.Values, request.X, request.Y)
+	ordinaryKriging.Transform = request.Transform
+	ordinaryKriging.Weights = request.Weights
+	if _, err := ordinaryKriging.MergeDuplicates(request.DuplicateTolerance, request.Duplicates); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	variogram, err := ordinaryKriging.Train(request.Model, request.Sigma2, request.Alpha)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	l := &layer{
+		Name:      request.Name,
+		Title:     request.Title,
+		Model:     request.Model,
+		BBox:      boundingBox(request.X, request.Y),
+		Zlim:      [2]float64{request.Values[0], request.Values[0]},
+		Variogram: variogram,
+	}
+	for _, value := range request.Values {
+		if value < l.Zlim[0] {
+			l.Zlim[0] = value
+		}
+		if value > l.Zlim[1] {
+			l.Zlim[1] = value
+		}
+	}
+	store.put(l)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(l)
+}
+
+// boundingBox 点集范围 [minX, minY, maxX, maxY]
+func boundingBox(x, y []float64) [4]float64 {
+	bbox := [4]float64{x[0], y[0], x[0], y[0]}
+	for i := range x {
+		if x[i] < bbox[0] {
+			bbox[0] = x[i]
+		}
+		if x[i] > bbox[2] {
+			bbox[2] = x[i]
+		}
+		if y[i] < bbox[1] {
+			bbox[1] = y[i]
+		}
+		if y[i] > bbox[3] {
+			bbox[3] = y[i]
+		}
+	}
+	return bbox
 }
 
 func gridHandler(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+)
+
+// layer 训练好的模型，作为一个图层对外提供服务
+type layer struct {
+	Name      string                     `json:"name"`
+	Title     string                     `json:"title"`
+	Model     ordinarykriging.ModelType  `json:"model"`
+	BBox      [4]float64                 `json:"bbox"` // [minX, minY, maxX, maxY] CRS:84
+	Zlim      [2]float64                 `json:"zLim"`
+	Variogram *ordinarykriging.Variogram `json:"-"`
+}
+
+// layerStore 内存中的模型存储
+type layerStore struct {
+	sync.RWMutex
+	layers map[string]*layer
+}
+
+var store = &layerStore{layers: map[string]*layer{}}
+
+// put 保存模型，同名覆盖
+func (s *layerStore) put(l *layer) {
+	s.Lock()
+	defer s.Unlock()
+	s.layers[l.Name] = l
+}
+
+// get 根据名称获取模型
+func (s *layerStore) get(name string) (*layer, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	l, ok := s.layers[name]
+	return l, ok
+}
+
+// list 按名称排序的全部模型
+func (s *layerStore) list() []*layer {
+	s.RLock()
+	defer s.RUnlock()
+	layers := make([]*layer, 0, len(s.layers))
+	for _, l := range s.layers {
+		layers = append(layers, l)
+	}
+	sort.Slice(layers, func(i, j int) bool {
+		return layers[i].Name < layers[j].Name
+	})
+	return layers
+}
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image/png"
+	"math"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lvisei/go-kriging/canvas"
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/json"
+)
+
+// OGC WMS 1.3.0
+// 每个训练好的模型作为一个图层，支持 GetCapabilities、GetMap、GetFeatureInfo
+
+const wmsVersion = "1.3.0"
+
+const maxMapSize = 4096
+
+// wmsException WMS 异常
+type wmsException struct {
+	Code    string
+	Message string
+}
+
+func (e *wmsException) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func newWMSException(code, format string, a ...interface{}) *wmsException {
+	return &wmsException{Code: code, Message: fmt.Sprintf(format, a...)}
+}
+
+// ogcParams OGC 请求参数，参数名不区分大小写
+type ogcParams map[string]string
+
+func newOGCParams(r *http.Request) ogcParams {
+	params := ogcParams{}
+	for key, values := range r.URL.Query() {
+		if len(values) > 0 {
+			params[strings.ToUpper(key)] = values[0]
+		}
+	}
+	return params
+}
+
+func (p ogcParams) get(key string) string {
+	return p[strings.ToUpper(key)]
+}
+
+func (p ogcParams) require(key string) (string, error) {
+	value := p.get(key)
+	if value == "" {
+		return "", newWMSException("MissingParameterValue", "missing parameter %s", key)
+	}
+	return value, nil
+}
+
+func (p ogcParams) requireInt(key string) (int, error) {
+	value, err := p.require(key)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, newWMSException("InvalidParameterValue", "invalid %s %q", key, value)
+	}
+	return v, nil
+}
+
+// mapRequest GetMap 与 GetFeatureInfo 共有的地图参数
+type mapRequest struct {
+	Layers []*layer
+	CRS    string
+	BBox   [4]float64 // 坐标参考系下的 [minX, minY, maxX, maxY]
+	Width  int
+	Height int
+}
+
+func parseMapRequest(params ogcParams, layersKey string) (*mapRequest, error) {
+	layerNames, err := params.require(layersKey)
+	if err != nil {
+		return nil, err
+	}
+	var layers []*layer
+	for _, name := range strings.Split(layerNames, ",") {
+		l, ok := store.get(name)
+		if !ok {
+			return nil, newWMSException("LayerNotDefined", "layer %q not defined", name)
+		}
+		layers = append(layers, l)
+	}
+
+	crs, err := params.require("CRS")
+	if err != nil {
+		return nil, err
+	}
+	crs = strings.ToUpper(crs)
+	if !isSupportedCRS(crs) {
+		return nil, newWMSException("InvalidCRS", "unsupported crs %q", crs)
+	}
+
+	bboxValue, err := params.require("BBOX")
+	if err != nil {
+		return nil, err
+	}
+	bbox, err := parseBBox(bboxValue, crs)
+	if err != nil {
+		return nil, newWMSException("InvalidParameterValue", "%v", err)
+	}
+
+	width, err := params.requireInt("WIDTH")
+	if err != nil {
+		return nil, err
+	}
+	height, err := params.requireInt("HEIGHT")
+	if err != nil {
+		return nil, err
+	}
+	if width <= 0 || height <= 0 || width > maxMapSize || height > maxMapSize {
+		return nil, newWMSException("InvalidParameterValue", "invalid size %dx%d", width, height)
+	}
+
+	return &mapRequest{Layers: layers, CRS: crs, BBox: bbox, Width: width, Height: height}, nil
+}
+
+// wmsHandler WMS 服务入口
+func wmsHandler(w http.ResponseWriter, r *http.Request) {
+	params := newOGCParams(r)
+	var err error
+	switch strings.ToLower(params.get("REQUEST")) {
+	case "getcapabilities":
+		err = wmsGetCapabilities(w, r)
+	case "getmap":
+		err = wmsGetMap(w, params)
+	case "getfeatureinfo":
+		err = wmsGetFeatureInfo(w, params)
+	default:
+		err = newWMSException("OperationNotSupported", "unsupported request %q", params.get("REQUEST"))
+	}
+	if err != nil {
+		writeWMSException(w, err)
+	}
+}
+
+func writeWMSException(w http.ResponseWriter, err error) {
+	code := "NoApplicableCode"
+	if e, ok := err.(*wmsException); ok {
+		code = e.Code
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(http.StatusBadRequest)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<ServiceExceptionReport version="%s" xmlns="http://www.opengis.net/ogc">
+  <ServiceException code="%s">%s</ServiceException>
+</ServiceExceptionReport>
+`, wmsVersion, code, xmlEscape(err.Error()))
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// maxPredictWork 每个 CPU 插值的像元数×样本数的上限
+// 每个像元的 Predict 对每个样本约 20ns，使请求在服务的 WriteTimeout 内完成
+const maxPredictWork = 2e8
+
+// checkPredictWork 在 layers 上插值 pixels 个像元是否超出 maxPredictWork，超出时返回错误
+func checkPredictWork(pixels int, layers ...*layer) error {
+	var samples int
+	for _, l := range layers {
+		samples += l.Variogram.N
+	}
+	budget := maxPredictWork * float64(runtime.NumCPU())
+	if float64(pixels)*float64(samples) > budget {
+		return fmt.Errorf("%d pixels over %d samples exceed the limit of %.0f pixels, request a smaller size",
+			pixels, samples, math.Floor(budget/float64(samples)))
+	}
+	return nil
+}
+
+// predictRectangle 在请求范围的 width×height 个像元中心插值
+// WIDTH/HEIGHT 的比例可以与 bbox 不同，不能用 ContourWithBBox（其行数由 bbox 的比例决定）
+// 各行由 runtime.NumCPU() 个 goroutine 并行插值，像元数应先经 checkPredictWork 检查
+func predictRectangle(l *layer, crs string, bbox [4]float64, width, height int) *ordinarykriging.ContourRectangle {
+	xResolution := (bbox[2] - bbox[0]) / float64(width)
+	yResolution := (bbox[3] - bbox[1]) / float64(height)
+
+	// 在像元中心插值，投影坐标系下先反算经纬度
+	contour := make([]float64, width*height)
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < runtime.NumCPU(); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range rows {
+				y := bbox[1] + (float64(j)+0.5)*yResolution
+				for k := 0; k < width; k++ {
+					x := bbox[0] + (float64(k)+0.5)*xResolution
+					contour[j*width+k] = l.Variogram.Predict(toLonLat(crs, x, y))
+				}
+			}
+		}()
+	}
+	for j := 0; j < height; j++ {
+		rows <- j
+	}
+	close(rows)
+	wg.Wait()
+	return &ordinarykriging.ContourRectangle{
+		Contour:     contour,
+		XWidth:      width,
+		YWidth:      height,
+		Xlim:        [2]float64{bbox[0] + xResolution/2, bbox[2] + xResolution/2},
+		Ylim:        [2]float64{bbox[1] + yResolution/2, bbox[3] + yResolution/2},
+		Zlim:        l.Zlim,
+		XResolution: xResolution,
+		YResolution: yResolution,
+	}
+}
+
+// renderLayer 绘制图层
+func renderLayer(l *layer, crs string, bbox [4]float64, width, height int) *canvas.Canvas {
+	contourRectangle := predictRectangle(l, crs, bbox, width, height)
+	return l.Variogram.PlotRectangleGrid(contourRectangle, width, height,
+		[2]float64{bbox[0], bbox[2]}, [2]float64{bbox[1], bbox[3]}, ordinarykriging.DefaultLegendColor)
+}
+
+func wmsGetMap(w http.ResponseWriter, params ogcParams) error {
+	request, err := parseMapRequest(params, "LAYERS")
+	if err != nil {
+		return err
+	}
+	if format := params.get("FORMAT"); format != "" && format != "image/png" {
+		return newWMSException("InvalidFormat", "unsupported format %q", format)
+	}
+	if err := checkPredictWork(request.Width*request.Height, request.Layers...); err != nil {
+		return newWMSException("InvalidParameterValue", "%v", err)
+	}
+
+	ctx := canvas.NewCanvas(request.Width, request.Height)
+	for _, l := range request.Layers {
+		ctx.DrawImage(renderLayer(l, request.CRS, request.BBox, request.Width, request.Height).Image(), 0, 0)
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	return png.Encode(w, ctx.Image())
+}
+
+// featureInfo 点击像元的预测值与方差
+type featureInfo struct {
+	Layer    string  `json:"layer"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Value    float64 `json:"value"`
+	Variance float64 `json:"variance"`
+}
+
+func wmsGetFeatureInfo(w http.ResponseWriter, params ogcParams) error {
+	request, err := parseMapRequest(params, "QUERY_LAYERS")
+	if err != nil {
+		return err
+	}
+	i, err := params.requireInt("I")
+	if err != nil {
+		return err
+	}
+	j, err := params.requireInt("J")
+	if err != nil {
+		return err
+	}
+	if i < 0 || i >= request.Width || j < 0 || j >= request.Height {
+		return newWMSException("InvalidPoint", "point %d,%d outside of map", i, j)
+	}
+
+	xResolution := (request.BBox[2] - request.BBox[0]) / float64(request.Width)
+	yResolution := (request.BBox[3] - request.BBox[1]) / float64(request.Height)
+	x := request.BBox[0] + (float64(i)+0.5)*xResolution
+	y := request.BBox[3] - (float64(j)+0.5)*yResolution
+	lon, lat := toLonLat(request.CRS, x, y)
+
+	infos := make([]featureInfo, 0, len(request.Layers))
+	for _, l := range request.Layers {
+		infos = append(infos, featureInfo{
+			Layer:    l.Name,
+			X:        lon,
+			Y:        lat,
+			Value:    l.Variogram.Predict(lon, lat),
+			Variance: l.Variogram.Variance(lon, lat),
+		})
+	}
+
+	switch format := params.get("INFO_FORMAT"); format {
+	case "", "application/json":
+		w.Header().Set("Content-Type", "application/json")
+		return json.NewEncoder(w).Encode(infos)
+	case "text/plain":
+		w.Header().Set("Content-Type", "text/plain")
+		for _, info := range infos {
+			fmt.Fprintf(w, "%s: x=%v y=%v value=%v variance=%v\n", info.Layer, info.X, info.Y, info.Value, info.Variance)
+		}
+		return nil
+	default:
+		return newWMSException("InvalidFormat", "unsupported info format %q", format)
+	}
+}
+
+// WMS_Capabilities 文档结构
+
+type wmsCapabilities struct {
+	XMLName    xml.Name      `xml:"WMS_Capabilities"`
+	Version    string        `xml:"version,attr"`
+	XMLNS      string        `xml:"xmlns,attr"`
+	XMLNSXLink string        `xml:"xmlns:xlink,attr"`
+	Service    wmsService    `xml:"Service"`
+	Capability wmsCapability `xml:"Capability"`
+}
+
+type wmsService struct {
+	Name           string            `xml:"Name"`
+	Title          string            `xml:"Title"`
+	Abstract       string            `xml:"Abstract"`
+	OnlineResource wmsOnlineResource `xml:"OnlineResource"`
+}
+
+type wmsOnlineResource struct {
+	Type string `xml:"xlink:type,attr"`
+	Href string `xml:"xlink:href,attr"`
+}
+
+type wmsCapability struct {
+	Request   wmsRequest   `xml:"Request"`
+	Exception []string     `xml:"Exception>Format"`
+	Layer     wmsRootLayer `xml:"Layer"`
+}
+
+type wmsRequest struct {
+	GetCapabilities wmsOperation `xml:"GetCapabilities"`
+	GetMap          wmsOperation `xml:"GetMap"`
+	GetFeatureInfo  wmsOperation `xml:"GetFeatureInfo"`
+}
+
+type wmsOperation struct {
+	Format         []string          `xml:"Format"`
+	OnlineResource wmsOnlineResource `xml:"DCPType>HTTP>Get>OnlineResource"`
+}
+
+type wmsRootLayer struct {
+	Title                   string             `xml:"Title"`
+	CRS                     []string           `xml:"CRS"`
+	EXGeographicBoundingBox *wmsGeographicBBox `xml:"EX_GeographicBoundingBox,omitempty"`
+	Layers                  []wmsLayer         `xml:"Layer"`
+}
+
+type wmsLayer struct {
+	Queryable               int               `xml:"queryable,attr"`
+	Name                    string            `xml:"Name"`
+	Title                   string            `xml:"Title"`
+	Abstract                string            `xml:"Abstract"`
+	CRS                     []string          `xml:"CRS"`
+	EXGeographicBoundingBox wmsGeographicBBox `xml:"EX_GeographicBoundingBox"`
+	BoundingBox             []wmsBBox         `xml:"BoundingBox"`
+}
+
+type wmsGeographicBBox struct {
+	West  float64 `xml:"westBoundLongitude"`
+	East  float64 `xml:"eastBoundLongitude"`
+	South float64 `xml:"southBoundLatitude"`
+	North float64 `xml:"northBoundLatitude"`
+}
+
+type wmsBBox struct {
+	CRS  string  `xml:"CRS,attr"`
+	MinX float64 `xml:"minx,attr"`
+	MinY float64 `xml:"miny,attr"`
+	MaxX float64 `xml:"maxx,attr"`
+	MaxY float64 `xml:"maxy,attr"`
+}
+
+// layerBoundingBoxes 图层在各坐标参考系下的范围
+func layerBoundingBoxes(l *layer) []wmsBBox {
+	var boxes []wmsBBox
+	for _, crs := range supportedCRS {
+		bbox := projectBBox(crs, l.BBox)
+		if crs == epsg4326 {
+			bbox = [4]float64{bbox[1], bbox[0], bbox[3], bbox[2]}
+		}
+		boxes = append(boxes, wmsBBox{CRS: crs, MinX: bbox[0], MinY: bbox[1], MaxX: bbox[2], MaxY: bbox[3]})
+	}
+	return boxes
+}
+
+func serviceURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s?", scheme, r.Host, r.URL.Path)
+}
+
+func wmsGetCapabilities(w http.ResponseWriter, r *http.Request) error {
+	resource := wmsOnlineResource{Type: "simple", Href: serviceURL(r)}
+
+	root := wmsRootLayer{Title: "ordinary-kriging", CRS: supportedCRS}
+	for i, l := range store.list() {
+		if i == 0 {
+			root.EXGeographicBoundingBox = &wmsGeographicBBox{West: l.BBox[0], East: l.BBox[2], South: l.BBox[1], North: l.BBox[3]}
+		} else {
+			bbox := root.EXGeographicBoundingBox
+			bbox.West = math.Min(bbox.West, l.BBox[0])
+			bbox.South = math.Min(bbox.South, l.BBox[1])
+			bbox.East = math.Max(bbox.East, l.BBox[2])
+			bbox.North = math.Max(bbox.North, l.BBox[3])
+		}
+		root.Layers = append(root.Layers, wmsLayer{
+			Queryable: 1,
+			Name:      l.Name,
+			Title:     l.Title,
+			Abstract:  fmt.Sprintf("ordinary kriging, %s variogram model", l.Model),
+			CRS:       supportedCRS,
+			EXGeographicBoundingBox: wmsGeographicBBox{
+				West: l.BBox[0], East: l.BBox[2], South: l.BBox[1], North: l.BBox[3],
+			},
+			BoundingBox: layerBoundingBoxes(l),
+		})
+	}
+
+	capabilities := wmsCapabilities{
+		Version:    wmsVersion,
+		XMLNS:      "http://www.opengis.net/wms",
+		XMLNSXLink: "http://www.w3.org/1999/xlink",
+		Service: wmsService{
+			Name:           "WMS",
+			Title:          "ordinary-kriging",
+			Abstract:       "geospatial prediction and mapping via ordinary kriging",
+			OnlineResource: resource,
+		},
+		Capability: wmsCapability{
+			Request: wmsRequest{
+				GetCapabilities: wmsOperation{Format: []string{"text/xml"}, OnlineResource: resource},
+				GetMap:          wmsOperation{Format: []string{"image/png"}, OnlineResource: resource},
+				GetFeatureInfo:  wmsOperation{Format: []string{"application/json", "text/plain"}, OnlineResource: resource},
+			},
+			Exception: []string{"XML"},
+			Layer:     root,
+		},
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(capabilities)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+)
+
+func TestWMSGetMap_PredictWork(t *testing.T) {
+	// 样本数使 4096×4096 超出 maxPredictWork
+	n := 16 * runtime.NumCPU()
+	var values, xs, ys []float64
+	for i := 0; i < n; i++ {
+		values = append(values, math.Sin(float64(i)))
+		xs = append(xs, 100+2*math.Mod(float64(i)*0.618, 1))
+		ys = append(ys, 25+float64(i)/float64(n))
+	}
+	variogram := ordinarykriging.NewOrdinary(values, xs, ys)
+	if _, err := variogram.Train(ordinarykriging.Exponential, 0, 100); err != nil {
+		t.Fatal(err)
+	}
+	store.put(&layer{Name: "work", Model: ordinarykriging.Exponential, BBox: [4]float64{100, 25, 102, 26}, Variogram: variogram})
+
+	query := "/wms?SERVICE=WMS&REQUEST=GetMap&LAYERS=work&CRS=CRS:84&BBOX=100,25,102,26&FORMAT=image/png"
+	rec := httptest.NewRecorder()
+	wmsHandler(rec, httptest.NewRequest(http.MethodGet, query+"&WIDTH=4096&HEIGHT=4096", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "ServiceException") {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	wmsHandler(rec, httptest.NewRequest(http.MethodGet, query+"&WIDTH=64&HEIGHT=32", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -1,21 +1,20 @@
 package main
 
 import (
-	"encoding/csv"
 	"fmt"
 
-	"image/color"
 	"image/png"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
-	"strconv"
 	"time"
 
-	"github.com/lvisei/go-kriging/canvas"
 	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/dataset"
+	"github.com/lvisei/go-kriging/pkg/geojson"
 	"github.com/lvisei/go-kriging/pkg/json"
+	"github.com/lvisei/go-kriging/pkg/layout"
 )
 
 const testDataDirPath = "testdata"
@@ -48,7 +47,7 @@ func main() {
 	}
 	defer timeCost()("训练模型与插值生成网格图片总耗时")
 
-	ordinaryKriging := ordinarykriging.NewOrdinary(data["values"], data["x"], data["y"])
+	ordinaryKriging := ordinarykriging.NewOrdinary(data.Values, data.X, data.Y)
 	if _, err := ordinaryKriging.Train(ordinarykriging.Exponential, 0, 100); err != nil {
 		log.Fatal(err)
 	}
@@ -69,22 +68,30 @@ func main() {
 	//wg.Wait()
 }
 
-func gridPlot(ordinaryKriging *ordinarykriging.Variogram, polygon ordinarykriging.PolygonCoordinates) {
+func gridPlot(ordinaryKriging *ordinarykriging.Variogram, polygon ordinarykriging.MultiPolygonCoordinates) {
 	defer timeCost()("插值生成网格图片耗时")
-	gridMatrices := ordinaryKriging.Grid(polygon, 0.01)
-	ctx := ordinaryKriging.Plot(gridMatrices, 500, 500, gridMatrices.Xlim, gridMatrices.Ylim, ordinarykriging.DefaultGridLevelColor)
-
-	subTitle := &canvas.TextConfig{
-		Text:     "球面半变异函数模型",
-		FontName: testDataDirPath + "/fonts/source-han-sans-sc/regular.ttf",
-		FontSize: 28,
-		Color:    color.RGBA{R: 0, G: 0, B: 0, A: 255},
-		OffsetX:  252,
-		OffsetY:  40,
-		AlignX:   0.5,
+	gridMatrices := ordinaryKriging.GridMultiPolygon(polygon, 0.01)
+	// 与 Plot 相同按 value - Zlim[0] 分级
+	p := ordinarykriging.NewGridLevelPalette(ordinarykriging.DefaultGridLevelColor, gridMatrices.Zlim[0])
+	l := &layout.Layout{
+		Width:      500,
+		Height:     500,
+		Title:      "球面半变异函数模型",
+		FontPath:   testDataDirPath + "/fonts/source-han-sans-sc/regular.ttf",
+		TitleSize:  28,
+		Palette:    p,
+		Legend:     true,
+		ScaleBar:   true,
+		Geographic: true,
+		NorthArrow: true,
+	}
+	frame, err := l.Frame(gridMatrices.Xlim, gridMatrices.Ylim)
+	if err != nil {
+		log.Fatal(err)
 	}
-	if err := ctx.DrawText(subTitle); err != nil {
-		log.Fatalf("DrawText %v", err)
+	ctx := ordinaryKriging.PlotPalette(gridMatrices, frame.Dx(), frame.Dy(), gridMatrices.Xlim, gridMatrices.Ylim, p)
+	if ctx, err = l.Compose(ctx.Image(), gridMatrices.Xlim, gridMatrices.Ylim); err != nil {
+		log.Fatalf("Compose %v", err)
 	}
 
 	buffer, err := ctx.Output()
@@ -128,60 +135,17 @@ func ContourWithBBoxPng(bbox [4]float64) {
 	//contourRectangle := ordinaryKriging.ContourWithBBox(bbox, 0.01)
 }
 
-func readCsvFile(filePath string) (map[string][]float64, error) {
-	f, err := os.Open(filePath)
-	if err != nil {
-		log.Fatal("Unable to read input file "+filePath, err)
-		return nil, err
-	}
-	defer f.Close()
-
-	records, err := csv.NewReader(f).ReadAll()
-	if err != nil {
-		log.Fatal("Unable to parse file as CSV for\n "+filePath, err)
-		return nil, err
-	}
-
-	var values, lats, lons []float64
-
-	for i := 1; i < len(records); i++ {
-		var value, lat, lon float64
-		if lon, err = strconv.ParseFloat(records[i][1], 64); err != nil {
-			return nil, err
-		}
-		lons = append(lons, lon)
-		if lat, err = strconv.ParseFloat(records[i][2], 64); err != nil {
-			return nil, err
-		}
-		lats = append(lats, lat)
-		if value, err = strconv.ParseFloat(records[i][5], 64); err != nil {
-			return nil, err
-		}
-		values = append(values, value)
-	}
-
-	data := map[string][]float64{"values": values, "x": lons, "y": lats}
-
-	//fmt.Printf("values %#v\n lons %#v\n lats %#v\n", values, lons, lats)
-	//writeFile("tempdata.json", tempdata)
-
-	return data, nil
+func readCsvFile(filePath string) (*dataset.Samples, error) {
+	return dataset.ReadCSVFile(filePath, &dataset.CSVOptions{X: "Lon", Y: "Lat", Value: "TEM_Avg"})
 }
 
-func readGeoJsonFile(filePath string) (ordinarykriging.PolygonCoordinates, error) {
+func readGeoJsonFile(filePath string) (ordinarykriging.MultiPolygonCoordinates, error) {
 	content, err := ioutil.ReadFile(filePath)
 	if err != nil {
-		log.Fatal("Unable to read input file \n"+filePath, err)
-		return nil, err
-	}
-	var polygonGeometry ordinarykriging.PolygonGeometry
-	err = json.Unmarshal(content, &polygonGeometry)
-	if err != nil {
-		log.Fatalf("invalid json: %v", err)
 		return nil, err
 	}
 
-	return polygonGeometry.Coordinates, nil
+	return geojson.ReadMultiPolygon(content)
 }
 
 func timeCost() func(name string) {
@@ -0,0 +1,406 @@
+package ordinarykriging
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DeclusterMethod 解聚的方法
+type DeclusterMethod string
+
+const (
+	DeclusterNone      DeclusterMethod = ""          // 不解聚，等权
+	DeclusterCell      DeclusterMethod = "cell"      // 网格单元解聚
+	DeclusterPolygonal DeclusterMethod = "polygonal" // 泰森多边形（Voronoi）解聚
+)
+
+// ParseDeclusterMethod 解析解聚的方法，"none" 为不解聚
+func ParseDeclusterMethod(name string) (DeclusterMethod, error) {
+	switch method := DeclusterMethod(strings.ToLower(name)); method {
+	case "none":
+		return DeclusterNone, nil
+	case DeclusterNone, DeclusterCell, DeclusterPolygonal:
+		return method, nil
+	}
+	return "", fmt.Errorf("unknown decluster method %q", name)
+}
+
+// CellDeclusterOptions 网格单元解聚的选项
+type CellDeclusterOptions struct {
+	// CellSize 大于 0 时使用固定的单元大小，不搜索
+	CellSize float64
+	// MinSize、MaxSize 搜索的单元大小范围，MaxSize 为 0 时为样本范围较长边的一半，MinSize 为 0 时为 MaxSize/Count
+	MinSize float64
+	MaxSize float64
+	// Count 搜索的单元大小个数，0 时为 24
+	Count int
+	// Offsets 每个单元大小平均的网格原点偏移次数，0 时为 5
+	Offsets int
+	// Maximize 取解聚均值最大的单元大小，默认取最小的，适用于样本聚集在高值区
+	Maximize bool
+}
+
+func (opt *CellDeclusterOptions) withDefaults(extent float64) CellDeclusterOptions {
+	o := CellDeclusterOptions{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.Count <= 0 {
+		o.Count = 24
+	}
+	if o.Offsets <= 0 {
+		o.Offsets = 5
+	}
+	if o.MaxSize <= 0 {
+		o.MaxSize = extent / 2
+	}
+	if o.MinSize <= 0 || o.MinSize > o.MaxSize {
+		o.MinSize = o.MaxSize / float64(o.Count)
+	}
+	return o
+}
+
+// PolygonalDeclusterOptions 泰森多边形解聚的选项
+type PolygonalDeclusterOptions struct {
+	// BBox 泰森多边形的外边界 minX,minY,maxX,maxY，为 nil 时为 Polygon 或样本的范围
+	BBox *[4]float64
+	// Polygon 不为空时泰森多边形的面积只计算在多边形内的部分
+	Polygon MultiPolygonCoordinates
+}
+
+// DeclusterCandidate 搜索的单元大小与对应的解聚均值
+type DeclusterCandidate struct {
+	CellSize float64 `json:"cellSize"`
+	Mean     float64 `json:"mean"`
+}
+
+// Declustering 解聚的结果
+type Declustering struct {
+	Method   DeclusterMethod `json:"method"`
+	CellSize float64         `json:"cellSize,omitempty"` // 网格单元解聚选用的单元大小
+	// Weights 每个样本的权重，平均值为 1，可设置为 Variogram.Weights
+	Weights    []float64            `json:"weights"`
+	NaiveMean  float64              `json:"naiveMean"` // 等权的均值
+	Mean       float64              `json:"mean"`      // 解聚后的均值
+	Candidates []DeclusterCandidate `json:"candidates,omitempty"`
+}
+
+// CellDecluster cell declustering
+// 网格单元解聚：每个样本的权重与所在单元内的样本数成反比，对多个网格原点偏移取平均；
+// 未指定 CellSize 时在 MinSize 到 MaxSize 之间搜索，取解聚均值最小（Maximize 时最大）的单元大小
+func CellDecluster(t, x, y []float64, opt *CellDeclusterOptions) (*Declustering, error) {
+	if err := NewOrdinary(t, x, y).checkSamples(); err != nil {
+		return nil, err
+	}
+	xlim := [2]float64{minFloat64(x), maxFloat64(x)}
+	ylim := [2]float64{minFloat64(y), maxFloat64(y)}
+	o := opt.withDefaults(math.Max(xlim[1]-xlim[0], ylim[1]-ylim[0]))
+	result := &Declustering{Method: DeclusterCell, NaiveMean: weightedMean(t, nil)}
+
+	if o.CellSize > 0 {
+		result.CellSize = o.CellSize
+		result.Weights = cellWeights(x, y, xlim, ylim, o.CellSize, o.Offsets)
+		result.Mean = weightedMean(t, result.Weights)
+		return result, nil
+	}
+	if !(o.MaxSize > 0) {
+		// 样本位置相同，等权
+		result.Weights = cellWeights(x, y, xlim, ylim, 1, 1)
+		result.Mean = result.NaiveMean
+		return result, nil
+	}
+
+	for k := 0; k < o.Count; k++ {
+		size := o.MinSize
+		if o.Count > 1 {
+			size += (o.MaxSize - o.MinSize) * float64(k) / float64(o.Count-1)
+		}
+		weights := cellWeights(x, y, xlim, ylim, size, o.Offsets)
+		mean := weightedMean(t, weights)
+		result.Candidates = append(result.Candidates, DeclusterCandidate{CellSize: size, Mean: mean})
+		if result.Weights == nil || (o.Maximize && mean > result.Mean) || (!o.Maximize && mean < result.Mean) {
+			result.CellSize, result.Weights, result.Mean = size, weights, mean
+		}
+	}
+	return result, nil
+}
+
+// cellWeights 单元大小为 size 的网格单元解聚权重，原点沿对角线偏移 offsets 次
+func cellWeights(x, y []float64, xlim, ylim [2]float64, size float64, offsets int) []float64 {
+	n := len(x)
+	weights := make([]float64, n)
+	cells := make([][2]int64, n)
+	for k := 0; k < offsets; k++ {
+		shift := size * float64(k) / float64(offsets)
+		count := make(map[[2]int64]int)
+		for i := 0; i < n; i++ {
+			cells[i] = [2]int64{
+				int64(math.Floor((x[i] - xlim[0] + shift) / size)),
+				int64(math.Floor((y[i] - ylim[0] + shift) / size)),
+			}
+			count[cells[i]]++
+		}
+		for i := 0; i < n; i++ {
+			weights[i] += 1 / float64(count[cells[i]]*len(count))
+		}
+	}
+	return normalizeWeights(weights)
+}
+
+// PolygonalDecluster polygonal declustering
+// 泰森多边形解聚：每个样本的权重与其泰森多边形在外边界（与 Polygon）内的面积成正比，
+// 位置相同的样本平分面积
+func PolygonalDecluster(t, x, y []float64, opt *PolygonalDeclusterOptions) (*Declustering, error) {
+	if err := NewOrdinary(t, x, y).checkSamples(); err != nil {
+		return nil, err
+	}
+	if opt == nil {
+		opt = &PolygonalDeclusterOptions{}
+	}
+	var bbox [4]float64
+	switch {
+	case opt.BBox != nil:
+		bbox = *opt.BBox
+	case len(opt.Polygon) > 0:
+		xlim, ylim := opt.Polygon.bbox()
+		bbox = [4]float64{xlim[0], ylim[0], xlim[1], ylim[1]}
+	default:
+		bbox = [4]float64{minFloat64(x), minFloat64(y), maxFloat64(x), maxFloat64(y)}
+	}
+
+	n := len(t)
+	coincident := make(map[Point]int)
+	for i := 0; i < n; i++ {
+		coincident[Point{x[i], y[i]}]++
+	}
+
+	areas := make([]float64, n)
+	order := make([]int, n)
+	distances := make([]float64, n)
+	for i := 0; i < n; i++ {
+		for j := range order {
+			order[j] = j
+			distances[j] = math.Hypot(x[j]-x[i], y[j]-y[i])
+		}
+		sort.Slice(order, func(a, b int) bool { return distances[order[a]] < distances[order[b]] })
+
+		p := Point{x[i], y[i]}
+		cell := []Point{{bbox[0], bbox[1]}, {bbox[2], bbox[1]}, {bbox[2], bbox[3]}, {bbox[0], bbox[3]}}
+		for _, j := range order {
+			if distances[j] == 0 {
+				continue
+			}
+			// 距离超过多边形顶点最远距离的两倍时，垂直平分线不再切割多边形
+			if distances[j] > 2*farthest(cell, p) {
+				break
+			}
+			q := Point{x[j], y[j]}
+			middle := Point{(p[0] + q[0]) / 2, (p[1] + q[1]) / 2}
+			cell = clipPolygon(cell, func(v Point) float64 {
+				return -((v[0]-middle[0])*(q[0]-p[0]) + (v[1]-middle[1])*(q[1]-p[1]))
+			})
+			if len(cell) == 0 {
+				break
+			}
+		}
+
+		if len(opt.Polygon) > 0 {
+			areas[i] = intersectionArea(opt.Polygon, cell)
+		} else {
+			areas[i] = math.Abs(polygonArea(cell))
+		}
+		areas[i] /= float64(coincident[p])
+	}
+
+	result := &Declustering{Method: DeclusterPolygonal, NaiveMean: weightedMean(t, nil)}
+	var total float64
+	for _, area := range areas {
+		total += area
+	}
+	if !(total > 0) {
+		// 外边界的面积为 0，等权
+		for i := range areas {
+			areas[i] = 1
+		}
+	}
+	result.Weights = normalizeWeights(areas)
+	result.Mean = weightedMean(t, result.Weights)
+	return result, nil
+}
+
+// clipPolygon 保留多边形中 f(v) >= 0 的部分，f 为线性函数
+func clipPolygon(polygon []Point, f func(v Point) float64) []Point {
+	var clipped []Point
+	for k := range polygon {
+		a, b := polygon[k], polygon[(k+1)%len(polygon)]
+		fa, fb := f(a), f(b)
+		if fa >= 0 {
+			clipped = append(clipped, a)
+		}
+		if (fa >= 0) != (fb >= 0) {
+			s := fa / (fa - fb)
+			clipped = append(clipped, Point{a[0] + s*(b[0]-a[0]), a[1] + s*(b[1]-a[1])})
+		}
+	}
+	return clipped
+}
+
+// intersectionArea 多面与凸多边形 convex 相交的面积，洞的面积从外环中减去
+func intersectionArea(multiPolygon MultiPolygonCoordinates, convex []Point) float64 {
+	if len(convex) < 3 {
+		return 0
+	}
+	// 凸多边形逆时针排列时，内部在每条边的左侧
+	if polygonArea(convex) < 0 {
+		reversed := make([]Point, len(convex))
+		for k := range convex {
+			reversed[k] = convex[len(convex)-1-k]
+		}
+		convex = reversed
+	}
+	var area float64
+	for _, polygon := range multiPolygon {
+		for r, ring := range polygon {
+			clipped := []Point(ring)
+			for k := range convex {
+				a, b := convex[k], convex[(k+1)%len(convex)]
+				clipped = clipPolygon(clipped, func(v Point) float64 {
+					return (b[0]-a[0])*(v[1]-a[1]) - (b[1]-a[1])*(v[0]-a[0])
+				})
+				if len(clipped) == 0 {
+					break
+				}
+			}
+			if r == 0 {
+				area += math.Abs(polygonArea(clipped))
+			} else {
+				area -= math.Abs(polygonArea(clipped))
+			}
+		}
+	}
+	return math.Max(area, 0)
+}
+
+// polygonArea 多边形的有向面积，逆时针为正
+func polygonArea(polygon []Point) float64 {
+	var area float64
+	for k := range polygon {
+		a, b := polygon[k], polygon[(k+1)%len(polygon)]
+		area += a[0]*b[1] - b[0]*a[1]
+	}
+	return area / 2
+}
+
+// farthest 多边形顶点到 p 的最远距离
+func farthest(polygon []Point, p Point) float64 {
+	var distance float64
+	for _, v := range polygon {
+		distance = math.Max(distance, math.Hypot(v[0]-p[0], v[1]-p[1]))
+	}
+	return distance
+}
+
+// normalizeWeights 将权重缩放为平均值 1
+func normalizeWeights(weights []float64) []float64 {
+	var sum float64
+	for _, weight := range weights {
+		sum += weight
+	}
+	for i := range weights {
+		weights[i] *= float64(len(weights)) / sum
+	}
+	return weights
+}
+
+// Statistics 样本值的统计量
+type Statistics struct {
+	N        int     `json:"n"`
+	Mean     float64 `json:"mean"`
+	Variance float64 `json:"variance"`
+	StdDev   float64 `json:"stdDev"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Median   float64 `json:"median"`
+	Q1       float64 `json:"q1"` // 下四分位数
+	Q3       float64 `json:"q3"` // 上四分位数
+}
+
+// WeightedStatistics 按解聚权重计算的统计量，weights 为空时等权，跳过不是有限值的样本
+func WeightedStatistics(values, weights []float64) Statistics {
+	var sample, sampleWeights []float64
+	for i, value := range values {
+		weight := 1.0
+		if len(weights) > 0 {
+			weight = weights[i]
+		}
+		if isFinite(value) && isFinite(weight) && weight > 0 {
+			sample = append(sample, value)
+			sampleWeights = append(sampleWeights, weight)
+		}
+	}
+	stats := Statistics{N: len(sample)}
+	if len(sample) == 0 {
+		return stats
+	}
+	stats.Mean = weightedMean(sample, sampleWeights)
+	var sum float64
+	stats.Min, stats.Max = sample[0], sample[0]
+	for i, value := range sample {
+		stats.Variance += sampleWeights[i] * pow2(value-stats.Mean)
+		sum += sampleWeights[i]
+		stats.Min = math.Min(stats.Min, value)
+		stats.Max = math.Max(stats.Max, value)
+	}
+	stats.Variance /= sum
+	stats.StdDev = math.Sqrt(stats.Variance)
+	stats.Q1 = WeightedQuantile(sample, sampleWeights, 0.25)
+	stats.Median = WeightedQuantile(sample, sampleWeights, 0.5)
+	stats.Q3 = WeightedQuantile(sample, sampleWeights, 0.75)
+	return stats
+}
+
+// WeightedQuantile 加权的分位数，p 为 0 到 1，weights 为空时等权
+// 每个样本的累积概率与 NewWeightedNormalScore 相同，之间线性插值，超出时取最小、最大值
+func WeightedQuantile(values, weights []float64, p float64) float64 {
+	sorted := make([]weightedValue, 0, len(values))
+	var total float64
+	for i, value := range values {
+		weight := 1.0
+		if len(weights) > 0 {
+			weight = weights[i]
+		}
+		if isFinite(value) && isFinite(weight) && weight > 0 {
+			sorted = append(sorted, weightedValue{value, weight})
+			total += weight
+		}
+	}
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].value < sorted[j].value })
+	probabilities := make([]float64, len(sorted))
+	sortedValues := make([]float64, len(sorted))
+	var cumulative float64
+	for i, v := range sorted {
+		probabilities[i] = (cumulative + v.weight/2) / total
+		cumulative += v.weight
+		sortedValues[i] = v.value
+	}
+	return interpolateSorted(probabilities, sortedValues, p)
+}
+
+// weightedMean 加权平均，weights 为空时等权
+func weightedMean(values, weights []float64) float64 {
+	var sum, total float64
+	for i, value := range values {
+		weight := 1.0
+		if len(weights) > 0 {
+			weight = weights[i]
+		}
+		sum += weight * value
+		total += weight
+	}
+	return sum / total
+}
@@ -0,0 +1,207 @@
+package ordinarykriging
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DuplicatePolicy 位置重合的样本的处理方式
+type DuplicatePolicy string
+
+const (
+	DuplicateMean   DuplicatePolicy = "mean"   // 取值与坐标的平均
+	DuplicateMedian DuplicatePolicy = "median" // 取值的中位数，坐标的平均
+	DuplicateFirst  DuplicatePolicy = "first"  // 保留最先出现的样本
+	DuplicateLast   DuplicatePolicy = "last"   // 保留最后出现的样本
+	DuplicateError  DuplicatePolicy = "error"  // 存在重合的样本时返回错误
+)
+
+// ParseDuplicatePolicy 解析重合样本的处理方式，为空时为 DuplicateMean
+func ParseDuplicatePolicy(name string) (DuplicatePolicy, error) {
+	switch policy := DuplicatePolicy(strings.ToLower(name)); policy {
+	case "":
+		return DuplicateMean, nil
+	case DuplicateMean, DuplicateMedian, DuplicateFirst, DuplicateLast, DuplicateError:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown duplicate policy %q", name)
+}
+
+// DuplicateGroup 合并为一个样本的重合样本
+type DuplicateGroup struct {
+	X       float64   `json:"x"`
+	Y       float64   `json:"y"`
+	Value   float64   `json:"value"`   // 合并后的值
+	Indexes []int     `json:"indexes"` // 原始样本的序号
+	Values  []float64 `json:"values"`  // 原始样本的值
+}
+
+// DuplicateReport 合并重合样本的报告
+type DuplicateReport struct {
+	Policy    DuplicatePolicy  `json:"policy"`
+	Tolerance float64          `json:"tolerance"`
+	Before    int              `json:"before"` // 合并前的样本数
+	After     int              `json:"after"`  // 合并后的样本数
+	Groups    []DuplicateGroup `json:"groups"`
+}
+
+// MergeDuplicates 合并距离不大于 tolerance 的样本，应在 Train 之前调用
+// 位置重合的样本会使 Gram 矩阵奇异；tolerance 为 0 时只合并坐标完全相同的样本，
+// 大于 0 时距离在 tolerance 内的样本逐个相连归为一组
+// 合并后的样本按每组最先出现的样本排序，设置了 Weights 时合并后的权重为各样本权重之和
+func (variogram *Variogram) MergeDuplicates(tolerance float64, policy DuplicatePolicy) (*DuplicateReport, error) {
+	policy, err := ParseDuplicatePolicy(string(policy))
+	if err != nil {
+		return nil, err
+	}
+	if err := variogram.checkLengths(); err != nil {
+		return nil, err
+	}
+	n := len(variogram.t)
+	report := &DuplicateReport{Policy: policy, Tolerance: tolerance, Before: n, After: n}
+
+	groups := groupCoincident(variogram.x, variogram.y, tolerance)
+	if len(groups) == n {
+		return report, nil
+	}
+
+	var t, x, y, weights []float64
+	for _, group := range groups {
+		if len(variogram.Weights) > 0 {
+			var weight float64
+			for _, i := range group {
+				weight += variogram.Weights[i]
+			}
+			weights = append(weights, weight)
+		}
+		if len(group) == 1 {
+			t = append(t, variogram.t[group[0]])
+			x = append(x, variogram.x[group[0]])
+			y = append(y, variogram.y[group[0]])
+			continue
+		}
+		merged := DuplicateGroup{Indexes: group}
+		for _, i := range group {
+			merged.Values = append(merged.Values, variogram.t[i])
+		}
+		if policy == DuplicateError {
+			return nil, fmt.Errorf("samples %v are within %v of (%v, %v)",
+				group, tolerance, variogram.x[group[0]], variogram.y[group[0]])
+		}
+		switch policy {
+		case DuplicateFirst, DuplicateLast:
+			i := group[0]
+			if policy == DuplicateLast {
+				i = group[len(group)-1]
+			}
+			merged.X, merged.Y, merged.Value = variogram.x[i], variogram.y[i], variogram.t[i]
+		default:
+			for _, i := range group {
+				merged.X += variogram.x[i]
+				merged.Y += variogram.y[i]
+				merged.Value += variogram.t[i]
+			}
+			size := float64(len(group))
+			merged.X /= size
+			merged.Y /= size
+			merged.Value /= size
+			if policy == DuplicateMedian {
+				merged.Value = median(merged.Values)
+			}
+		}
+		report.Groups = append(report.Groups, merged)
+		t = append(t, merged.Value)
+		x = append(x, merged.X)
+		y = append(y, merged.Y)
+	}
+
+	variogram.t, variogram.x, variogram.y = t, x, y
+	if len(variogram.Weights) > 0 {
+		variogram.Weights = weights
+	}
+	report.After = len(t)
+	return report, nil
+}
+
+// groupCoincident 按距离不大于 tolerance 将样本分组，每组的序号递增，组按首个序号排序
+func groupCoincident(x, y []float64, tolerance float64) [][]int {
+	n := len(x)
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(i, j int) {
+		i, j = find(i), find(j)
+		// 以较小的序号为根，组按首个序号排序
+		if i < j {
+			parent[j] = i
+		} else if j < i {
+			parent[i] = j
+		}
+	}
+
+	if tolerance > 0 {
+		// 边长为 tolerance 的网格，只比较相邻网格内的样本
+		cells := make(map[[2]int64][]int)
+		cellOf := func(i int) [2]int64 {
+			return [2]int64{int64(math.Floor(x[i] / tolerance)), int64(math.Floor(y[i] / tolerance))}
+		}
+		for i := 0; i < n; i++ {
+			cell := cellOf(i)
+			for dx := int64(-1); dx <= 1; dx++ {
+				for dy := int64(-1); dy <= 1; dy++ {
+					for _, j := range cells[[2]int64{cell[0] + dx, cell[1] + dy}] {
+						if math.Hypot(x[i]-x[j], y[i]-y[j]) <= tolerance {
+							union(i, j)
+						}
+					}
+				}
+			}
+			cells[cell] = append(cells[cell], i)
+		}
+	} else {
+		first := make(map[[2]float64]int)
+		for i := 0; i < n; i++ {
+			key := [2]float64{x[i], y[i]}
+			if j, ok := first[key]; ok {
+				union(i, j)
+			} else {
+				first[key] = i
+			}
+		}
+	}
+
+	var groups [][]int
+	index := make(map[int]int)
+	for i := 0; i < n; i++ {
+		root := find(i)
+		k, ok := index[root]
+		if !ok {
+			k = len(groups)
+			index[root] = k
+			groups = append(groups, nil)
+		}
+		groups[k] = append(groups[k], i)
+	}
+	return groups
+}
+
+// median 中位数，不修改 values
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
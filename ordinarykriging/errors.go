@@ -0,0 +1,19 @@
+package ordinarykriging
+
+import (
+	"errors"
+)
+
+// 训练与验证返回的错误，可用 errors.Is 判断，返回的错误包含具体的原因
+var (
+	// ErrMismatchedLengths t、x、y 的长度不同
+	ErrMismatchedLengths = errors.New("mismatched lengths")
+	// ErrTooFewPoints 样本少于 2 个，或不足以分组计算实验变异函数
+	ErrTooFewPoints = errors.New("too few points")
+	// ErrUnknownModel 未知的变异函数模型
+	ErrUnknownModel = errors.New("unknown variogram model")
+	// ErrSingularMatrix 矩阵不可逆，通常由位置重合的样本引起，可先调用 MergeDuplicates
+	ErrSingularMatrix = errors.New("singular matrix")
+	// ErrNonFinite 样本的值或坐标、训练参数为 NaN 或无穷大
+	ErrNonFinite = errors.New("non-finite value")
+)
@@ -36,32 +36,32 @@ func (t FloatList) max() float64 {
 	return max
 }
 
-func ExampleVariogram_Contour_Exponential() {
+func ExampleVariogram_Contour_exponential() {
 	ordinaryKriging := ordinarykriging.NewOrdinary(values, lats, lons)
 	ordinaryKriging.Train(ordinarykriging.Exponential, 0, 100)
 	contourRectangle := ordinaryKriging.Contour(200, 200)
 	fmt.Printf("%#v", contourRectangle.Contour[:10])
 	// Output:
-	// []float64{31.062802427639, 31.67443506838088, 32.27805611994289, 32.87380457354172, 33.461820447530236, 34.042244827188064, 34.61521990152406, 35.18088899653797, 35.73939660436969, 36.29088840795865}
+	// []float64{31.06280242763846, 31.674435068380348, 32.27805611994236, 32.8738045735412, 33.46182044752972, 34.04224482718755, 34.61521990152354, 35.180888996537476, 35.7393966043692, 36.29088840795814}
 
 }
 
-func ExampleVariogram_Contour_Spherical() {
+func ExampleVariogram_Contour_spherical() {
 	ordinaryKriging := ordinarykriging.NewOrdinary(values, lats, lons)
 	ordinaryKriging.Train(ordinarykriging.Spherical, 0, 100)
 	contourRectangle := ordinaryKriging.Contour(200, 200)
 	fmt.Printf("%#v", contourRectangle.Contour[:10])
 	// Output:
-	// []float64{31.062802427637955, 31.35568613698695, 31.649070507173384, 31.942636980745615, 32.23631166432933, 32.53011270795399, 32.82405871070559, 33.11816872323164, 33.41246224930053, 33.70695924637588}
+	// []float64{31.06280242763895, 31.355686136987938, 31.649070507174365, 31.94263698074658, 32.23631166433029, 32.530112707954935, 32.82405871070652, 33.118168723232564, 33.41246224930144, 33.70695924637679}
 
 }
 
-func ExampleVariogram_Contour_Gaussian() {
+func ExampleVariogram_Contour_gaussian() {
 	ordinaryKriging := ordinarykriging.NewOrdinary(values, lats, lons)
 	ordinaryKriging.Train(ordinarykriging.Gaussian, 0, 100)
 	contourRectangle := ordinaryKriging.Contour(200, 200)
 	fmt.Printf("%#v", contourRectangle.Contour[:10])
 	// Output:
-	// []float64{31.062802438363697, 31.19418275387546, 31.32895545000455, 31.467084521380848, 31.60853364267539, 31.753266184588494, 31.901245229805934, 32.052433588854164, 32.206793815838, 32.36428822416533}
+	// []float64{31.06280243132413, 31.19418274692037, 31.328955443135307, 31.467084514595356, 31.60853363597684, 31.75326617797287, 31.901245223270955, 32.052433582393824, 32.20679380945912, 32.36428821785838}
 
 }
@@ -0,0 +1,116 @@
+package ordinarykriging
+
+import (
+	"fmt"
+	"math"
+)
+
+// IDWOptions 反距离加权的选项
+type IDWOptions struct {
+	// Power 距离的幂，越大越接近最近邻，0 时为 2
+	Power float64
+	// Radius 搜索半径，0 时不限制，半径内没有样本时预测值为 NaN
+	Radius float64
+	// Neighbors 最近邻样本数，0 时使用全部样本
+	Neighbors int
+}
+
+func (opt *IDWOptions) withDefaults() IDWOptions {
+	o := IDWOptions{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.Power == 0 {
+		o.Power = 2
+	}
+	return o
+}
+
+// IDW 反距离加权插值
+type IDW struct {
+	t []float64
+	x []float64
+	y []float64
+
+	Power     float64
+	Radius    float64
+	Neighbors int
+}
+
+// NewIDW inverse distance weighting
+// 反距离加权插值，预测值为样本值按距离的 -Power 次幂加权的平均，与样本重合时为重合样本的平均
+func NewIDW(t, x, y []float64, opt *IDWOptions) (*IDW, error) {
+	if err := checkPoints(t, x, y, 1); err != nil {
+		return nil, err
+	}
+	o := opt.withDefaults()
+	if !isFinite(o.Power) || o.Power < 0 {
+		return nil, fmt.Errorf("invalid IDW power %v", o.Power)
+	}
+	if !isFinite(o.Radius) || o.Radius < 0 {
+		return nil, fmt.Errorf("invalid IDW radius %v", o.Radius)
+	}
+	if o.Neighbors < 0 {
+		return nil, fmt.Errorf("invalid IDW neighbors %d", o.Neighbors)
+	}
+	t, x, y = copySamples(t, x, y)
+	return &IDW{t: t, x: x, y: y, Power: o.Power, Radius: o.Radius, Neighbors: o.Neighbors}, nil
+}
+
+// TrainingData training values and coordinates
+// 训练数据的副本
+func (idw *IDW) TrainingData() (t, x, y []float64) {
+	return copySamples(idw.t, idw.x, idw.y)
+}
+
+// Predict inverse distance weighted prediction
+func (idw *IDW) Predict(x, y float64) float64 {
+	var nearest []neighbor
+	for i := range idw.t {
+		d := math.Hypot(x-idw.x[i], y-idw.y[i])
+		if idw.Radius > 0 && d > idw.Radius {
+			continue
+		}
+		candidate := neighbor{value: idw.t[i], distance: d}
+		if idw.Neighbors > 0 {
+			nearest = insertNearest(nearest, candidate, idw.Neighbors)
+		} else {
+			nearest = append(nearest, candidate)
+		}
+	}
+	if len(nearest) == 0 {
+		return math.NaN()
+	}
+
+	var sum, weights, exact float64
+	var coincident int
+	for _, nb := range nearest {
+		if nb.distance == 0 {
+			exact += nb.value
+			coincident++
+			continue
+		}
+		w := math.Pow(nb.distance, -idw.Power)
+		sum += w * nb.value
+		weights += w
+	}
+	if coincident > 0 {
+		return exact / float64(coincident)
+	}
+	if weights == 0 || math.IsInf(weights, 0) {
+		// 距离过小或过大使权重溢出时退化为最近的样本
+		return nearestValue(nearest)
+	}
+	return sum / weights
+}
+
+// nearestValue 距离最近的邻近点的值
+func nearestValue(nearest []neighbor) float64 {
+	best := nearest[0]
+	for _, nb := range nearest[1:] {
+		if nb.distance < best.distance {
+			best = nb
+		}
+	}
+	return best.value
+}
@@ -0,0 +1,48 @@
+package ordinarykriging
+
+import (
+	"fmt"
+)
+
+// Interpolator 空间插值方法，Variogram、IDW、RBF 与 NearestNeighbor 都实现了该接口
+// Grid、GridMultiPolygon、Contour、ContourWithBBox 与 DrawOverlay 接受任意的实现
+type Interpolator interface {
+	// Predict 预测点的值，无法估计时为 NaN
+	Predict(x, y float64) float64
+	// TrainingData 训练数据，用于网格的值域、Contour 的范围与绘制样本点
+	TrainingData() (t, x, y []float64)
+}
+
+// VarianceInterpolator 能给出预测方差的插值方法，如 Variogram 的克里金方差
+type VarianceInterpolator interface {
+	Interpolator
+	Variance(x, y float64) float64
+}
+
+var (
+	_ VarianceInterpolator = (*Variogram)(nil)
+	_ Interpolator         = (*IDW)(nil)
+	_ Interpolator         = (*RBF)(nil)
+	_ Interpolator         = (*NearestNeighbor)(nil)
+)
+
+// checkPoints 检查插值样本的长度、样本数与取值
+func checkPoints(t, x, y []float64, min int) error {
+	if len(x) != len(t) || len(y) != len(t) {
+		return fmt.Errorf("%w: %d values, %d x, %d y", ErrMismatchedLengths, len(t), len(x), len(y))
+	}
+	if len(t) < min {
+		return fmt.Errorf("%w: %d samples, at least %d are needed", ErrTooFewPoints, len(t), min)
+	}
+	for i, value := range t {
+		if !isFinite(value) || !isFinite(x[i]) || !isFinite(y[i]) {
+			return fmt.Errorf("%w: sample %d at (%v, %v) is %v", ErrNonFinite, i, x[i], y[i], value)
+		}
+	}
+	return nil
+}
+
+// copySamples 训练数据的副本
+func copySamples(t, x, y []float64) ([]float64, []float64, []float64) {
+	return append([]float64(nil), t...), append([]float64(nil), x...), append([]float64(nil), y...)
+}
@@ -0,0 +1,52 @@
+package ordinarykriging
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// variogramAlias 避免 MarshalJSON 递归
+type variogramAlias Variogram
+
+// variogramJSON 训练好的模型，包含训练数据，可用于保存与加载模型
+type variogramJSON struct {
+	T []float64 `json:"t"`
+	X []float64 `json:"x"`
+	Y []float64 `json:"y"`
+	*variogramAlias
+}
+
+// MarshalJSON 序列化训练好的模型与训练数据
+func (variogram *Variogram) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&variogramJSON{
+		T:              variogram.t,
+		X:              variogram.x,
+		Y:              variogram.y,
+		variogramAlias: (*variogramAlias)(variogram),
+	})
+}
+
+// UnmarshalJSON 加载 MarshalJSON 保存的模型
+func (variogram *Variogram) UnmarshalJSON(data []byte) error {
+	v := &variogramJSON{variogramAlias: (*variogramAlias)(variogram)}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	variogram.t = v.T
+	variogram.x = v.X
+	variogram.y = v.Y
+	if variogram.Model != "" {
+		variogram.model = variogramModelOf(variogram.Model)
+		if variogram.model == nil {
+			return fmt.Errorf("%w %q", ErrUnknownModel, variogram.Model)
+		}
+	}
+	variogram.z = nil
+	if variogram.Transform != nil && variogram.Transform.Type != TransformNone {
+		if variogram.Transform.Type == TransformNormalScore && variogram.Transform.NormalScore == nil {
+			return fmt.Errorf("normal score transform without scores")
+		}
+		variogram.applyTransform()
+	}
+	return nil
+}
@@ -2,10 +2,14 @@ package ordinarykriging
 
 import "math"
 
+// minFloat64 最小值，空切片为 0
 func minFloat64(t []float64) float64 {
-	min := float64(0)
-	for i := 0; i < len(t); i++ {
-		if min == 0 || min > t[i] {
+	if len(t) == 0 {
+		return 0
+	}
+	min := t[0]
+	for i := 1; i < len(t); i++ {
+		if min > t[i] {
 			min = t[i]
 		}
 	}
@@ -13,9 +17,13 @@ func minFloat64(t []float64) float64 {
 	return min
 }
 
+// maxFloat64 最大值，空切片为 0
 func maxFloat64(t []float64) float64 {
-	max := float64(0)
-	for i := 0; i < len(t); i++ {
+	if len(t) == 0 {
+		return 0
+	}
+	max := t[0]
+	for i := 1; i < len(t); i++ {
 		if max < t[i] {
 			max = t[i]
 		}
@@ -35,6 +43,19 @@ func pipFloat64(t []Point, x, y float64) bool {
 	return c
 }
 
+// pipPolygon point in polygon with holes
+// 按奇偶规则判断，落在内环（洞）中的点不在多边形内
+func pipPolygon(polygon PolygonCoordinates, x, y float64) bool {
+	c := false
+	for _, ring := range polygon {
+		if pipFloat64(ring, x, y) {
+			c = !c
+		}
+	}
+
+	return c
+}
+
 func exp(x float64) float64 {
 	if x == 0 {
 		return 1
@@ -42,6 +63,10 @@ func exp(x float64) float64 {
 	return math.Exp(x)
 }
 
+func isFinite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
+
 func pow2(x float64) float64 {
 	return x * x
 }
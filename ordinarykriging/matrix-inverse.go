@@ -3,6 +3,9 @@ package ordinarykriging
 import (
 	"math"
 
+	"gonum.org/v1/gonum/blas"
+	"gonum.org/v1/gonum/blas/blas64"
+	"gonum.org/v1/gonum/lapack/lapack64"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -211,15 +214,74 @@ func matrixInverseByCol_(x []float64, n int) ([]float64, bool) {
 	return xx, false
 }
 
+// matrixInverse 矩阵求逆，奇异或结果不是有限值时返回 false
+// gonum 对病态矩阵的条件数估计可能溢出，此时按残差 ‖A·A⁻¹ - I‖ 判断求得的逆矩阵是否可用
 func matrixInverse(x []float64, n int) ([]float64, bool) {
 	a := mat.NewDense(n, n, x)
 	var ia mat.Dense
 
 	// Take the inverse of a and place the result in ia.
 	err := ia.Inverse(a)
-	if err != nil {
-		return ia.RawMatrix().Data, false
+	data := ia.RawMatrix().Data
+	for _, value := range data {
+		if !isFinite(value) {
+			return data, false
+		}
+	}
+	if err == nil {
+		return data, true
+	}
+	if _, ok := err.(mat.Condition); !ok {
+		return data, false
+	}
+
+	identity := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		identity[i*n+i] = 1
+	}
+	return data, residualWithin(x, data, identity, n, n)
+}
+
+// matrixSolve 以 LU 分解求解 x·s = b，奇异或残差不满足 residualWithin 时返回 false
+// 不使用 mat.Dense.Solve：其条件数估计溢出时不求解，而病态矩阵的解仍可能可用
+func matrixSolve(x, b []float64, n int) ([]float64, bool) {
+	lu := blas64.General{Rows: n, Cols: n, Stride: n, Data: append([]float64(nil), x...)}
+	ipiv := make([]int, n)
+	if !lapack64.Getrf(lu, ipiv) {
+		return nil, false
 	}
+	solution := append([]float64(nil), b...)
+	lapack64.Getrs(blas.NoTrans, lu, blas64.General{Rows: n, Cols: 1, Stride: 1, Data: solution}, ipiv)
 
-	return ia.RawMatrix().Data, true
+	if !residualWithin(x, solution, b, n, 1) {
+		return nil, false
+	}
+	return solution, true
+}
+
+// residualWithin n 阶矩阵 x 与 n 行 columns 列的 s、b 的残差 ‖x·s - b‖ 是否不超过 1e-6·max(‖b‖, 1)，范数为行和范数
+// 只检验 x·s 与某个向量的乘积会掩盖病态矩阵求得的错误结果，须检验全部列
+func residualWithin(x, s, b []float64, n, columns int) bool {
+	residual := blas64.General{Rows: n, Cols: columns, Stride: columns, Data: append([]float64(nil), b...)}
+	blas64.Gemm(blas.NoTrans, blas.NoTrans, 1,
+		blas64.General{Rows: n, Cols: n, Stride: n, Data: x},
+		blas64.General{Rows: n, Cols: columns, Stride: columns, Data: s},
+		-1, residual)
+	return rowSumNorm(residual.Data, columns) <= 1e-6*math.Max(rowSumNorm(b, columns), 1)
+}
+
+// rowSumNorm 按行存储、每行 columns 个元素的矩阵的行和范数，有非有限值时为 NaN
+func rowSumNorm(a []float64, columns int) float64 {
+	var norm float64
+	for i := 0; i < len(a); i += columns {
+		var sum float64
+		for _, value := range a[i : i+columns] {
+			sum += math.Abs(value)
+		}
+		if !isFinite(sum) {
+			return math.NaN()
+		}
+		norm = math.Max(norm, sum)
+	}
+	return norm
 }
@@ -74,8 +74,8 @@ func matrixChol(X []float64, n int) bool {
 		for j := i + 1; j < n; j++ {
 			for k := 0; k < i; k++ {
 				X[j*n+i] -= X[j*n+k] * X[i*n+k]
-				X[j*n+i] /= p[i]
 			}
+			X[j*n+i] /= p[i]
 		}
 	}
 
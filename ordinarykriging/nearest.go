@@ -0,0 +1,36 @@
+package ordinarykriging
+
+import "math"
+
+// NearestNeighbor 最近邻插值，预测值为距离最近的样本的值，距离相同时取最先出现的样本
+type NearestNeighbor struct {
+	t []float64
+	x []float64
+	y []float64
+}
+
+// NewNearestNeighbor nearest neighbor interpolation
+func NewNearestNeighbor(t, x, y []float64) (*NearestNeighbor, error) {
+	if err := checkPoints(t, x, y, 1); err != nil {
+		return nil, err
+	}
+	t, x, y = copySamples(t, x, y)
+	return &NearestNeighbor{t: t, x: x, y: y}, nil
+}
+
+// TrainingData training values and coordinates
+// 训练数据的副本
+func (nn *NearestNeighbor) TrainingData() (t, x, y []float64) {
+	return copySamples(nn.t, nn.x, nn.y)
+}
+
+// Predict value of the nearest sample
+func (nn *NearestNeighbor) Predict(x, y float64) float64 {
+	best, distance := 0, math.Inf(1)
+	for i := range nn.t {
+		if d := math.Hypot(x-nn.x[i], y-nn.y[i]); d < distance {
+			best, distance = i, d
+		}
+	}
+	return nn.t[best]
+}
@@ -0,0 +1,171 @@
+package ordinarykriging
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Tail 正态得分反变换超出样本范围时的外推方式
+type Tail string
+
+const (
+	TailClamp  Tail = "clamp"  // 取样本的最小、最大值
+	TailLinear Tail = "linear" // 按累积概率线性外推到 Min、Max
+	TailPower  Tail = "power"  // 下尾按累积概率的幂函数外推到 Min，上尾按双曲线外推，没有上界
+)
+
+// Tails 正态得分反变换的尾部外推
+type Tails struct {
+	Lower Tail `json:"lower,omitempty"` // 为空时为 TailClamp
+	Upper Tail `json:"upper,omitempty"` // 为空时为 TailClamp
+	// Min、Max 外推的下界与上界，Max 不大于 Min 时为样本范围向两侧各延伸 10%
+	Min float64 `json:"min,omitempty"`
+	Max float64 `json:"max,omitempty"`
+	// Omega 幂函数与双曲线的指数，越大尾部越短，0 时为 1.5
+	Omega float64 `json:"omega,omitempty"`
+}
+
+// ParseTail 解析尾部外推方式
+func ParseTail(name string) (Tail, error) {
+	switch tail := Tail(strings.ToLower(name)); tail {
+	case "", TailClamp, TailLinear, TailPower:
+		return tail, nil
+	}
+	return "", fmt.Errorf("unknown normal score tail %q", name)
+}
+
+// NormalScore normal score transform
+// 正态得分变换，按样本的经验分布将值映射为标准正态分布的分位数
+// 样本值之间线性插值，超出样本范围时按 Tails 外推
+type NormalScore struct {
+	Values []float64 `json:"values"` // 由小到大的不重复样本值
+	Scores []float64 `json:"scores"` // 对应的正态得分
+	Tails  Tails     `json:"tails"`
+}
+
+// NewNormalScore 由样本值建立正态得分变换，第 i 个（由 0 开始）样本的累积概率为 (i+0.5)/n
+// 相同的值取其累积概率的平均
+func NewNormalScore(values []float64) (*NormalScore, error) {
+	return NewWeightedNormalScore(values, nil)
+}
+
+// NewWeightedNormalScore 由样本值与解聚权重建立正态得分变换，weights 为空时等权
+// 每个样本的累积概率为排在其前面的权重之和加上自身权重的一半，再除以总权重
+func NewWeightedNormalScore(values, weights []float64) (*NormalScore, error) {
+	if len(weights) > 0 && len(weights) != len(values) {
+		return nil, fmt.Errorf("%w: %d values, %d weights", ErrMismatchedLengths, len(values), len(weights))
+	}
+	sorted := make([]weightedValue, 0, len(values))
+	var total float64
+	for i, value := range values {
+		weight := 1.0
+		if len(weights) > 0 {
+			weight = weights[i]
+		}
+		if isFinite(value) && isFinite(weight) && weight > 0 {
+			sorted = append(sorted, weightedValue{value, weight})
+			total += weight
+		}
+	}
+	if len(sorted) < 2 {
+		return nil, fmt.Errorf("%w: normal score transform needs at least 2 finite values", ErrTooFewPoints)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].value < sorted[j].value })
+
+	ns := &NormalScore{}
+	var cumulative float64
+	for i := 0; i < len(sorted); {
+		j := i
+		var weight float64
+		for j < len(sorted) && sorted[j].value == sorted[i].value {
+			weight += sorted[j].weight
+			j++
+		}
+		// 第 i 到 j-1 个样本累积概率的平均
+		p := (cumulative + weight/2) / total
+		cumulative += weight
+		ns.Values = append(ns.Values, sorted[i].value)
+		ns.Scores = append(ns.Scores, normalQuantile(p))
+		i = j
+	}
+	if len(ns.Values) < 2 {
+		return nil, fmt.Errorf("%w: normal score transform needs at least 2 distinct values", ErrTooFewPoints)
+	}
+	return ns, nil
+}
+
+type weightedValue struct {
+	value, weight float64
+}
+
+// Forward 值的正态得分
+func (ns *NormalScore) Forward(value float64) float64 {
+	return interpolateSorted(ns.Values, ns.Scores, value)
+}
+
+// Backward 正态得分对应的值
+func (ns *NormalScore) Backward(score float64) float64 {
+	n := len(ns.Scores)
+	switch {
+	case score < ns.Scores[0] && ns.Tails.Lower != "" && ns.Tails.Lower != TailClamp:
+		min, _ := ns.bounds()
+		p, p0, v0 := normalCDF(score), normalCDF(ns.Scores[0]), ns.Values[0]
+		if ns.Tails.Lower == TailPower {
+			return min + (v0-min)*math.Pow(p/p0, 1/ns.omega())
+		}
+		return min + (v0-min)*p/p0
+	case score > ns.Scores[n-1] && ns.Tails.Upper != "" && ns.Tails.Upper != TailClamp:
+		_, max := ns.bounds()
+		p, pn, vn := normalCDF(score), normalCDF(ns.Scores[n-1]), ns.Values[n-1]
+		if ns.Tails.Upper == TailPower && vn > 0 {
+			return vn * math.Pow((1-pn)/(1-p), 1/ns.omega())
+		}
+		return vn + (max-vn)*(p-pn)/(1-pn)
+	}
+	return interpolateSorted(ns.Scores, ns.Values, score)
+}
+
+// bounds 尾部外推的下界与上界
+func (ns *NormalScore) bounds() (float64, float64) {
+	if ns.Tails.Max > ns.Tails.Min {
+		return ns.Tails.Min, ns.Tails.Max
+	}
+	min, max := ns.Values[0], ns.Values[len(ns.Values)-1]
+	return min - (max-min)*0.1, max + (max-min)*0.1
+}
+
+func (ns *NormalScore) omega() float64 {
+	if ns.Tails.Omega > 0 {
+		return ns.Tails.Omega
+	}
+	return 1.5
+}
+
+// interpolateSorted 在递增的 xs 上对 ys 线性插值，超出范围时取端点
+func interpolateSorted(xs, ys []float64, x float64) float64 {
+	n := len(xs)
+	if x <= xs[0] {
+		return ys[0]
+	}
+	if x >= xs[n-1] {
+		return ys[n-1]
+	}
+	i := sort.SearchFloat64s(xs, x)
+	if xs[i] == x {
+		return ys[i]
+	}
+	t := (x - xs[i-1]) / (xs[i] - xs[i-1])
+	return ys[i-1] + t*(ys[i]-ys[i-1])
+}
+
+// normalQuantile 标准正态分布的分位数
+func normalQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+// normalCDF 标准正态分布的累积概率
+func normalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
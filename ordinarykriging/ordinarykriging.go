@@ -5,7 +5,7 @@
 package ordinarykriging
 
 import (
-	"errors"
+	"fmt"
 	"image"
 	"image/color"
 	"math"
@@ -13,6 +13,7 @@ import (
 	"sync"
 
 	"github.com/lvisei/go-kriging/canvas"
+	"github.com/lvisei/go-kriging/pkg/palette"
 )
 
 // Variogram ordinary kriging variogram
@@ -29,7 +30,15 @@ type Variogram struct {
 
 	K     []float64 `json:"K"`
 	M     []float64 `json:"M"`
+	Model ModelType `json:"model"`
 	model variogramModel
+
+	// Transform 不为 nil 时在变换后的值上训练，预测值反变换回原始单位
+	Transform *Transform `json:"transform,omitempty"`
+	// Weights 样本的解聚权重，为空时等权，用于正态得分变换与序贯高斯模拟的直方图
+	Weights []float64 `json:"weights,omitempty"`
+	// z 变换后的训练值
+	z []float64
 }
 
 func NewOrdinary(t, x, y []float64) *Variogram {
@@ -63,95 +72,67 @@ func krigingVariogramSpherical(h, nugget, range_, sill, A float64) float64 {
 	}
 }
 
+// variogramModelOf variogram model function of the model type
+func variogramModelOf(model ModelType) variogramModel {
+	switch model {
+	case Gaussian:
+		return krigingVariogramGaussian
+	case Exponential:
+		return krigingVariogramExponential
+	case Spherical:
+		return krigingVariogramSpherical
+	}
+	return nil
+}
+
 // Train using gaussian processes with bayesian priors
+// 设置了 Transform 时先由训练值确定变换的参数，再在变换后的值上训练；
+// 参数写在 Transform 的副本中，调用者传入的 Transform 不变
+// 训练数据或参数无效时返回 ErrMismatchedLengths、ErrTooFewPoints、ErrUnknownModel、ErrNonFinite，
+// 矩阵不可逆时返回 ErrSingularMatrix
 func (variogram *Variogram) Train(model ModelType, sigma2 float64, alpha float64) (*Variogram, error) {
+	if err := variogram.checkSamples(); err != nil {
+		return nil, err
+	}
+	if variogramModelOf(model) == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknownModel, model)
+	}
+	if !isFinite(sigma2) || !isFinite(alpha) {
+		return nil, fmt.Errorf("%w: sigma2 %v, alpha %v", ErrNonFinite, sigma2, alpha)
+	}
+
 	variogram.Nugget = 0.0
 	variogram.Range = 0.0
 	variogram.Sill = 0.0
 	variogram.A = float64(1) / float64(3)
 	variogram.N = 0.0
 
-	switch model {
-	case Gaussian:
-		variogram.model = krigingVariogramGaussian
-	case Exponential:
-		variogram.model = krigingVariogramExponential
-	case Spherical:
-		variogram.model = krigingVariogramSpherical
-	}
-
-	// Lag distance/semivariance
-	var i, j, k, l, n int
-	n = len(variogram.t)
-
-	var distance DistanceList = make([][2]float64, (n*n-n)/2)
+	variogram.Model = model
+	variogram.model = variogramModelOf(model)
 
-	i = 0
-	k = 0
-	for ; i < n; i++ {
-		for j = 0; j < i; {
-			distance[k] = [2]float64{}
-			distance[k][0] = math.Sqrt(pow2(variogram.x[i]-variogram.x[j]) + pow2(variogram.y[i]-variogram.y[j]))
-			distance[k][1] = math.Abs(variogram.t[i] - variogram.t[j])
-			j++
-			k++
+	variogram.z = nil
+	if variogram.Transform != nil {
+		transform := *variogram.Transform
+		if err := transform.fit(variogram.t, variogram.Weights); err != nil {
+			return nil, err
 		}
+		variogram.Transform = &transform
+		variogram.applyTransform()
 	}
-	sort.Sort(distance)
-	variogram.Range = distance[(n*n-n)/2-1][0]
 
-	// Bin lag distance
-	var lags int
-	if ((n*n - n) / 2) > 30 {
-		lags = 30
-	} else {
-		lags = (n*n - n) / 2
+	lag, semi, err := variogram.lagSemivariance()
+	if err != nil {
+		return nil, err
 	}
 
-	tolerance := variogram.Range / float64(lags)
-
-	lag := make([]float64, lags)
-	semi := make([]float64, lags)
-	if lags < 30 {
-		for l = 0; l < lags; l++ {
-			lag[l] = distance[l][0]
-			semi[l] = distance[l][1]
-		}
-	} else {
-		i = 0
-		j = 0
-		k = 0
-		l = 0
-		for i < lags && j < ((n*n-n)/2) {
-			for {
-				if distance[j][0] > (float64(i+1) * tolerance) {
-					break
-				}
-				lag[l] += distance[j][0]
-				semi[l] += distance[j][1]
-				j++
-				k++
-				if j >= ((n*n - n) / 2) {
-					break
-				}
-			}
-
-			if k > 0 {
-				lag[l] = lag[l] / float64(k)
-				semi[l] = semi[l] / float64(k)
-				l++
-			}
-			i++
-			k = 0
-		}
-		if l < 2 {
-			return nil, errors.New("not enough points")
-		}
-	}
+	var i, j, n int
 
 	// Feature transformation
-	n = l
+	n = len(lag)
 	variogram.Range = lag[n-1] - lag[0]
+	if !(variogram.Range > 0) {
+		return nil, fmt.Errorf("%w: all sample pairs are at the same distance", ErrTooFewPoints)
+	}
 	X := make([]float64, 2*n)
 	for i := 0; i < len(X); i++ {
 		X[i] = 1
@@ -179,8 +160,10 @@ func (variogram *Variogram) Train(model ModelType, sigma2 float64, alpha float64
 	if matrixChol(Z, 2) {
 		matrixChol2inv(Z, 2)
 	} else {
-		// TODO false
-		Z, _ = matrixInverse(cloneZ, 2)
+		var ok bool
+		if Z, ok = matrixInverse(cloneZ, 2); !ok {
+			return nil, fmt.Errorf("%w: variogram least squares, alpha %v", ErrSingularMatrix, alpha)
+		}
 	}
 
 	var W = matrixMultiply(matrixMultiply(Z, Xt, 2, 2, n), Y, 2, n, 1)
@@ -216,20 +199,170 @@ func (variogram *Variogram) Train(model ModelType, sigma2 float64, alpha float64
 	if matrixChol(C, n) {
 		matrixChol2inv(C, n)
 	} else {
-		// TODO false
-		C, _ = matrixInverse(cloneC, n)
+		var ok bool
+		if C, ok = matrixInverse(cloneC, n); !ok {
+			return nil, fmt.Errorf("%w: gram matrix of %d samples, merge coincident samples or set sigma2", ErrSingularMatrix, n)
+		}
 	}
 
 	// Copy unprojected inverted matrix as K 复制未投影的逆矩阵为K
 	copy(K, C)
-	var M = matrixMultiply(C, variogram.t, n, n, 1)
+	var M = matrixMultiply(C, variogram.values(), n, n, 1)
 	variogram.K = K
 	variogram.M = M
 
 	return variogram, nil
 }
 
+// checkLengths 检查 t、x、y 的长度相同，Weights 为空或与样本数相同
+func (variogram *Variogram) checkLengths() error {
+	if len(variogram.x) != len(variogram.t) || len(variogram.y) != len(variogram.t) {
+		return fmt.Errorf("%w: %d values, %d x, %d y", ErrMismatchedLengths, len(variogram.t), len(variogram.x), len(variogram.y))
+	}
+	if len(variogram.Weights) > 0 && len(variogram.Weights) != len(variogram.t) {
+		return fmt.Errorf("%w: %d values, %d weights", ErrMismatchedLengths, len(variogram.t), len(variogram.Weights))
+	}
+	return nil
+}
+
+// checkSamples 检查训练数据的长度、样本数与取值
+func (variogram *Variogram) checkSamples() error {
+	if err := variogram.checkLengths(); err != nil {
+		return err
+	}
+	if len(variogram.t) < 2 {
+		return fmt.Errorf("%w: %d samples, at least 2 are needed", ErrTooFewPoints, len(variogram.t))
+	}
+	for i, value := range variogram.t {
+		if !isFinite(value) || !isFinite(variogram.x[i]) || !isFinite(variogram.y[i]) {
+			return fmt.Errorf("%w: sample %d at (%v, %v) is %v", ErrNonFinite, i, variogram.x[i], variogram.y[i], value)
+		}
+	}
+	for i, weight := range variogram.Weights {
+		if !isFinite(weight) || weight < 0 {
+			return fmt.Errorf("%w: weight %d is %v", ErrNonFinite, i, weight)
+		}
+	}
+	return nil
+}
+
+// applyTransform 计算变换后的训练值
+func (variogram *Variogram) applyTransform() {
+	variogram.z = make([]float64, len(variogram.t))
+	for i, value := range variogram.t {
+		variogram.z[i] = variogram.Transform.Forward(value)
+	}
+}
+
+// values 训练使用的值，设置了 Transform 时为变换后的值
+func (variogram *Variogram) values() []float64 {
+	if variogram.z != nil {
+		return variogram.z
+	}
+	return variogram.t
+}
+
+// lagSemivariance lag distance/semivariance
+// 计算样本两两之间的距离与半方差，并按距离分组
+func (variogram *Variogram) lagSemivariance() ([]float64, []float64, error) {
+	var i, j, k, l, n int
+	n = len(variogram.t)
+	t := variogram.values()
+
+	var distance DistanceList = make([][2]float64, (n*n-n)/2)
+
+	i = 0
+	k = 0
+	for ; i < n; i++ {
+		for j = 0; j < i; {
+			distance[k] = [2]float64{}
+			distance[k][0] = math.Sqrt(pow2(variogram.x[i]-variogram.x[j]) + pow2(variogram.y[i]-variogram.y[j]))
+			distance[k][1] = math.Abs(t[i] - t[j])
+			j++
+			k++
+		}
+	}
+	sort.Sort(distance)
+	maxDistance := distance[(n*n-n)/2-1][0]
+
+	// Bin lag distance
+	var lags int
+	if ((n*n - n) / 2) > 30 {
+		lags = 30
+	} else {
+		lags = (n*n - n) / 2
+	}
+
+	tolerance := maxDistance / float64(lags)
+
+	lag := make([]float64, lags)
+	semi := make([]float64, lags)
+	if lags < 30 {
+		for l = 0; l < lags; l++ {
+			lag[l] = distance[l][0]
+			semi[l] = distance[l][1]
+		}
+	} else {
+		i = 0
+		j = 0
+		k = 0
+		l = 0
+		for i < lags && j < ((n*n-n)/2) {
+			for {
+				if distance[j][0] > (float64(i+1) * tolerance) {
+					break
+				}
+				lag[l] += distance[j][0]
+				semi[l] += distance[j][1]
+				j++
+				k++
+				if j >= ((n*n - n) / 2) {
+					break
+				}
+			}
+
+			if k > 0 {
+				lag[l] = lag[l] / float64(k)
+				semi[l] = semi[l] / float64(k)
+				l++
+			}
+			i++
+			k = 0
+		}
+	}
+	if l < 2 {
+		return nil, nil, fmt.Errorf("%w: %d lag classes, at least 2 are needed", ErrTooFewPoints, l)
+	}
+
+	return lag[:l], semi[:l], nil
+}
+
+// Experimental experimental variogram used for fitting
+// 用于拟合模型的实验变异函数，返回分组后的平均距离与半方差
+func (variogram *Variogram) Experimental() ([]float64, []float64, error) {
+	if err := variogram.checkSamples(); err != nil {
+		return nil, nil, err
+	}
+	return variogram.lagSemivariance()
+}
+
+// Semivariance fitted variogram model value at lag distance h
+// 拟合的变异函数模型在距离 h 处的值
+func (variogram *Variogram) Semivariance(h float64) float64 {
+	return variogram.model(h, variogram.Nugget, variogram.Range, variogram.Sill, variogram.A)
+}
+
+// TrainingData training values and coordinates
+// 训练数据的副本，用于绘制样本点
+func (variogram *Variogram) TrainingData() (t, x, y []float64) {
+	t = append([]float64(nil), variogram.t...)
+	x = append([]float64(nil), variogram.x...)
+	y = append([]float64(nil), variogram.y...)
+	return t, x, y
+}
+
 // Predict model prediction
+// 设置了 Transform 时为反变换后原始单位的值
 func (variogram *Variogram) Predict(x, y float64) float64 {
 	k := make([]float64, variogram.N)
 	for i := 0; i < variogram.N; i++ {
@@ -243,47 +376,74 @@ func (variogram *Variogram) Predict(x, y float64) float64 {
 		)
 	}
 
-	return matrixMultiply(k, variogram.M, 1, variogram.N, 1)[0]
+	value := matrixMultiply(k, variogram.M, 1, variogram.N, 1)[0]
+	if variogram.Transform == nil || variogram.Transform.Type == TransformNone {
+		return value
+	}
+	var variance float64
+	if variogram.Transform.needsVariance() {
+		variance = variogram.Variance(x, y)
+	}
+	return variogram.Transform.Backward(value, variance)
 }
 
-func (variogram *Variogram) Variance(x, y float64) {
+// Variance model prediction variance
+// 预测点的克里金方差，设置了 Transform 时为变换后的单位
+func (variogram *Variogram) Variance(x, y float64) float64 {
+	k := make([]float64, variogram.N)
+	for i := 0; i < variogram.N; i++ {
+		x_ := x - variogram.x[i]
+		y_ := y - variogram.y[i]
+		h := math.Sqrt(pow2(x_) + pow2(y_))
+		k[i] = variogram.model(
+			h,
+			variogram.Nugget, variogram.Range,
+			variogram.Sill, variogram.A,
+		)
+	}
 
+	return variogram.model(0, variogram.Nugget, variogram.Range, variogram.Sill, variogram.A) +
+		matrixMultiply(matrixMultiply(k, variogram.K, 1, variogram.N, variogram.N), k, 1, variogram.N, 1)[0]
 }
 
 // Grid gridded matrices or contour paths
 // 根据 PolygonCoordinates 生成裁剪过的矩阵网格数据
 // 这里 polygon 是一个三维数组，可以变相的支持的多个面，但不符合 Polygon 规范
 // PolygonCoordinates [[[x,y]],[[x,y]]] 两个面
+// 需要支持内环（洞）与多面时使用 GridMultiPolygon
 func (variogram *Variogram) Grid(polygon PolygonCoordinates, width float64) *GridMatrices {
-	n := len(polygon)
-	if n == 0 {
+	return Grid(variogram, polygon, width)
+}
+
+// Grid gridded matrices of any interpolator
+// 与 Variogram.Grid 相同，使用任意的插值方法
+func Grid(interpolator Interpolator, polygon PolygonCoordinates, width float64) *GridMatrices {
+	multiPolygon := make(MultiPolygonCoordinates, len(polygon))
+	for i, ring := range polygon {
+		multiPolygon[i] = PolygonCoordinates{ring}
+	}
+
+	return GridMultiPolygon(interpolator, multiPolygon, width)
+}
+
+// GridMultiPolygon gridded matrices clipped by multi polygon
+// 根据符合 GeoJSON 规范的多面生成裁剪过的矩阵网格数据，每个面的第一个环为外环，其余为内环（洞）
+func (variogram *Variogram) GridMultiPolygon(multiPolygon MultiPolygonCoordinates, width float64) *GridMatrices {
+	return GridMultiPolygon(variogram, multiPolygon, width)
+}
+
+// GridMultiPolygon gridded matrices of any interpolator clipped by multi polygon
+// 与 Variogram.GridMultiPolygon 相同，使用任意的插值方法，Zlim 为训练值的范围，无法估计的格点为 NodataValue
+func GridMultiPolygon(interpolator Interpolator, multiPolygon MultiPolygonCoordinates, width float64) *GridMatrices {
+	n := len(multiPolygon)
+	if n == 0 || len(multiPolygon[0]) == 0 || len(multiPolygon[0][0]) == 0 {
 		return &GridMatrices{}
 	}
 
 	var nodataValue float64 = -9999
 
 	// Boundaries of polygon space
-	xlim := [2]float64{polygon[0][0][0], polygon[0][0][0]}
-	ylim := [2]float64{polygon[0][0][1], polygon[0][0][1]}
-
-	// Polygons
-	for i := 0; i < n; i++ {
-		// Vertices
-		for j := 0; j < len(polygon[i]); j++ {
-			if polygon[i][j][0] < xlim[0] {
-				xlim[0] = polygon[i][j][0]
-			}
-			if polygon[i][j][0] > xlim[1] {
-				xlim[1] = polygon[i][j][0]
-			}
-			if polygon[i][j][1] < ylim[0] {
-				ylim[0] = polygon[i][j][1]
-			}
-			if polygon[i][j][1] > ylim[1] {
-				ylim[1] = polygon[i][j][1]
-			}
-		}
-	}
+	xlim, ylim := multiPolygon.bbox()
 
 	// Alloc for O(N^2) space
 	x := int(math.Ceil((xlim[1] - xlim[0]) / width))
@@ -292,31 +452,18 @@ func (variogram *Variogram) Grid(polygon PolygonCoordinates, width float64) *Gri
 	A := make([][]float64, x+1)
 	for i := 0; i <= x; i++ {
 		A[i] = make([]float64, y+1)
+		for j := 0; j <= y; j++ {
+			A[i][j] = nodataValue
+		}
 	}
 
 	for i := 0; i < n; i++ {
-		currentPolygon := polygon[i]
-		var lxlim [2]float64 // Local dimensions
-		var lylim [2]float64 // Local dimensions
-		// Range for currentPolygon
-		lxlim[0] = currentPolygon[0][0]
-		lxlim[1] = lxlim[0]
-		lylim[0] = currentPolygon[0][1]
-		lylim[1] = lylim[0]
-		for j := 1; j < len(currentPolygon); j++ { // Vertices
-			if currentPolygon[j][0] < lxlim[0] {
-				lxlim[0] = currentPolygon[j][0]
-			}
-			if currentPolygon[j][0] > lxlim[1] {
-				lxlim[1] = currentPolygon[j][0]
-			}
-			if currentPolygon[j][1] < lylim[0] {
-				lylim[0] = currentPolygon[j][1]
-			}
-			if currentPolygon[j][1] > lylim[1] {
-				lylim[1] = currentPolygon[j][1]
-			}
+		currentPolygon := multiPolygon[i]
+		if len(currentPolygon) == 0 || len(currentPolygon[0]) == 0 {
+			continue
 		}
+		// Range for currentPolygon, holes are inside the exterior ring
+		lxlim, lylim := currentPolygon[0].bbox() // Local dimensions
 
 		var a, b [2]int
 		// Loop through polygon subspace
@@ -324,16 +471,22 @@ func (variogram *Variogram) Grid(polygon PolygonCoordinates, width float64) *Gri
 		a[1] = int(math.Ceil(((lxlim[1] - math.Mod(lxlim[1]-xlim[1], width)) - xlim[0]) / width))
 		b[0] = int(math.Floor(((lylim[0] - math.Mod(lylim[0]-ylim[0], width)) - ylim[0]) / width))
 		b[1] = int(math.Ceil(((lylim[1] - math.Mod(lylim[1]-ylim[1], width)) - ylim[0]) / width))
+		if a[1] > x {
+			a[1] = x
+		}
+		if b[1] > y {
+			b[1] = y
+		}
 
 		var wg sync.WaitGroup
-		predictCh := make(chan *PredictDate, (b[1]-b[0])*(a[1]-a[0]))
-		var parallelPredict = func(j, k int, polygon []Point, xTarget, yTarget float64) {
+		predictCh := make(chan *PredictDate, (b[1]-b[0]+1)*(a[1]-a[0]+1))
+		var parallelPredict = func(j, k int, xTarget, yTarget float64) {
+			defer wg.Done()
 			predictDate := &PredictDate{X: j, Y: k}
-			predictDate.Value = variogram.Predict(xTarget,
+			predictDate.Value = interpolator.Predict(xTarget,
 				yTarget,
 			)
 			predictCh <- predictDate
-			defer wg.Done()
 		}
 
 		var xTarget, yTarget float64
@@ -342,17 +495,10 @@ func (variogram *Variogram) Grid(polygon PolygonCoordinates, width float64) *Gri
 			for k := b[0]; k <= b[1]; k++ {
 				yTarget = ylim[0] + float64(k)*width
 
-				if pipFloat64(currentPolygon, xTarget, yTarget) {
+				if pipPolygon(currentPolygon, xTarget, yTarget) {
 					wg.Add(1)
-					go parallelPredict(j, k, currentPolygon, xTarget, yTarget)
-				} else {
-					A[j][k] = nodataValue
+					go parallelPredict(j, k, xTarget, yTarget)
 				}
-				//if pipFloat64(currentPolygon, xTarget, yTarget) {
-				//	A[j][k] = variogram.Predict(xTarget,
-				//		yTarget,
-				//	)
-				//}
 			}
 		}
 
@@ -362,19 +508,19 @@ func (variogram *Variogram) Grid(polygon PolygonCoordinates, width float64) *Gri
 		}()
 
 		for predictDate := range predictCh {
-			if predictDate.Value != 0 {
-				j := predictDate.X
-				k := predictDate.Y
-				A[j][k] = predictDate.Value
+			// 无法估计的格点（如 IDW 搜索半径外的 NaN）记为 nodataValue
+			if !isFinite(predictDate.Value) {
+				continue
 			}
-
+			A[predictDate.X][predictDate.Y] = predictDate.Value
 		}
 	}
 
+	t, _, _ := interpolator.TrainingData()
 	gridMatrices := &GridMatrices{
 		Xlim:        xlim,
 		Ylim:        ylim,
-		Zlim:        [2]float64{minFloat64(variogram.t), maxFloat64(variogram.t)},
+		Zlim:        [2]float64{minFloat64(t), maxFloat64(t)},
 		Width:       width,
 		Data:        A,
 		NodataValue: nodataValue,
@@ -385,9 +531,16 @@ func (variogram *Variogram) Grid(polygon PolygonCoordinates, width float64) *Gri
 // Contour contour paths
 // 根据宽高度生成轮廓数据
 func (variogram *Variogram) Contour(xWidth, yWidth int) *ContourRectangle {
-	xlim := [2]float64{minFloat64(variogram.x), maxFloat64(variogram.x)}
-	ylim := [2]float64{minFloat64(variogram.y), maxFloat64(variogram.y)}
-	zlim := [2]float64{minFloat64(variogram.t), maxFloat64(variogram.t)}
+	return Contour(variogram, xWidth, yWidth)
+}
+
+// Contour contour paths of any interpolator
+// 与 Variogram.Contour 相同，使用任意的插值方法，范围为训练样本的范围
+func Contour(interpolator Interpolator, xWidth, yWidth int) *ContourRectangle {
+	t, x, y := interpolator.TrainingData()
+	xlim := [2]float64{minFloat64(x), maxFloat64(x)}
+	ylim := [2]float64{minFloat64(y), maxFloat64(y)}
+	zlim := [2]float64{minFloat64(t), maxFloat64(t)}
 	xl := xlim[1] - xlim[0]
 	yl := ylim[1] - ylim[0]
 	gridW := xl / float64(xWidth)
@@ -400,7 +553,7 @@ func (variogram *Variogram) Contour(xWidth, yWidth int) *ContourRectangle {
 		yTarget = ylim[0] + float64(j)*gridW
 		for k := 0; k < xWidth; k++ {
 			xTarget = xlim[0] + float64(k)*gridH
-			contour = append(contour, variogram.Predict(xTarget, yTarget))
+			contour = append(contour, interpolator.Predict(xTarget, yTarget))
 		}
 	}
 
@@ -421,10 +574,17 @@ func (variogram *Variogram) Contour(xWidth, yWidth int) *ContourRectangle {
 // ContourWithBBox contour paths
 // 根据 bbox 生成轮廓数据
 func (variogram *Variogram) ContourWithBBox(bbox [4]float64, width float64) *ContourRectangle {
+	return ContourWithBBox(variogram, bbox, width)
+}
+
+// ContourWithBBox contour paths of any interpolator
+// 与 Variogram.ContourWithBBox 相同，使用任意的插值方法
+func ContourWithBBox(interpolator Interpolator, bbox [4]float64, width float64) *ContourRectangle {
+	t, _, _ := interpolator.TrainingData()
 	// x方向
 	xlim := [2]float64{bbox[0], bbox[2]}
 	ylim := [2]float64{bbox[1], bbox[3]}
-	zlim := [2]float64{minFloat64(variogram.t), maxFloat64(variogram.t)}
+	zlim := [2]float64{minFloat64(t), maxFloat64(t)}
 
 	// xy 方向地理跨度
 	geoXWidth := xlim[1] - xlim[0]
@@ -445,7 +605,7 @@ func (variogram *Variogram) ContourWithBBox(bbox [4]float64, width float64) *Con
 		yTarget = bbox[1] + float64(j)*yResolution
 		for k := 0; k < xWidth; k++ {
 			xTarget = bbox[0] + float64(k)*xResolution
-			contour = append(contour, variogram.Predict(xTarget, yTarget))
+			contour = append(contour, interpolator.Predict(xTarget, yTarget))
 		}
 	}
 	contourRectangle := &ContourRectangle{
@@ -463,8 +623,14 @@ func (variogram *Variogram) ContourWithBBox(bbox [4]float64, width float64) *Con
 }
 
 // Plot plotting on the canvas
-// 绘制裁剪过的矩阵网格数据到 canvas 上
+// 同 Plot 函数
 func (variogram *Variogram) Plot(gridMatrices *GridMatrices, width, height int, xlim, ylim [2]float64, colors []GridLevelColor) *canvas.Canvas {
+	return Plot(gridMatrices, width, height, xlim, ylim, colors)
+}
+
+// Plot plotting on the canvas
+// 绘制裁剪过的矩阵网格数据到 canvas 上
+func Plot(gridMatrices *GridMatrices, width, height int, xlim, ylim [2]float64, colors []GridLevelColor) *canvas.Canvas {
 	// Create canvas
 	ctx := canvas.NewCanvas(width, height)
 	// Starting boundaries
@@ -511,8 +677,14 @@ func (variogram *Variogram) Plot(gridMatrices *GridMatrices, width, height int,
 }
 
 // PlotRectangleGrid plot to canvas
-// 绘制矩形网格到数据 canvas 上
+// 同 PlotRectangleGrid 函数
 func (variogram *Variogram) PlotRectangleGrid(contourRectangle *ContourRectangle, width, height int, xlim, ylim [2]float64, colors []color.Color) *canvas.Canvas {
+	return PlotRectangleGrid(contourRectangle, width, height, xlim, ylim, colors)
+}
+
+// PlotRectangleGrid plot to canvas
+// 绘制矩形网格到数据 canvas 上
+func PlotRectangleGrid(contourRectangle *ContourRectangle, width, height int, xlim, ylim [2]float64, colors []color.Color) *canvas.Canvas {
 	// Create canvas
 	ctx := canvas.NewCanvas(width, height)
 	// Starting boundaries
@@ -526,6 +698,10 @@ func (variogram *Variogram) PlotRectangleGrid(contourRectangle *ContourRectangle
 	for i := 0; i < m; i++ {
 		for j := 0; j < n; j++ {
 			index := i*n + j
+			// 无法估计的格点（如 IDW 搜索半径外的 NaN）不绘制
+			if !isFinite(contourRectangle.Contour[index]) {
+				continue
+			}
 			x := (float64(width) * (float64(j)*contourRectangle.XResolution + contourRectangle.Xlim[0] - xlim[0])) / range_[0]
 			y := float64(height) * (1 - (float64(i)*contourRectangle.YResolution+contourRectangle.Ylim[0]-ylim[0])/range_[1])
 			z := (contourRectangle.Contour[index] - contourRectangle.Zlim[0]) / range_[2]
@@ -545,7 +721,14 @@ func (variogram *Variogram) PlotRectangleGrid(contourRectangle *ContourRectangle
 }
 
 // PlotPng plot to png
+// 同 PlotPng 函数
 func (variogram *Variogram) PlotPng(rectangleGrids *ContourRectangle) *image.RGBA {
+	return PlotPng(rectangleGrids)
+}
+
+// PlotPng plot to png
+// 每个格点一个像素，按 Zlim 五等分着色，不是有限值的格点透明
+func PlotPng(rectangleGrids *ContourRectangle) *image.RGBA {
 	contour := rectangleGrids.Contour
 	xWidth := rectangleGrids.XWidth
 	yWidth := rectangleGrids.YWidth
@@ -555,6 +738,10 @@ func (variogram *Variogram) PlotPng(rectangleGrids *ContourRectangle) *image.RGB
 
 	for i := 0; i < xWidth*yWidth; i++ {
 		zi := contour[i]
+		if !isFinite(zi) {
+			// 无法估计的格点保持透明
+			continue
+		}
 		var color color.RGBA
 
 		if zi <= zlim[1] && zi > zlim[1]-colorperiod {
@@ -585,9 +772,130 @@ func (variogram *Variogram) PlotPng(rectangleGrids *ContourRectangle) *image.RGB
 		}
 
 		x := i % xWidth
-		y := i / yWidth
+		y := i / xWidth
 		img.Set(x, y, color)
 	}
 
 	return img
 }
+
+// PlotPalette plot gridded matrices with a palette
+// 同 PlotPalette 函数
+func (variogram *Variogram) PlotPalette(gridMatrices *GridMatrices, width, height int, xlim, ylim [2]float64, p *palette.Palette) *canvas.Canvas {
+	return PlotPalette(gridMatrices, width, height, xlim, ylim, p)
+}
+
+// PlotPalette plot gridded matrices with a palette
+// 按 palette 的颜色绘制裁剪过的矩阵网格数据，无数据值使用 palette 的无数据颜色
+func PlotPalette(gridMatrices *GridMatrices, width, height int, xlim, ylim [2]float64, p *palette.Palette) *canvas.Canvas {
+	ctx := canvas.NewCanvas(width, height)
+	DrawPalette(ctx, gridMatrices, xlim, ylim, p)
+
+	return ctx
+}
+
+// DrawPalette draw gridded matrices with a palette on an existing canvas
+// 同 DrawPalette 函数
+func (variogram *Variogram) DrawPalette(ctx *canvas.Canvas, gridMatrices *GridMatrices, xlim, ylim [2]float64, p *palette.Palette) {
+	DrawPalette(ctx, gridMatrices, xlim, ylim, p)
+}
+
+// DrawPalette draw gridded matrices with a palette on an existing canvas
+// 与 PlotPalette 相同，绘制到已有的画布上（如 NewBasemapCanvas 创建的底图）
+func DrawPalette(ctx *canvas.Canvas, gridMatrices *GridMatrices, xlim, ylim [2]float64, p *palette.Palette) {
+	width, height := float64(ctx.Width), float64(ctx.Height)
+	range_ := [...]float64{xlim[1] - xlim[0], ylim[1] - ylim[0]}
+	wx := math.Ceil(gridMatrices.Width * width / range_[0])
+	wy := math.Ceil(gridMatrices.Width * height / range_[1])
+	nodata := p.NodataColor()
+	_, _, _, nodataAlpha := nodata.RGBA()
+
+	for i := range gridMatrices.Data {
+		for j, value := range gridMatrices.Data[i] {
+			c := nodata
+			if value != gridMatrices.NodataValue {
+				c = p.Color(value)
+			} else if nodataAlpha == 0 {
+				continue
+			}
+			x := width * (float64(i)*gridMatrices.Width + gridMatrices.Xlim[0] - xlim[0]) / range_[0]
+			y := height * (1 - (float64(j)*gridMatrices.Width+gridMatrices.Ylim[0]-ylim[0])/range_[1])
+			ctx.DrawRect(math.Round(x-wx/2), math.Round(y-wy/2), wx, wy, c)
+		}
+	}
+}
+
+// PlotRectangleGridPalette plot rectangle grid with a palette
+// 同 PlotRectangleGridPalette 函数
+func (variogram *Variogram) PlotRectangleGridPalette(contourRectangle *ContourRectangle, width, height int, xlim, ylim [2]float64, p *palette.Palette) *canvas.Canvas {
+	return PlotRectangleGridPalette(contourRectangle, width, height, xlim, ylim, p)
+}
+
+// PlotRectangleGridPalette plot rectangle grid with a palette
+// 按 palette 的颜色绘制矩形网格
+func PlotRectangleGridPalette(contourRectangle *ContourRectangle, width, height int, xlim, ylim [2]float64, p *palette.Palette) *canvas.Canvas {
+	ctx := canvas.NewCanvas(width, height)
+	DrawRectangleGridPalette(ctx, contourRectangle, xlim, ylim, p)
+
+	return ctx
+}
+
+// DrawRectangleGridPalette draw rectangle grid with a palette on an existing canvas
+// 同 DrawRectangleGridPalette 函数
+func (variogram *Variogram) DrawRectangleGridPalette(ctx *canvas.Canvas, contourRectangle *ContourRectangle, xlim, ylim [2]float64, p *palette.Palette) {
+	DrawRectangleGridPalette(ctx, contourRectangle, xlim, ylim, p)
+}
+
+// DrawRectangleGridPalette draw rectangle grid with a palette on an existing canvas
+// 与 PlotRectangleGridPalette 相同，绘制到已有的画布上
+func DrawRectangleGridPalette(ctx *canvas.Canvas, contourRectangle *ContourRectangle, xlim, ylim [2]float64, p *palette.Palette) {
+	width, height := float64(ctx.Width), float64(ctx.Height)
+	range_ := [...]float64{xlim[1] - xlim[0], ylim[1] - ylim[0]}
+	n := contourRectangle.XWidth
+	wx := math.Ceil(contourRectangle.XResolution * width / range_[0])
+	wy := math.Ceil(contourRectangle.YResolution * height / range_[1])
+
+	for i := 0; i < contourRectangle.YWidth; i++ {
+		for j := 0; j < n; j++ {
+			x := (width * (float64(j)*contourRectangle.XResolution + contourRectangle.Xlim[0] - xlim[0])) / range_[0]
+			y := height * (1 - (float64(i)*contourRectangle.YResolution+contourRectangle.Ylim[0]-ylim[0])/range_[1])
+			ctx.DrawRect(math.Round(x-wx/2), math.Round(y-wy/2), wx, wy, p.Color(contourRectangle.Contour[i*n+j]))
+		}
+	}
+}
+
+// PlotPngPalette plot to png with a palette
+// 同 PlotPngPalette 函数
+func (variogram *Variogram) PlotPngPalette(rectangleGrids *ContourRectangle, p *palette.Palette) *image.RGBA {
+	return PlotPngPalette(rectangleGrids, p)
+}
+
+// PlotPngPalette plot to png with a palette
+// 与 PlotPng 相同，每个格点一个像素，第一行为 Ylim[0]
+func PlotPngPalette(rectangleGrids *ContourRectangle, p *palette.Palette) *image.RGBA {
+	xWidth := rectangleGrids.XWidth
+	img := image.NewRGBA(image.Rect(0, 0, xWidth, rectangleGrids.YWidth))
+	for i, value := range rectangleGrids.Contour {
+		img.Set(i%xWidth, i/xWidth, p.Color(value))
+	}
+
+	return img
+}
+
+// NewGridLevelPalette palette of contiguous value ranges
+// 由首尾相接的分级颜色（如 DefaultGridLevelColor）生成 palette，分级区间整体加上 offset；
+// Plot 按 value - Zlim[0] 分级，offset 为 Zlim[0] 时着色与 Plot 相同，为 0 时按绝对值分级
+func NewGridLevelPalette(colors []GridLevelColor, offset float64) *palette.Palette {
+	levels := append([]GridLevelColor(nil), colors...)
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Value[0] < levels[j].Value[0] })
+	breaks := make([]float64, 0, len(levels)+1)
+	classColors := make([]color.Color, 0, len(levels))
+	for i, level := range levels {
+		if i == 0 {
+			breaks = append(breaks, level.Value[0]+offset)
+		}
+		breaks = append(breaks, level.Value[1]+offset)
+		classColors = append(classColors, level.Color)
+	}
+	return palette.NewColors(classColors, breaks)
+}
@@ -1,8 +1,14 @@
 package ordinarykriging_test
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
 	"image/png"
+	"math"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -106,6 +112,42 @@ func TestVariogram_Plot(t *testing.T) {
 	png.Encode(file, img)
 }
 
+func TestVariogram_DrawOverlay(t *testing.T) {
+	ordinaryKriging := ordinarykriging.NewOrdinary([]float64{1, 2}, []float64{2.5, 7.5}, []float64{2.5, 7.5})
+	basemap := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(basemap, basemap.Bounds(), image.NewUniform(color.NRGBA{R: 200, G: 220, B: 240, A: 255}), image.Point{}, draw.Src)
+	ctx := ordinarykriging.NewBasemapCanvas(basemap, 100, 100)
+	xlim, ylim := [2]float64{0, 10}, [2]float64{0, 10}
+	boundary := ordinarykriging.MultiPolygonCoordinates{{{{1, 1}, {9, 1}, {9, 9}, {1, 9}}}}
+	err := ordinaryKriging.DrawOverlay(ctx, xlim, ylim, &ordinarykriging.Overlay{
+		Points:        true,
+		PointColor:    color.NRGBA{R: 255, A: 255},
+		Labels:        true,
+		Boundary:      boundary,
+		BoundaryColor: color.Black,
+		BoundaryWidth: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img := ctx.Image()
+	rgba := func(x, y int) [4]uint32 {
+		r, g, b, a := img.At(x, y).RGBA()
+		return [4]uint32{r >> 8, g >> 8, b >> 8, a >> 8}
+	}
+	// 样本点 (2.5, 2.5) 位于画布 (25, 75)，边界 x = 1 位于画布第 10 列
+	if c := rgba(25, 75); c != [4]uint32{255, 0, 0, 255} {
+		t.Fatalf("unexpected sample point color %v", c)
+	}
+	if c := rgba(10, 50); c[0] > 64 {
+		t.Fatalf("boundary is not drawn at the xlim transform, got %v", c)
+	}
+	if c := rgba(50, 50); c != [4]uint32{200, 220, 240, 255} {
+		t.Fatalf("basemap is not kept, got %v", c)
+	}
+}
+
 func BenchmarkVariogram_Train_Exponential(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		ordinaryKriging := ordinarykriging.NewOrdinary(randomValues, randomLats, randomLons)
@@ -134,3 +176,584 @@ func BenchmarkVariogram_Contour(b *testing.B) {
 		ordinaryKriging.Contour(600, 600)
 	}
 }
+
+func TestVariogram_MarshalJSON(t *testing.T) {
+	ordinaryKriging := ordinarykriging.NewOrdinary(values, lats, lons)
+	if _, err := ordinaryKriging.Train(ordinarykriging.Spherical, 0, 100); err != nil {
+		t.Fatal(err)
+	}
+	content, err := json.Marshal(ordinaryKriging)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := &ordinarykriging.Variogram{}
+	if err := json.Unmarshal(content, loaded); err != nil {
+		t.Fatal(err)
+	}
+	x, y := 118.0, 32.0
+	if loaded.Predict(x, y) != ordinaryKriging.Predict(x, y) {
+		t.Fatalf("loaded model predicts %v, want %v", loaded.Predict(x, y), ordinaryKriging.Predict(x, y))
+	}
+	if loaded.Variance(x, y) != ordinaryKriging.Variance(x, y) {
+		t.Fatalf("loaded model variance %v, want %v", loaded.Variance(x, y), ordinaryKriging.Variance(x, y))
+	}
+}
+
+func TestVariogram_CrossValidate(t *testing.T) {
+	ordinaryKriging := ordinarykriging.NewOrdinary(randomValues, randomLats, randomLons)
+	report, err := ordinaryKriging.CrossValidate(ordinarykriging.Exponential, 0, 100, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.N != len(randomValues) || len(report.Points) != len(randomValues) || report.Folds != 10 {
+		t.Fatalf("unexpected report size %d/%d folds %d", report.N, len(report.Points), report.Folds)
+	}
+	if math.IsNaN(report.RootMeanSquareError) || report.RootMeanSquareError < report.MeanAbsoluteError {
+		t.Fatalf("unexpected errors rmse %v mae %v", report.RootMeanSquareError, report.MeanAbsoluteError)
+	}
+}
+
+func TestVariogram_GridMultiPolygon(t *testing.T) {
+	ordinaryKriging := ordinarykriging.NewOrdinary(randomValues, randomLats, randomLons)
+	if _, err := ordinaryKriging.Train(ordinarykriging.Exponential, 0, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	multiPolygon := ordinarykriging.MultiPolygonCoordinates{
+		{
+			{{117.95, 31.95}, {118.05, 31.95}, {118.05, 32.05}, {117.95, 32.05}, {117.95, 31.95}},
+			{{117.98, 31.98}, {118.02, 31.98}, {118.02, 32.02}, {117.98, 32.02}, {117.98, 31.98}},
+		},
+	}
+	gridMatrices := ordinaryKriging.GridMultiPolygon(multiPolygon, 0.01)
+	if len(gridMatrices.Data) != 11 || len(gridMatrices.Data[0]) != 11 {
+		t.Fatalf("unexpected grid size %dx%d", len(gridMatrices.Data), len(gridMatrices.Data[0]))
+	}
+	if gridMatrices.Data[5][5] != gridMatrices.NodataValue {
+		t.Fatalf("cell in the hole should be nodata, got %v", gridMatrices.Data[5][5])
+	}
+	if gridMatrices.Data[1][1] == gridMatrices.NodataValue {
+		t.Fatal("cell inside the exterior ring should be predicted")
+	}
+}
+
+func TestNormalScore(t *testing.T) {
+	ns, err := ordinarykriging.NewNormalScore([]float64{5, 1, 3, 3, 100, math.NaN()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 对称的累积概率得到对称的得分，相同的值得分相同
+	if len(ns.Values) != 4 || math.Abs(ns.Forward(1)+ns.Forward(100)) > 1e-12 || ns.Forward(3) >= ns.Forward(5) {
+		t.Fatalf("unexpected normal scores %v for %v", ns.Scores, ns.Values)
+	}
+	for _, value := range []float64{1, 2, 3, 40, 100} {
+		if back := ns.Backward(ns.Forward(value)); math.Abs(back-value) > 1e-9 {
+			t.Fatalf("back transform of %v is %v", value, back)
+		}
+	}
+	if ns.Backward(10) != 100 || ns.Backward(-10) != 1 {
+		t.Fatal("back transform is not clamped to the sample range")
+	}
+	if _, err := ordinarykriging.NewNormalScore([]float64{2, 2}); err == nil {
+		t.Fatal("expected error for equal values")
+	}
+}
+
+func TestVariogram_Simulate(t *testing.T) {
+	var values, xs, ys []float64
+	for i := 0; i < 7; i++ {
+		for j := 0; j < 7; j++ {
+			xs = append(xs, float64(i))
+			ys = append(ys, float64(j))
+			values = append(values, 10+3*math.Sin(float64(i)/2)+2*math.Cos(float64(j)/3)+float64((i*7+j)%5)/10)
+		}
+	}
+	variogram, err := ordinarykriging.NewOrdinary(values, xs, ys).Train(ordinarykriging.Exponential, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bbox := [4]float64{0, 0, 6, 6}
+	opt := &ordinarykriging.SimulationOptions{Realizations: 8, Seed: 42}
+	simulation, err := variogram.SimulateWithBBox(bbox, 0.5, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := variogram.SimulateWithBBox(bbox, 0.5, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(simulation.Realizations) != 8 || len(simulation.Realizations[0].Data) != 13 {
+		t.Fatal("unexpected number of realizations or grid size")
+	}
+
+	first, second := simulation.Realizations[0], simulation.Realizations[1]
+	var differs bool
+	for i := range first.Data {
+		for j, value := range first.Data[i] {
+			// 相同的种子得到相同的实现
+			if value != again.Realizations[0].Data[i][j] {
+				t.Fatal("realizations are not reproducible with the same seed")
+			}
+			if value < first.Zlim[0] || value > first.Zlim[1] {
+				t.Fatalf("simulated value %v outside the sample range %v", value, first.Zlim)
+			}
+			differs = differs || value != second.Data[i][j]
+		}
+	}
+	if !differs {
+		t.Fatal("realizations are identical")
+	}
+	// 样本所在的节点等于样本值
+	if value := first.Data[4][6]; math.Abs(value-values[2*7+3]) > 1e-9 {
+		t.Fatalf("node at a sample is %v, expected %v", value, values[2*7+3])
+	}
+
+	mean, low, high := simulation.Mean(), simulation.Percentile(10), simulation.Percentile(90)
+	exceedance := simulation.Exceedance(12)
+	for i := range mean.Data {
+		for j := range mean.Data[i] {
+			if low.Data[i][j] > high.Data[i][j] || mean.Data[i][j] < first.Zlim[0]-1e-9 || mean.Data[i][j] > first.Zlim[1]+1e-9 {
+				t.Fatalf("percentiles %v, %v are not ordered or the mean %v is out of range", low.Data[i][j], high.Data[i][j], mean.Data[i][j])
+			}
+			if p := exceedance.Data[i][j]; p < 0 || p > 1 {
+				t.Fatalf("probability %v outside [0, 1]", p)
+			}
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	var values, xs, ys []float64
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 10; j++ {
+			xs = append(xs, float64(i))
+			ys = append(ys, float64(j))
+			// 偏态的对数正态数据
+			values = append(values, math.Exp(math.Sin(float64(i)/3)+math.Cos(float64(j)/4)+rng.NormFloat64()*0.5))
+		}
+	}
+
+	boxCox := &ordinarykriging.Transform{Type: ordinarykriging.TransformBoxCox}
+	ordinaryKriging := ordinarykriging.NewOrdinary(values, xs, ys)
+	ordinaryKriging.Transform = boxCox
+	trained, err := ordinaryKriging.Train(ordinarykriging.Exponential, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lambda := trained.Transform.BoxCoxLambda(); math.Abs(lambda) > 0.3 || lambda == 0 {
+		t.Fatalf("estimated Box-Cox lambda %v, want near 0 for lognormal data", lambda)
+	}
+	if boxCox.Lambda != 0 || boxCox.FittedLambda != 0 {
+		t.Fatalf("Train modified the caller's transform %+v", boxCox)
+	}
+	// 交叉验证每折重新估计 λ，不改变训练结果的变换
+	lambda := trained.Transform.FittedLambda
+	if _, err := trained.CrossValidate(ordinarykriging.Exponential, 0, 100, 5); err != nil {
+		t.Fatal(err)
+	}
+	if trained.Transform.FittedLambda != lambda {
+		t.Fatalf("CrossValidate modified the fitted lambda %v, want %v", trained.Transform.FittedLambda, lambda)
+	}
+
+	// 对数变换的期望值反变换大于中位数
+	logarithm := &ordinarykriging.Transform{Type: ordinarykriging.TransformLog}
+	if got, want := logarithm.Backward(1, 0.5), math.Exp(1.25); math.Abs(got-want) > 1e-12 {
+		t.Fatalf("lognormal back transform %v, want %v", got, want)
+	}
+	for _, transform := range []*ordinarykriging.Transform{
+		logarithm,
+		{Type: ordinarykriging.TransformNormalScore, Tails: ordinarykriging.Tails{Lower: ordinarykriging.TailPower, Upper: ordinarykriging.TailPower}},
+	} {
+		ordinaryKriging := ordinarykriging.NewOrdinary(values, xs, ys)
+		ordinaryKriging.Transform = transform
+		variogram, err := ordinaryKriging.Train(ordinarykriging.Exponential, 0, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range [][2]float64{{0.5, 0.5}, {4.3, 7.1}, {12, -3}} {
+			if predicted := variogram.Predict(p[0], p[1]); !(predicted > 0) {
+				t.Fatalf("%s transform predicts %v at %v, want positive", transform.Type, predicted, p)
+			}
+		}
+		// 样本点上的预测接近实测值，为原始单位
+		if predicted := variogram.Predict(xs[23], ys[23]); math.Abs(predicted-values[23]) > values[23]*0.5 {
+			t.Fatalf("%s transform predicts %v at a sample of %v", transform.Type, predicted, values[23])
+		}
+
+		data, err := json.Marshal(variogram)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var loaded ordinarykriging.Variogram
+		if err := json.Unmarshal(data, &loaded); err != nil {
+			t.Fatal(err)
+		}
+		if loaded.Predict(4.3, 7.1) != variogram.Predict(4.3, 7.1) {
+			t.Fatalf("loaded %s model predicts %v, want %v", transform.Type, loaded.Predict(4.3, 7.1), variogram.Predict(4.3, 7.1))
+		}
+	}
+
+	negative := ordinarykriging.NewOrdinary([]float64{1, -1, 2}, []float64{0, 1, 2}, []float64{0, 1, 2})
+	negative.Transform = &ordinarykriging.Transform{Type: ordinarykriging.TransformLog}
+	if _, err := negative.Train(ordinarykriging.Exponential, 0, 100); err == nil {
+		t.Fatal("expected error for log transform of a negative value")
+	}
+}
+
+func TestNormalScore_Tails(t *testing.T) {
+	ns, err := ordinarykriging.NewNormalScore([]float64{1, 2, 3, 4, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns.Tails = ordinarykriging.Tails{Lower: ordinarykriging.TailLinear, Upper: ordinarykriging.TailLinear, Min: 0, Max: 10}
+	if low, high := ns.Backward(-10), ns.Backward(10); math.Abs(low) > 1e-9 || math.Abs(high-10) > 1e-9 {
+		t.Fatalf("linear tails reach %v and %v, want 0 and 10", low, high)
+	}
+	ns.Tails.Upper = ordinarykriging.TailPower
+	if high := ns.Backward(3); !(high > 5) || high < ns.Backward(2.5) {
+		t.Fatalf("power upper tail %v is not increasing beyond the maximum", high)
+	}
+}
+
+func TestVariogram_MergeDuplicates(t *testing.T) {
+	values := []float64{1, 2, 3, 10, 5, 6}
+	xs := []float64{0, 1, 0, 5, 1.0005, 9}
+	ys := []float64{0, 1, 0, 5, 1, 9}
+
+	exact := ordinarykriging.NewOrdinary(values, xs, ys)
+	report, err := exact.MergeDuplicates(0, ordinarykriging.DuplicateMean)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Before != 6 || report.After != 5 || len(report.Groups) != 1 || report.Groups[0].Value != 2 {
+		t.Fatalf("unexpected exact duplicate report %+v", report)
+	}
+
+	for policy, want := range map[ordinarykriging.DuplicatePolicy][2]float64{
+		ordinarykriging.DuplicateMean:   {2, 3.5},
+		ordinarykriging.DuplicateMedian: {2, 3.5},
+		ordinarykriging.DuplicateFirst:  {1, 2},
+		ordinarykriging.DuplicateLast:   {3, 5},
+	} {
+		report, err := ordinarykriging.NewOrdinary(values, xs, ys).MergeDuplicates(0.001, policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.After != 4 || len(report.Groups) != 2 {
+			t.Fatalf("%s: expected 2 merged groups, got %+v", policy, report)
+		}
+		if report.Groups[0].Value != want[0] || report.Groups[1].Value != want[1] {
+			t.Fatalf("%s: merged values %v and %v, want %v", policy, report.Groups[0].Value, report.Groups[1].Value, want)
+		}
+	}
+
+	if _, err := ordinarykriging.NewOrdinary(values, xs, ys).MergeDuplicates(0, ordinarykriging.DuplicateError); err == nil {
+		t.Fatal("expected error for duplicate locations")
+	}
+	if _, err := ordinarykriging.NewOrdinary(values, xs, ys).MergeDuplicates(0, "max"); err == nil {
+		t.Fatal("expected error for unknown policy")
+	}
+}
+
+func TestVariogram_Train_errors(t *testing.T) {
+	for name, c := range map[string]struct {
+		t, x, y []float64
+		model   ordinarykriging.ModelType
+		want    error
+	}{
+		"mismatched lengths": {[]float64{1, 2, 3}, []float64{0, 1}, []float64{0, 1, 2}, ordinarykriging.Exponential, ordinarykriging.ErrMismatchedLengths},
+		"one point":          {[]float64{1}, []float64{0}, []float64{0}, ordinarykriging.Exponential, ordinarykriging.ErrTooFewPoints},
+		"two points":         {[]float64{1, 2}, []float64{0, 1}, []float64{0, 1}, ordinarykriging.Exponential, ordinarykriging.ErrTooFewPoints},
+		"unknown model":      {[]float64{1, 2, 3}, []float64{0, 1, 2}, []float64{0, 1, 3}, "linear", ordinarykriging.ErrUnknownModel},
+		"nan value":          {[]float64{1, math.NaN(), 3}, []float64{0, 1, 2}, []float64{0, 1, 3}, ordinarykriging.Exponential, ordinarykriging.ErrNonFinite},
+		"coincident":         {[]float64{1, 2, 3, 4, 5}, []float64{0, 1, 2, 2, 4}, []float64{0, 1, 3, 3, 7}, ordinarykriging.Exponential, ordinarykriging.ErrSingularMatrix},
+	} {
+		_, err := ordinarykriging.NewOrdinary(c.t, c.x, c.y).Train(c.model, 0, 100)
+		if !errors.Is(err, c.want) {
+			t.Fatalf("%s: got error %v, want %v", name, err, c.want)
+		}
+		if _, err := ordinarykriging.NewOrdinary(c.t, c.x, c.y).CrossValidate(c.model, 0, 100, 0); err == nil {
+			t.Fatalf("%s: expected cross validation error", name)
+		}
+	}
+}
+
+func TestScreen(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	var values, xs, ys []float64
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			xs = append(xs, float64(i))
+			ys = append(ys, float64(j))
+			values = append(values, 10+math.Sin(float64(i)/2)+math.Cos(float64(j)/3)+rng.NormFloat64()*0.3)
+		}
+	}
+	spike := 27
+	values[spike] = 40
+	values = append(values, math.NaN(), 11)
+	xs = append(xs, 3.5, 20)
+	ys = append(ys, 3.5, 3)
+
+	bbox := [4]float64{0, 0, 10, 10}
+	for _, method := range []ordinarykriging.OutlierMethod{ordinarykriging.OutlierLocalMedian, ordinarykriging.OutlierKriging} {
+		report, err := ordinarykriging.Screen(values, xs, ys, &ordinarykriging.ScreenOptions{BBox: &bbox, Outliers: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.NonFinite != 1 || report.OutsideBBox != 1 || report.Outliers != 1 {
+			t.Fatalf("%s: unexpected report %+v", method, report)
+		}
+		if !report.Flagged(spike) || report.Flagged(spike+1) {
+			t.Fatalf("%s: the spike should be the only outlier, got %+v", method, report.Flags)
+		}
+		filtered, fx, fy := report.Filter(values, xs, ys)
+		if len(filtered) != len(values)-3 || len(fx) != len(filtered) || len(fy) != len(filtered) {
+			t.Fatalf("%s: filtered %d samples, want %d", method, len(filtered), len(values)-3)
+		}
+	}
+
+	polygon := ordinarykriging.MultiPolygonCoordinates{{{{0, 0}, {4, 0}, {4, 8}, {0, 8}, {0, 0}}}}
+	report, err := ordinarykriging.Screen(values, xs, ys, &ordinarykriging.ScreenOptions{Polygon: polygon})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OutsidePolygon == 0 || report.Outliers != 0 || !report.Flagged(len(values)-1) {
+		t.Fatalf("unexpected polygon report %+v", report)
+	}
+}
+
+func TestDecluster(t *testing.T) {
+	// 规则网格上的低值样本，加上聚集在一角的高值样本
+	var values, xs, ys []float64
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 5; j++ {
+			xs = append(xs, float64(i)*2+1)
+			ys = append(ys, float64(j)*2+1)
+			values = append(values, 1)
+		}
+	}
+	for k := 0; k < 25; k++ {
+		xs = append(xs, 8.5+float64(k%5)*0.2)
+		ys = append(ys, 8.5+float64(k/5)*0.2)
+		values = append(values, 10)
+	}
+
+	cell, err := ordinarykriging.CellDecluster(values, xs, ys, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bbox := [4]float64{0, 0, 10, 10}
+	polygonal, err := ordinarykriging.PolygonalDecluster(values, xs, ys, &ordinarykriging.PolygonalDeclusterOptions{BBox: &bbox})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, declustering := range []*ordinarykriging.Declustering{cell, polygonal} {
+		if declustering.NaiveMean != 5.5 || !(declustering.Mean < 3) {
+			t.Fatalf("%s: declustered mean %v of naive mean %v", declustering.Method, declustering.Mean, declustering.NaiveMean)
+		}
+		if sum := ordinarykriging.WeightedStatistics(declustering.Weights, nil).Mean; math.Abs(sum-1) > 1e-9 {
+			t.Fatalf("%s: mean weight %v, want 1", declustering.Method, sum)
+		}
+		if !(declustering.Weights[0] > 1) || !(declustering.Weights[40] < 1) {
+			t.Fatalf("%s: isolated weight %v, clustered weight %v", declustering.Method, declustering.Weights[0], declustering.Weights[40])
+		}
+	}
+	// 泰森多边形的面积之和为外边界的面积，第一个样本的多边形为 2x2 的正方形
+	if got := polygonal.Weights[0] * 100 / 50; math.Abs(got-4) > 1e-9 {
+		t.Fatalf("voronoi area of the corner sample %v, want 4", got)
+	}
+
+	// 只计算多边形内的面积，多边形外的样本权重为 0
+	half := ordinarykriging.MultiPolygonCoordinates{{{{0, 0}, {10, 0}, {10, 10}, {0, 0}}}}
+	clipped, err := ordinarykriging.PolygonalDecluster(values, xs, ys, &ordinarykriging.PolygonalDeclusterOptions{Polygon: half})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clipped.Weights[4] != 0 || !(clipped.Weights[20] > 0) {
+		t.Fatalf("weights of samples outside and inside the polygon %v and %v", clipped.Weights[4], clipped.Weights[20])
+	}
+
+	ordinaryKriging := ordinarykriging.NewOrdinary(values, xs, ys)
+	ordinaryKriging.Weights = cell.Weights
+	ordinaryKriging.Transform = &ordinarykriging.Transform{Type: ordinarykriging.TransformNormalScore}
+	if _, err := ordinaryKriging.Train(ordinarykriging.Exponential, 0.1, 100); err != nil {
+		t.Fatal(err)
+	}
+	ordinaryKriging.Weights = cell.Weights[1:]
+	if _, err := ordinaryKriging.Train(ordinarykriging.Exponential, 0.1, 100); !errors.Is(err, ordinarykriging.ErrMismatchedLengths) {
+		t.Fatalf("got error %v for mismatched weights", err)
+	}
+
+	// 权重影响正态得分变换的累积概率
+	ns, err := ordinarykriging.NewWeightedNormalScore([]float64{1, 2, 3}, []float64{1, 1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(ns.Forward(3)-0.6744897501960817) > 1e-9 {
+		t.Fatalf("weighted normal score of the heaviest sample %v", ns.Forward(3))
+	}
+	if median := ordinarykriging.WeightedQuantile([]float64{1, 2, 3}, []float64{1, 1, 2}, 0.5); median <= 2 {
+		t.Fatalf("weighted median %v should be above 2", median)
+	}
+}
+
+func TestInterpolators(t *testing.T) {
+	// 线性趋势面上的随机样本
+	r := rand.New(rand.NewSource(1))
+	var values, xs, ys []float64
+	minValue := math.Inf(1)
+	for i := 0; i < 40; i++ {
+		x, y := r.Float64()*10, r.Float64()*10
+		xs, ys = append(xs, x), append(ys, y)
+		values = append(values, 2*x-y+3)
+		minValue = math.Min(minValue, values[i])
+	}
+
+	idw, err := ordinarykriging.NewIDW(values, xs, ys, &ordinarykriging.IDWOptions{Neighbors: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nearest, err := ordinarykriging.NewNearestNeighbor(values, xs, ys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	interpolators := map[string]ordinarykriging.Interpolator{"idw": idw, "nearest": nearest}
+	for _, kernel := range []ordinarykriging.RBFKernel{ordinarykriging.RBFThinPlate, ordinarykriging.RBFMultiquadric, ordinarykriging.RBFGaussian} {
+		rbf, err := ordinarykriging.NewRBF(values, xs, ys, &ordinarykriging.RBFOptions{Kernel: kernel})
+		if err != nil {
+			t.Fatal(err)
+		}
+		interpolators[string(kernel)] = rbf
+		// 带线性趋势的径向基函数插值精确重现线性函数
+		if got := rbf.Predict(4.2, 6.1); math.Abs(got-(2*4.2-6.1+3)) > 1e-6 {
+			t.Fatalf("%s: predict %v on a linear surface, want %v", kernel, got, 2*4.2-6.1+3)
+		}
+	}
+	for name, interpolator := range interpolators {
+		for i := range values {
+			if got := interpolator.Predict(xs[i], ys[i]); math.Abs(got-values[i]) > 1e-6 {
+				t.Fatalf("%s: predict %v at sample %d, want %v", name, got, i, values[i])
+			}
+		}
+		contourRectangle := ordinarykriging.Contour(interpolator, 20, 10)
+		if len(contourRectangle.Contour) != 200 || contourRectangle.Zlim[0] != minValue {
+			t.Fatalf("%s: contour of %d cells, zlim %v", name, len(contourRectangle.Contour), contourRectangle.Zlim)
+		}
+	}
+
+	// 搜索半径内没有样本时为 NaN
+	idw, err = ordinarykriging.NewIDW(values, xs, ys, &ordinarykriging.IDWOptions{Radius: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := idw.Predict(100, 100); !math.IsNaN(got) {
+		t.Fatalf("idw outside the radius %v, want NaN", got)
+	}
+
+	if _, err := ordinarykriging.NewRBF([]float64{1, 2, 3, 4}, []float64{0, 1, 1, 0}, []float64{0, 0, 0, 1}, nil); !errors.Is(err, ordinarykriging.ErrSingularMatrix) {
+		t.Fatalf("coincident samples: %v, want ErrSingularMatrix", err)
+	}
+	if _, err := ordinarykriging.NewIDW(values, xs[:1], ys, nil); !errors.Is(err, ordinarykriging.ErrMismatchedLengths) {
+		t.Fatalf("mismatched lengths: %v, want ErrMismatchedLengths", err)
+	}
+}
+
+func TestPlotRectangleGrid_NaN(t *testing.T) {
+	// 搜索半径外的格点为 NaN，绘制时跳过
+	idw, err := ordinarykriging.NewIDW([]float64{1, 2, 3}, []float64{0, 1, 0}, []float64{0, 0, 1}, &ordinarykriging.IDWOptions{Radius: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	contourRectangle := ordinarykriging.ContourWithBBox(idw, [4]float64{-1, -1, 2, 2}, 30)
+	var nan int
+	for _, value := range contourRectangle.Contour {
+		if math.IsNaN(value) {
+			nan++
+		}
+	}
+	if nan == 0 || nan == len(contourRectangle.Contour) {
+		t.Fatalf("%d of %d cells are NaN", nan, len(contourRectangle.Contour))
+	}
+
+	xlim, ylim := [2]float64{-1, 2}, [2]float64{-1, 2}
+	ordinarykriging.PlotRectangleGrid(contourRectangle, 60, 60, xlim, ylim, ordinarykriging.DefaultLegendColor)
+	img := ordinarykriging.PlotPng(contourRectangle)
+	// 第一个格点 (-1, -1) 距样本超过半径，应保持透明
+	if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+		t.Fatalf("NaN cell alpha %d, want transparent", a)
+	}
+	if _, _, _, a := img.At(10, 10).RGBA(); a == 0 {
+		t.Fatal("cell at the first sample is transparent")
+	}
+}
+
+func TestNewGridLevelPalette_Plot(t *testing.T) {
+	ordinaryKriging := ordinarykriging.NewOrdinary([]float64{12, 20, 31, 25}, []float64{0, 0, 10, 10}, []float64{0, 10, 0, 10})
+	ordinaryKriging.Train(ordinarykriging.Spherical, 0, 100)
+	polygon := ordinarykriging.MultiPolygonCoordinates{{{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}}}
+	gridMatrices := ordinaryKriging.GridMultiPolygon(polygon, 0.5)
+
+	// offset 为 Zlim[0] 时与 Plot 按 value - Zlim[0] 分级的着色相同
+	colors := ordinarykriging.DefaultGridLevelColor
+	p := ordinarykriging.NewGridLevelPalette(colors, gridMatrices.Zlim[0])
+	plot := ordinaryKriging.Plot(gridMatrices, 50, 50, gridMatrices.Xlim, gridMatrices.Ylim, colors).Image()
+	plotPalette := ordinaryKriging.PlotPalette(gridMatrices, 50, 50, gridMatrices.Xlim, gridMatrices.Ylim, p).Image()
+	var differences int
+	for y := 0; y < 50; y++ {
+		for x := 0; x < 50; x++ {
+			if plot.At(x, y) != plotPalette.At(x, y) {
+				differences++
+			}
+		}
+	}
+	if differences > 0 {
+		t.Fatalf("%d of 2500 pixels differ from Plot", differences)
+	}
+}
+
+func TestContour_Zlim(t *testing.T) {
+	// 样本值含 0 或全为负数时 Zlim 为样本值的范围
+	for _, values := range [][]float64{{5, 0, 2}, {-3, -1, -2}} {
+		idw, err := ordinarykriging.NewIDW(values, []float64{0, 1, 2}, []float64{0, 2, 1}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contourRectangle := ordinarykriging.Contour(idw, 4, 4)
+		min, max := values[0], values[0]
+		for _, value := range values {
+			min, max = math.Min(min, value), math.Max(max, value)
+		}
+		if contourRectangle.Zlim != [2]float64{min, max} {
+			t.Fatalf("Zlim %v of %v", contourRectangle.Zlim, values)
+		}
+	}
+}
+
+func TestGridMultiPolygon_NaN(t *testing.T) {
+	// 搜索半径外的格点为 NodataValue，不是 NaN
+	idw, err := ordinarykriging.NewIDW([]float64{1, 2}, []float64{0, 0.2}, []float64{0, 0.2}, &ordinarykriging.IDWOptions{Radius: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	polygon := ordinarykriging.MultiPolygonCoordinates{{{{-1, -1}, {3, -1}, {3, 3}, {-1, 3}, {-1, -1}}}}
+	gridMatrices := ordinarykriging.GridMultiPolygon(idw, polygon, 0.25)
+	var valid, nodata int
+	for _, column := range gridMatrices.Data {
+		for _, value := range column {
+			switch {
+			case math.IsNaN(value):
+				t.Fatal("NaN cell in grid")
+			case value == gridMatrices.NodataValue:
+				nodata++
+			default:
+				valid++
+			}
+		}
+	}
+	if valid == 0 || nodata == 0 {
+		t.Fatalf("%d valid and %d nodata cells", valid, nodata)
+	}
+}
@@ -0,0 +1,132 @@
+package ordinarykriging
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/lvisei/go-kriging/canvas"
+	"github.com/lvisei/go-kriging/pkg/palette"
+)
+
+// Overlay 叠加在绘图结果上的样本点与边界，坐标按 Plot 相同的 xlim、ylim 换算到画布
+type Overlay struct {
+	Points      bool        // 绘制训练样本点
+	PointRadius float64     // 样本点半径，0 时为 3
+	PointColor  color.Color // 样本点颜色，nil 时为黑色
+	// PointPalette 不为 nil 时样本点按样本值取色并加白色描边，优先于 PointColor
+	PointPalette *palette.Palette
+
+	Labels      bool   // 在样本点右上方标注样本值
+	LabelFormat string // 样本值格式，为空时为 "%.1f"
+	// FontPath TrueType 字体文件，为空时使用内置的 7x13 点阵字体，字号不生效
+	FontPath   string
+	FontSize   float64     // 0 时为 10
+	LabelColor color.Color // nil 时为黑色
+
+	Boundary      MultiPolygonCoordinates // 边界轮廓，如 Grid 使用的裁剪多边形
+	BoundaryColor color.Color             // nil 时为深灰
+	BoundaryWidth float64                 // 0 时为 1
+}
+
+// NewBasemapCanvas canvas with a background image
+// 以底图创建画布，底图覆盖 xlim、ylim 的范围，大小不同时缩放到 width、height
+func NewBasemapCanvas(background image.Image, width, height int) *canvas.Canvas {
+	return canvas.NewCanvasWithImage(canvas.ImageResize(background, uint(width), uint(height)))
+}
+
+// DrawOverlay draw boundaries and training points on the canvas
+// 同 DrawOverlay 函数
+func (variogram *Variogram) DrawOverlay(ctx *canvas.Canvas, xlim, ylim [2]float64, overlay *Overlay) error {
+	return DrawOverlay(variogram, ctx, xlim, ylim, overlay)
+}
+
+// DrawOverlay draw boundaries and training points of any interpolator on the canvas
+// 在 Plot 系列函数的画布上绘制边界轮廓与训练样本点，边界在下，样本点在上
+func DrawOverlay(interpolator Interpolator, ctx *canvas.Canvas, xlim, ylim [2]float64, overlay *Overlay) error {
+	if overlay == nil {
+		return nil
+	}
+	width, height := float64(ctx.Width), float64(ctx.Height)
+	project := func(x, y float64) [2]float64 {
+		return [2]float64{
+			width * (x - xlim[0]) / (xlim[1] - xlim[0]),
+			height * (1 - (y-ylim[0])/(ylim[1]-ylim[0])),
+		}
+	}
+
+	boundaryColor, boundaryWidth := overlay.BoundaryColor, overlay.BoundaryWidth
+	if boundaryColor == nil {
+		boundaryColor = color.Gray{Y: 64}
+	}
+	if boundaryWidth <= 0 {
+		boundaryWidth = 1
+	}
+	for _, polygon := range overlay.Boundary {
+		for _, ring := range polygon {
+			if len(ring) < 2 {
+				continue
+			}
+			points := make([][2]float64, 0, len(ring)+1)
+			for _, point := range ring {
+				points = append(points, project(point[0], point[1]))
+			}
+			if ring[0] != ring[len(ring)-1] {
+				points = append(points, points[0])
+			}
+			ctx.DrawPath(points, boundaryColor, boundaryWidth)
+		}
+	}
+
+	if !overlay.Points && !overlay.Labels {
+		return nil
+	}
+	radius := overlay.PointRadius
+	if radius <= 0 {
+		radius = 3
+	}
+	pointColor := overlay.PointColor
+	if pointColor == nil {
+		pointColor = color.Black
+	}
+	format := overlay.LabelFormat
+	if format == "" {
+		format = "%.1f"
+	}
+	fontSize := overlay.FontSize
+	if fontSize <= 0 {
+		fontSize = 10
+	}
+	labelColor := overlay.LabelColor
+	if labelColor == nil {
+		labelColor = color.Black
+	}
+
+	t, x, y := interpolator.TrainingData()
+	for i := range t {
+		p := project(x[i], y[i])
+		if overlay.Points {
+			if overlay.PointPalette != nil {
+				ctx.DrawCircle(p[0], p[1], radius+1, color.White)
+				ctx.DrawCircle(p[0], p[1], radius, overlay.PointPalette.Color(t[i]))
+			} else {
+				ctx.DrawCircle(p[0], p[1], radius, pointColor)
+			}
+		}
+		if overlay.Labels {
+			err := ctx.DrawText(&canvas.TextConfig{
+				Text:     fmt.Sprintf(format, t[i]),
+				FontName: overlay.FontPath,
+				FontSize: fontSize,
+				Color:    labelColor,
+				OffsetX:  p[0] + radius + 2,
+				OffsetY:  p[1] - radius,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
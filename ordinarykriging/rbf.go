@@ -0,0 +1,181 @@
+package ordinarykriging
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// RBFKernel 径向基函数
+type RBFKernel string
+
+const (
+	RBFThinPlate    RBFKernel = "thin-plate"   // 薄板样条 r² ln r
+	RBFMultiquadric RBFKernel = "multiquadric" // 多二次曲面 sqrt(r² + 1)
+	RBFGaussian     RBFKernel = "gaussian"     // 高斯 exp(-r²)
+)
+
+// ParseRBFKernel 解析径向基函数，为空时为 RBFThinPlate
+func ParseRBFKernel(name string) (RBFKernel, error) {
+	switch kernel := RBFKernel(strings.ToLower(name)); kernel {
+	case "":
+		return RBFThinPlate, nil
+	case RBFThinPlate, RBFMultiquadric, RBFGaussian:
+		return kernel, nil
+	}
+	return "", fmt.Errorf("unknown RBF kernel %q", name)
+}
+
+// rbfKernelOf 径向基函数，r 为除以 Epsilon 后的距离
+func rbfKernelOf(kernel RBFKernel) func(r float64) float64 {
+	switch kernel {
+	case RBFThinPlate:
+		return func(r float64) float64 {
+			if r == 0 {
+				return 0
+			}
+			return r * r * math.Log(r)
+		}
+	case RBFMultiquadric:
+		return func(r float64) float64 {
+			return math.Sqrt(r*r + 1)
+		}
+	case RBFGaussian:
+		return func(r float64) float64 {
+			return math.Exp(-r * r)
+		}
+	}
+	return nil
+}
+
+// RBFOptions 径向基函数插值的选项
+type RBFOptions struct {
+	// Kernel 径向基函数，为空时为 RBFThinPlate
+	Kernel RBFKernel
+	// Epsilon 距离的尺度，0 时为样本到最近样本的平均距离
+	// 薄板样条的插值结果与尺度无关，只影响矩阵的条件数；
+	// multiquadric 与 gaussian 的尺度越大曲面越平滑，但矩阵越接近奇异
+	Epsilon float64
+	// Smoothing 平滑系数，加在矩阵的对角线上，0 时插值结果精确通过样本
+	Smoothing float64
+}
+
+// RBF 径向基函数插值
+type RBF struct {
+	t []float64
+	x []float64
+	y []float64
+
+	Kernel    RBFKernel
+	Epsilon   float64
+	Smoothing float64
+	// Coefficients 各样本径向基函数的系数，最后 3 个为线性趋势 1、x、y 的系数
+	Coefficients []float64
+	// Center 线性趋势的坐标原点
+	Center [2]float64
+	kernel func(r float64) float64
+}
+
+// NewRBF radial basis function interpolation
+// 径向基函数加线性趋势的插值，系数由 n+3 阶的线性方程组求得，
+// 样本重合或共线时方程组奇异，返回 ErrSingularMatrix
+func NewRBF(t, x, y []float64, opt *RBFOptions) (*RBF, error) {
+	if err := checkPoints(t, x, y, 3); err != nil {
+		return nil, err
+	}
+	o := RBFOptions{}
+	if opt != nil {
+		o = *opt
+	}
+	kernel, err := ParseRBFKernel(string(o.Kernel))
+	if err != nil {
+		return nil, err
+	}
+	if !isFinite(o.Epsilon) || o.Epsilon < 0 {
+		return nil, fmt.Errorf("invalid RBF epsilon %v", o.Epsilon)
+	}
+	if !isFinite(o.Smoothing) || o.Smoothing < 0 {
+		return nil, fmt.Errorf("invalid RBF smoothing %v", o.Smoothing)
+	}
+
+	n := len(t)
+	t, x, y = copySamples(t, x, y)
+	xlim := [2]float64{minFloat64(x), maxFloat64(x)}
+	ylim := [2]float64{minFloat64(y), maxFloat64(y)}
+	if o.Epsilon == 0 {
+		o.Epsilon = meanNearestDistance(x, y)
+		if o.Epsilon == 0 {
+			o.Epsilon = 1
+		}
+	}
+	rbf := &RBF{
+		t: t, x: x, y: y,
+		Kernel:    kernel,
+		Epsilon:   o.Epsilon,
+		Smoothing: o.Smoothing,
+		Center:    [2]float64{(xlim[0] + xlim[1]) / 2, (ylim[0] + ylim[1]) / 2},
+		kernel:    rbfKernelOf(kernel),
+	}
+
+	// [Φ + sI  P] [c]   [t]
+	// [Pᵀ      0] [d] = [0]，P 的行为 1、(x-cx)/ε、(y-cy)/ε
+	m := n + 3
+	A := make([]float64, m*m)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			value := rbf.kernel(math.Hypot(x[i]-x[j], y[i]-y[j]) / rbf.Epsilon)
+			A[i*m+j], A[j*m+i] = value, value
+		}
+		A[i*m+i] += rbf.Smoothing
+		for k, p := range rbf.trend(x[i], y[i]) {
+			A[i*m+n+k], A[(n+k)*m+i] = p, p
+		}
+	}
+	rhs := make([]float64, m)
+	copy(rhs, t)
+	coefficients, ok := matrixSolve(A, rhs, m)
+	if !ok {
+		return nil, fmt.Errorf("%w: RBF system of %d samples, try a smaller epsilon or a positive smoothing", ErrSingularMatrix, n)
+	}
+	rbf.Coefficients = coefficients
+	return rbf, nil
+}
+
+// trend 线性趋势的基函数
+func (rbf *RBF) trend(x, y float64) [3]float64 {
+	return [3]float64{1, (x - rbf.Center[0]) / rbf.Epsilon, (y - rbf.Center[1]) / rbf.Epsilon}
+}
+
+// TrainingData training values and coordinates
+// 训练数据的副本
+func (rbf *RBF) TrainingData() (t, x, y []float64) {
+	return copySamples(rbf.t, rbf.x, rbf.y)
+}
+
+// Predict radial basis function prediction
+func (rbf *RBF) Predict(x, y float64) float64 {
+	n := len(rbf.t)
+	var value float64
+	for i := 0; i < n; i++ {
+		value += rbf.Coefficients[i] * rbf.kernel(math.Hypot(x-rbf.x[i], y-rbf.y[i])/rbf.Epsilon)
+	}
+	for k, p := range rbf.trend(x, y) {
+		value += rbf.Coefficients[n+k] * p
+	}
+	return value
+}
+
+// meanNearestDistance 样本到最近的其他样本的平均距离
+func meanNearestDistance(x, y []float64) float64 {
+	var sum float64
+	for i := range x {
+		nearest := math.Inf(1)
+		for j := range x {
+			if j != i {
+				nearest = math.Min(nearest, math.Hypot(x[i]-x[j], y[i]-y[j]))
+			}
+		}
+		sum += nearest
+	}
+	return sum / float64(len(x))
+}
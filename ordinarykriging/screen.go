@@ -0,0 +1,328 @@
+package ordinarykriging
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// OutlierMethod 空间异常值的估计方法
+type OutlierMethod string
+
+const (
+	OutlierNone        OutlierMethod = ""             // 不检查异常值
+	OutlierLocalMedian OutlierMethod = "local-median" // 与最近邻样本值的中位数比较
+	OutlierKriging     OutlierMethod = "kriging"      // 与留一法克里金估计比较
+)
+
+// ParseOutlierMethod 解析异常值的估计方法，"none" 为不检查
+func ParseOutlierMethod(name string) (OutlierMethod, error) {
+	switch method := OutlierMethod(strings.ToLower(name)); method {
+	case "none":
+		return OutlierNone, nil
+	case OutlierNone, OutlierLocalMedian, OutlierKriging:
+		return method, nil
+	}
+	return "", fmt.Errorf("unknown outlier method %q", name)
+}
+
+// ScreenIssue 样本的问题
+type ScreenIssue string
+
+const (
+	IssueNonFinite      ScreenIssue = "non-finite"      // 值或坐标为 NaN 或无穷大
+	IssueOutsideBBox    ScreenIssue = "outside-bbox"    // 在期望的范围外
+	IssueOutsidePolygon ScreenIssue = "outside-polygon" // 在期望的多边形外
+	IssueOutlier        ScreenIssue = "outlier"         // 与邻近样本的估计相差过大
+)
+
+// ScreenOptions 样本检查的选项
+type ScreenOptions struct {
+	// BBox 期望的范围 minX,minY,maxX,maxY，为 nil 时不检查
+	BBox *[4]float64
+	// Polygon 期望的多边形，为空时不检查
+	Polygon MultiPolygonCoordinates
+	// Outliers 异常值的估计方法，为空时不检查
+	Outliers OutlierMethod
+	// Neighbors 局部中位数的最近邻样本数，0 时为 8
+	Neighbors int
+	// Threshold 残差的稳健 z 分数超过该值时为异常值，0 时为 3.5
+	Threshold float64
+	// Model、Sigma2、Alpha 留一法克里金的训练参数，Model 为空时为 Exponential，Alpha 为 0 时为 100
+	Model  ModelType
+	Sigma2 float64
+	Alpha  float64
+}
+
+func (opt *ScreenOptions) withDefaults() ScreenOptions {
+	o := ScreenOptions{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.Neighbors <= 0 {
+		o.Neighbors = 8
+	}
+	if o.Threshold <= 0 {
+		o.Threshold = 3.5
+	}
+	if o.Model == "" {
+		o.Model = Exponential
+	}
+	if o.Alpha == 0 {
+		o.Alpha = 100
+	}
+	return o
+}
+
+// ScreenFlag 样本的一个问题
+type ScreenFlag struct {
+	Index int         `json:"index"` // 样本的序号
+	X     float64     `json:"x"`
+	Y     float64     `json:"y"`
+	Value float64     `json:"value"`
+	Issue ScreenIssue `json:"issue"`
+	// Expected、Score 异常值的邻近样本估计与残差的稳健 z 分数
+	Expected float64 `json:"expected,omitempty"`
+	Score    float64 `json:"score,omitempty"`
+}
+
+// ScreenReport 样本检查的报告
+type ScreenReport struct {
+	N              int          `json:"n"`
+	NonFinite      int          `json:"nonFinite"`
+	OutsideBBox    int          `json:"outsideBBox"`
+	OutsidePolygon int          `json:"outsidePolygon"`
+	Outliers       int          `json:"outliers"`
+	Flags          []ScreenFlag `json:"flags"` // 按样本的序号排列，一个样本可有多个问题
+	flagged        []bool
+}
+
+// Flagged 第 i 个样本是否有问题
+func (report *ScreenReport) Flagged(i int) bool {
+	return report.flagged[i]
+}
+
+// Filter 去掉有问题的样本，t、x、y 为 Screen 检查的样本
+func (report *ScreenReport) Filter(t, x, y []float64) ([]float64, []float64, []float64) {
+	var ft, fx, fy []float64
+	for i := range t {
+		if !report.flagged[i] {
+			ft = append(ft, t[i])
+			fx = append(fx, x[i])
+			fy = append(fy, y[i])
+		}
+	}
+	return ft, fx, fy
+}
+
+// Screen 训练前检查样本：值或坐标不是有限值、在期望的范围或多边形外，以及空间异常值
+// 异常值只在其他检查通过的样本中判断：每个样本的值与邻近样本的估计之差为残差，
+// 残差减去中位数再除以 1.4826 倍的绝对中位差为稳健 z 分数，
+// 每次剔除绝对值最大且超过 Threshold 的样本后重新估计，直到没有超过的样本
+// 留一法克里金在通过检查的样本上训练一次，由 Gram 矩阵的逆直接得到每个样本的留一估计
+func Screen(t, x, y []float64, opt *ScreenOptions) (*ScreenReport, error) {
+	if len(x) != len(t) || len(y) != len(t) {
+		return nil, fmt.Errorf("%w: %d values, %d x, %d y", ErrMismatchedLengths, len(t), len(x), len(y))
+	}
+	o := opt.withDefaults()
+	n := len(t)
+	report := &ScreenReport{N: n, flagged: make([]bool, n)}
+	flag := func(i int, issue ScreenIssue) {
+		report.Flags = append(report.Flags, ScreenFlag{Index: i, X: x[i], Y: y[i], Value: t[i], Issue: issue})
+		report.flagged[i] = true
+	}
+
+	var clean []int
+	for i := 0; i < n; i++ {
+		if !isFinite(t[i]) || !isFinite(x[i]) || !isFinite(y[i]) {
+			flag(i, IssueNonFinite)
+			report.NonFinite++
+			continue
+		}
+		if o.BBox != nil && (x[i] < o.BBox[0] || y[i] < o.BBox[1] || x[i] > o.BBox[2] || y[i] > o.BBox[3]) {
+			flag(i, IssueOutsideBBox)
+			report.OutsideBBox++
+		}
+		if len(o.Polygon) > 0 && !o.Polygon.Contains(x[i], y[i]) {
+			flag(i, IssueOutsidePolygon)
+			report.OutsidePolygon++
+		}
+		if !report.flagged[i] {
+			clean = append(clean, i)
+		}
+	}
+
+	if o.Outliers == OutlierNone || len(clean) < 3 {
+		return report, nil
+	}
+	var estimate func(active []int) []float64
+	var remove func(position int)
+	switch o.Outliers {
+	case OutlierLocalMedian:
+		estimate = func(active []int) []float64 {
+			indexes := make([]int, len(active))
+			for k, a := range active {
+				indexes[k] = clean[a]
+			}
+			return localMedians(t, x, y, indexes, o.Neighbors)
+		}
+		remove = func(int) {}
+	case OutlierKriging:
+		loo, err := newLeaveOneOut(t, x, y, clean, &o)
+		if err != nil {
+			return nil, err
+		}
+		estimate, remove = loo.estimate, loo.remove
+	default:
+		return nil, fmt.Errorf("unknown outlier method %q", o.Outliers)
+	}
+
+	// 每次只剔除分数最大的样本再重新估计，避免异常值抬高邻近样本的估计
+	active := make([]int, len(clean))
+	for a := range active {
+		active[a] = a
+	}
+	var outliers []ScreenFlag
+	for len(active) > 2 {
+		expected := estimate(active)
+		residuals := make([]float64, len(active))
+		for k, a := range active {
+			residuals[k] = t[clean[a]] - expected[k]
+		}
+		scores := robustScores(residuals)
+		worst := 0
+		for k := range scores {
+			if math.Abs(scores[k]) > math.Abs(scores[worst]) {
+				worst = k
+			}
+		}
+		if !(math.Abs(scores[worst]) > o.Threshold) {
+			break
+		}
+		i := clean[active[worst]]
+		outliers = append(outliers, ScreenFlag{
+			Index: i, X: x[i], Y: y[i], Value: t[i], Issue: IssueOutlier,
+			Expected: expected[worst], Score: scores[worst],
+		})
+		report.flagged[i] = true
+		remove(active[worst])
+		active = append(active[:worst], active[worst+1:]...)
+	}
+	sort.Slice(outliers, func(a, b int) bool { return outliers[a].Index < outliers[b].Index })
+	report.Outliers = len(outliers)
+	report.Flags = mergeFlags(report.Flags, outliers)
+	return report, nil
+}
+
+// localMedians 每个样本最近的 k 个其他样本值的中位数
+func localMedians(t, x, y []float64, indexes []int, k int) []float64 {
+	medians := make([]float64, len(indexes))
+	for a, i := range indexes {
+		var nearest []neighbor
+		for _, j := range indexes {
+			if j != i {
+				nearest = insertNearest(nearest, neighbor{value: t[j], distance: math.Hypot(x[i]-x[j], y[i]-y[j])}, k)
+			}
+		}
+		values := make([]float64, len(nearest))
+		for b, nb := range nearest {
+			values[b] = nb.value
+		}
+		medians[a] = median(values)
+	}
+	return medians
+}
+
+// leaveOneOut 留一法克里金，变异函数只在全部样本上训练一次
+// 第 a 个样本的留一估计为 t_a - M_a / B_aa，B 为 Gram 矩阵的逆，M = B t；
+// 剔除样本时对 B 与 M 做秩一更新，不重新求逆
+type leaveOneOut struct {
+	t []float64
+	n int
+	B []float64
+	M []float64
+}
+
+func newLeaveOneOut(t, x, y []float64, indexes []int, o *ScreenOptions) (*leaveOneOut, error) {
+	n := len(indexes)
+	ct, cx, cy := make([]float64, n), make([]float64, n), make([]float64, n)
+	for k, i := range indexes {
+		ct[k], cx[k], cy[k] = t[i], x[i], y[i]
+	}
+	variogram, err := NewOrdinary(ct, cx, cy).Train(o.Model, o.Sigma2, o.Alpha)
+	if err != nil {
+		return nil, err
+	}
+	return &leaveOneOut{t: ct, n: n, B: variogram.K, M: variogram.M}, nil
+}
+
+// estimate 保留的样本的留一估计
+func (loo *leaveOneOut) estimate(active []int) []float64 {
+	expected := make([]float64, len(active))
+	for k, a := range active {
+		expected[k] = loo.t[a] - loo.M[a]/loo.B[a*loo.n+a]
+	}
+	return expected
+}
+
+// remove 剔除第 a 个样本：B' = B - B[:,a] B[a,:] / B_aa，M' = M - B[:,a] M_a / B_aa
+func (loo *leaveOneOut) remove(a int) {
+	n, B := loo.n, loo.B
+	pivot := B[a*n+a]
+	for p := 0; p < n; p++ {
+		if p == a {
+			continue
+		}
+		f := B[p*n+a] / pivot
+		loo.M[p] -= f * loo.M[a]
+		for q := 0; q < n; q++ {
+			if q != a {
+				B[p*n+q] -= f * B[a*n+q]
+			}
+		}
+	}
+	for p := 0; p < n; p++ {
+		B[p*n+a], B[a*n+p] = 0, 0
+	}
+	loo.M[a] = 0
+	B[a*n+a] = 1
+}
+
+// robustScores 稳健 z 分数，绝对中位差为 0 时使用平均绝对偏差，都为 0 时分数为 0
+func robustScores(values []float64) []float64 {
+	center := median(values)
+	deviations := make([]float64, len(values))
+	var meanDeviation float64
+	for i, value := range values {
+		deviations[i] = math.Abs(value - center)
+		meanDeviation += deviations[i]
+	}
+	meanDeviation /= float64(len(values))
+
+	scale := 1.4826 * median(deviations)
+	if scale == 0 {
+		scale = 1.2533 * meanDeviation
+	}
+	scores := make([]float64, len(values))
+	if scale == 0 {
+		return scores
+	}
+	for i, value := range values {
+		scores[i] = (value - center) / scale
+	}
+	return scores
+}
+
+// mergeFlags 合并两个按序号排列的问题列表
+func mergeFlags(a, b []ScreenFlag) []ScreenFlag {
+	merged := make([]ScreenFlag, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if b[0].Index < a[0].Index {
+			merged, b = append(merged, b[0]), b[1:]
+		} else {
+			merged, a = append(merged, a[0]), a[1:]
+		}
+	}
+	return append(append(merged, a...), b...)
+}
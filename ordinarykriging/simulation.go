@@ -0,0 +1,357 @@
+package ordinarykriging
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// SimulationOptions 序贯高斯模拟选项
+type SimulationOptions struct {
+	Realizations int   // 实现的数量，0 时为 1
+	Seed         int64 // 随机数种子，相同的种子、样本与网格得到相同的实现
+	// MaxNeighbors 每个节点局部简单克里金使用的最多邻近点（样本与已模拟的节点），0 时为 16
+	MaxNeighbors int
+	// Radius 邻近点的搜索半径，0 时为变异函数的 Range
+	Radius float64
+}
+
+func (opt *SimulationOptions) withDefaults(variogram *Variogram) SimulationOptions {
+	o := SimulationOptions{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.Realizations <= 0 {
+		o.Realizations = 1
+	}
+	if o.MaxNeighbors <= 0 {
+		o.MaxNeighbors = 16
+	}
+	if o.Radius <= 0 {
+		o.Radius = variogram.Range
+	}
+	if !(o.Radius > 0) {
+		o.Radius = math.Inf(1)
+	}
+	return o
+}
+
+// Simulation 序贯高斯模拟的结果，每个实现的网格与 GridMultiPolygon 相同
+type Simulation struct {
+	Realizations []*GridMatrices
+}
+
+// simulationGrid 模拟的网格节点，节点 (i, j) 位于 (xlim[0]+i*width, ylim[0]+j*width)
+type simulationGrid struct {
+	xlim, ylim [2]float64
+	width      float64
+	nx, ny     int
+	nodes      [][2]int
+}
+
+// neighbor 局部克里金的邻近点
+type neighbor struct {
+	x, y, value, distance float64
+}
+
+// Simulate sequential gaussian simulation clipped by multi polygon
+// 在 GridMultiPolygon 相同的网格上做序贯高斯模拟：
+// 样本值做正态得分变换（设置了 Weights 时按解聚权重），按随机路径依次以邻近的样本与已模拟节点做简单克里金，
+// 从条件分布中抽样后再反变换回原始单位，各实现保留样本的直方图与变异函数
+func (variogram *Variogram) Simulate(multiPolygon MultiPolygonCoordinates, width float64, opt *SimulationOptions) (*Simulation, error) {
+	if len(multiPolygon) == 0 || !(width > 0) {
+		return nil, errors.New("simulation needs a polygon and a positive cell width")
+	}
+	xlim, ylim := multiPolygon.bbox()
+	return variogram.simulate(newSimulationGrid(xlim, ylim, width, multiPolygon.Contains), opt)
+}
+
+// SimulateWithBBox sequential gaussian simulation over a bbox
+// 在 bbox 内间距为 width 的网格上做序贯高斯模拟
+func (variogram *Variogram) SimulateWithBBox(bbox [4]float64, width float64, opt *SimulationOptions) (*Simulation, error) {
+	if !(bbox[2] > bbox[0]) || !(bbox[3] > bbox[1]) || !(width > 0) {
+		return nil, errors.New("simulation needs a non-empty bbox and a positive cell width")
+	}
+	inside := func(x, y float64) bool { return true }
+	return variogram.simulate(newSimulationGrid([2]float64{bbox[0], bbox[2]}, [2]float64{bbox[1], bbox[3]}, width, inside), opt)
+}
+
+func newSimulationGrid(xlim, ylim [2]float64, width float64, inside func(x, y float64) bool) *simulationGrid {
+	grid := &simulationGrid{
+		xlim:  xlim,
+		ylim:  ylim,
+		width: width,
+		nx:    int(math.Ceil((xlim[1]-xlim[0])/width)) + 1,
+		ny:    int(math.Ceil((ylim[1]-ylim[0])/width)) + 1,
+	}
+	for i := 0; i < grid.nx; i++ {
+		for j := 0; j < grid.ny; j++ {
+			if inside(grid.location(i, j)) {
+				grid.nodes = append(grid.nodes, [2]int{i, j})
+			}
+		}
+	}
+	return grid
+}
+
+func (grid *simulationGrid) location(i, j int) (float64, float64) {
+	return grid.xlim[0] + float64(i)*grid.width, grid.ylim[0] + float64(j)*grid.width
+}
+
+func (variogram *Variogram) simulate(grid *simulationGrid, opt *SimulationOptions) (*Simulation, error) {
+	if variogram.model == nil {
+		return nil, errors.New("variogram is not trained")
+	}
+	rho, err := variogram.correlogram()
+	if err != nil {
+		return nil, err
+	}
+	ns, err := NewWeightedNormalScore(variogram.t, variogram.Weights)
+	if err != nil {
+		return nil, err
+	}
+	scores := make([]float64, len(variogram.t))
+	for i, value := range variogram.t {
+		scores[i] = ns.Forward(value)
+	}
+	o := opt.withDefaults(variogram)
+
+	simulation := &Simulation{Realizations: make([]*GridMatrices, o.Realizations)}
+	var wg sync.WaitGroup
+	for r := 0; r < o.Realizations; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			// 每个实现使用独立的随机数，与并行的调度无关
+			rng := rand.New(rand.NewSource(o.Seed + int64(r)))
+			simulation.Realizations[r] = variogram.realization(grid, ns, scores, rho, &o, rng)
+		}(r)
+	}
+	wg.Wait()
+
+	return simulation, nil
+}
+
+// correlogram 正态得分的相关函数，由变异函数的形状换算为基台值为 1，保留块金效应的比例
+func (variogram *Variogram) correlogram() (func(h float64) float64, error) {
+	nugget := variogram.Semivariance(0)
+	total := variogram.Semivariance(math.Inf(1))
+	if !(total > nugget) || !(total > 0) {
+		return nil, errors.New("variogram has no spatial structure to simulate")
+	}
+	c0 := math.Max(0, math.Min(nugget/total, 1))
+	return func(h float64) float64 {
+		if h == 0 {
+			return 1
+		}
+		s := (variogram.Semivariance(h) - nugget) / (total - nugget)
+		return (1 - c0) * (1 - math.Max(0, math.Min(s, 1)))
+	}, nil
+}
+
+// realization 一个实现，返回反变换后的网格
+func (variogram *Variogram) realization(grid *simulationGrid, ns *NormalScore, scores []float64, rho func(float64) float64, o *SimulationOptions, rng *rand.Rand) *GridMatrices {
+	simulated := make([][]float64, grid.nx)
+	for i := range simulated {
+		simulated[i] = make([]float64, grid.ny)
+		for j := range simulated[i] {
+			simulated[i][j] = math.NaN()
+		}
+	}
+
+	for _, k := range rng.Perm(len(grid.nodes)) {
+		i, j := grid.nodes[k][0], grid.nodes[k][1]
+		neighbors := variogram.neighbors(grid, simulated, scores, i, j, o)
+		mean, variance := simpleKriging(neighbors, rho)
+		simulated[i][j] = mean + math.Sqrt(variance)*rng.NormFloat64()
+	}
+
+	nodataValue := -9999.0
+	data := make([][]float64, grid.nx)
+	for i := range data {
+		data[i] = make([]float64, grid.ny)
+		for j := range data[i] {
+			data[i][j] = nodataValue
+			if !math.IsNaN(simulated[i][j]) {
+				data[i][j] = ns.Backward(simulated[i][j])
+			}
+		}
+	}
+	return &GridMatrices{
+		Data:        data,
+		Width:       grid.width,
+		Xlim:        grid.xlim,
+		Ylim:        grid.ylim,
+		Zlim:        [2]float64{minFloat64(variogram.t), maxFloat64(variogram.t)},
+		NodataValue: nodataValue,
+	}
+}
+
+// neighbors 节点 (i, j) 搜索半径内最近的样本与已模拟节点
+// 已模拟节点由近及远逐圈搜索，最近的 MaxNeighbors 个确定后停止
+func (variogram *Variogram) neighbors(grid *simulationGrid, simulated [][]float64, scores []float64, i, j int, o *SimulationOptions) []neighbor {
+	x, y := grid.location(i, j)
+	nearest := make([]neighbor, 0, o.MaxNeighbors)
+	for k := range variogram.t {
+		d := math.Hypot(variogram.x[k]-x, variogram.y[k]-y)
+		if d <= o.Radius {
+			nearest = insertNearest(nearest, neighbor{x: variogram.x[k], y: variogram.y[k], value: scores[k], distance: d}, o.MaxNeighbors)
+		}
+	}
+
+	maxRing := grid.nx + grid.ny
+	if rings := o.Radius / grid.width; rings < float64(maxRing) {
+		maxRing = int(math.Ceil(rings))
+	}
+	for ring := 1; ring <= maxRing; ring++ {
+		// 第 ring 圈之外的节点距离都大于 ring*width
+		if len(nearest) == o.MaxNeighbors && nearest[len(nearest)-1].distance <= float64(ring)*grid.width {
+			break
+		}
+		// 第 ring 圈的 8*ring 个节点
+		for side := 0; side < 4; side++ {
+			for step := -ring; step < ring; step++ {
+				var ni, nj int
+				switch side {
+				case 0:
+					ni, nj = i+step, j-ring
+				case 1:
+					ni, nj = i+ring, j+step
+				case 2:
+					ni, nj = i-step, j+ring
+				case 3:
+					ni, nj = i-ring, j-step
+				}
+				if ni < 0 || ni >= grid.nx || nj < 0 || nj >= grid.ny || math.IsNaN(simulated[ni][nj]) {
+					continue
+				}
+				nx, ny := grid.location(ni, nj)
+				if d := math.Hypot(nx-x, ny-y); d <= o.Radius {
+					nearest = insertNearest(nearest, neighbor{x: nx, y: ny, value: simulated[ni][nj], distance: d}, o.MaxNeighbors)
+				}
+			}
+		}
+	}
+	return nearest
+}
+
+// insertNearest 将 candidate 插入按距离由近及远排列、最多 k 个的 nearest
+func insertNearest(nearest []neighbor, candidate neighbor, k int) []neighbor {
+	if len(nearest) < k {
+		nearest = append(nearest, candidate)
+	} else if candidate.distance < nearest[k-1].distance {
+		nearest[k-1] = candidate
+	} else {
+		return nearest
+	}
+	for i := len(nearest) - 1; i > 0 && nearest[i].distance < nearest[i-1].distance; i-- {
+		nearest[i], nearest[i-1] = nearest[i-1], nearest[i]
+	}
+	return nearest
+}
+
+// simpleKriging 均值为 0 的简单克里金估计与方差
+// 协方差矩阵不可逆时只使用最近的邻近点
+func simpleKriging(neighbors []neighbor, rho func(float64) float64) (float64, float64) {
+	n := len(neighbors)
+	if n == 0 {
+		return 0, 1
+	}
+	C := make([]float64, n*n)
+	c := make([]float64, n)
+	for a := 0; a < n; a++ {
+		c[a] = rho(neighbors[a].distance)
+		for b := 0; b <= a; b++ {
+			C[a*n+b] = rho(math.Hypot(neighbors[a].x-neighbors[b].x, neighbors[a].y-neighbors[b].y))
+			C[b*n+a] = C[a*n+b]
+		}
+	}
+	C, ok := matrixInverse(C, n)
+	if !ok {
+		return simpleKriging(neighbors[:1], rho)
+	}
+
+	w := matrixMultiply(C, c, n, n, 1)
+	var mean, variance float64 = 0, 1
+	for a := 0; a < n; a++ {
+		mean += w[a] * neighbors[a].value
+		variance -= w[a] * c[a]
+	}
+	return mean, math.Max(variance, 0)
+}
+
+// Mean E-type estimate
+// 各节点所有实现的平均（E-type 估计）
+func (simulation *Simulation) Mean() *GridMatrices {
+	return simulation.summarize(nil, func(values []float64) float64 {
+		var sum float64
+		for _, value := range values {
+			sum += value
+		}
+		return sum / float64(len(values))
+	})
+}
+
+// Percentile 各节点所有实现的第 p 百分位数，p 为 0 到 100，相邻的实现之间线性插值
+func (simulation *Simulation) Percentile(p float64) *GridMatrices {
+	q := math.Max(0, math.Min(p, 100)) / 100
+	return simulation.summarize(nil, func(values []float64) float64 {
+		sort.Float64s(values)
+		position := q * float64(len(values)-1)
+		lower := int(math.Floor(position))
+		if lower+1 >= len(values) {
+			return values[lower]
+		}
+		return values[lower] + (position-float64(lower))*(values[lower+1]-values[lower])
+	})
+}
+
+// Exceedance probability of exceedance
+// 各节点超过 threshold 的实现比例
+func (simulation *Simulation) Exceedance(threshold float64) *GridMatrices {
+	return simulation.summarize(&[2]float64{0, 1}, func(values []float64) float64 {
+		var count int
+		for _, value := range values {
+			if value > threshold {
+				count++
+			}
+		}
+		return float64(count) / float64(len(values))
+	})
+}
+
+// summarize 由各节点所有实现的值计算统计网格，zlim 为 nil 时与实现相同
+func (simulation *Simulation) summarize(zlim *[2]float64, f func(values []float64) float64) *GridMatrices {
+	if len(simulation.Realizations) == 0 {
+		return &GridMatrices{}
+	}
+	first := simulation.Realizations[0]
+	summary := &GridMatrices{
+		Data:        make([][]float64, len(first.Data)),
+		Width:       first.Width,
+		Xlim:        first.Xlim,
+		Ylim:        first.Ylim,
+		Zlim:        first.Zlim,
+		NodataValue: first.NodataValue,
+	}
+	if zlim != nil {
+		summary.Zlim = *zlim
+	}
+	values := make([]float64, len(simulation.Realizations))
+	for i := range first.Data {
+		summary.Data[i] = make([]float64, len(first.Data[i]))
+		for j := range first.Data[i] {
+			if first.Data[i][j] == first.NodataValue {
+				summary.Data[i][j] = first.NodataValue
+				continue
+			}
+			for r, realization := range simulation.Realizations {
+				values[r] = realization.Data[i][j]
+			}
+			summary.Data[i][j] = f(values)
+		}
+	}
+	return summary
+}
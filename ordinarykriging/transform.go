@@ -0,0 +1,196 @@
+package ordinarykriging
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// TransformType 训练前对样本值的变换
+type TransformType string
+
+const (
+	TransformNone        TransformType = ""             // 不变换
+	TransformLog         TransformType = "log"          // 自然对数
+	TransformBoxCox      TransformType = "boxcox"       // Box-Cox 幂变换
+	TransformNormalScore TransformType = "normal-score" // 正态得分变换
+)
+
+// ParseTransformType 解析变换类型，"none" 为不变换
+func ParseTransformType(name string) (TransformType, error) {
+	switch t := TransformType(strings.ToLower(name)); t {
+	case "none":
+		return TransformNone, nil
+	case TransformNone, TransformLog, TransformBoxCox, TransformNormalScore:
+		return t, nil
+	}
+	return "", fmt.Errorf("unknown transform %q", name)
+}
+
+// backwardQuantiles 反变换期望值的数值积分点数
+const backwardQuantiles = 64
+
+// Transform 样本值的变换，Train 在变换后的值上拟合变异函数，
+// Predict、Grid、ContourWithBBox 等将预测值反变换回原始单位，Variance 仍为变换后的单位
+//
+// 反变换默认取期望值：克里金估计 m 与克里金方差 s² 视为变换后的正态分布 N(m, s²)，
+// 对数变换为 exp(m+s²/2)，Box-Cox 与正态得分变换按分位数数值积分；Median 为 true 时直接反变换 m
+type Transform struct {
+	Type TransformType `json:"type"`
+	// Shift 对数与 Box-Cox 变换前加在值上，使所有值为正
+	Shift float64 `json:"shift,omitempty"`
+	// Lambda Box-Cox 变换的参数，0 时在 Train 中按最大似然估计
+	Lambda float64 `json:"lambda,omitempty"`
+	// FittedLambda Lambda 为 0 时 Train 估计的 λ
+	FittedLambda float64 `json:"fittedLambda,omitempty"`
+	// Median 反变换不做偏差校正，得到中位数而不是期望值
+	Median bool `json:"median,omitempty"`
+	// Tails 正态得分反变换超出样本范围时的外推方式
+	Tails Tails `json:"tails"`
+	// NormalScore Train 中由样本建立的正态得分变换
+	NormalScore *NormalScore `json:"normalScore,omitempty"`
+}
+
+// BoxCoxLambda Box-Cox 变换使用的 λ，Lambda 为 0 时为 FittedLambda
+func (transform *Transform) BoxCoxLambda() float64 {
+	if transform.Lambda != 0 {
+		return transform.Lambda
+	}
+	return transform.FittedLambda
+}
+
+// fit 由样本值确定变换的参数，正态得分变换使用解聚权重 weights
+// 每次都重新估计 FittedLambda 与 NormalScore，用户设置的 Lambda 不变
+func (transform *Transform) fit(values, weights []float64) error {
+	switch transform.Type {
+	case TransformNone:
+		return nil
+	case TransformLog, TransformBoxCox:
+		for _, value := range values {
+			if !(value+transform.Shift > 0) {
+				return fmt.Errorf("%s transform needs positive values, %v + shift %v is not, set a larger shift", transform.Type, value, transform.Shift)
+			}
+		}
+		transform.FittedLambda = 0
+		if transform.Type == TransformBoxCox && transform.Lambda == 0 {
+			transform.FittedLambda = transform.estimateLambda(values)
+		}
+		return nil
+	case TransformNormalScore:
+		ns, err := NewWeightedNormalScore(values, weights)
+		if err != nil {
+			return err
+		}
+		ns.Tails = transform.Tails
+		transform.NormalScore = ns
+		return nil
+	}
+	return fmt.Errorf("unknown transform %q", transform.Type)
+}
+
+// Forward 原始值变换后的值
+func (transform *Transform) Forward(value float64) float64 {
+	switch transform.Type {
+	case TransformLog:
+		return math.Log(value + transform.Shift)
+	case TransformBoxCox:
+		return boxCox(value+transform.Shift, transform.BoxCoxLambda())
+	case TransformNormalScore:
+		return transform.NormalScore.Forward(value)
+	}
+	return value
+}
+
+// Backward 变换后的克里金估计反变换回原始单位，variance 为变换后单位的克里金方差
+func (transform *Transform) Backward(value, variance float64) float64 {
+	variance = math.Max(variance, 0)
+	switch transform.Type {
+	case TransformLog:
+		if transform.Median {
+			return math.Exp(value) - transform.Shift
+		}
+		return math.Exp(value+variance/2) - transform.Shift
+	case TransformBoxCox, TransformNormalScore:
+		if transform.Median || variance == 0 {
+			return transform.backward(value)
+		}
+		// 分位数中点上反变换值的平均
+		sd := math.Sqrt(variance)
+		var sum float64
+		for k := 0; k < backwardQuantiles; k++ {
+			sum += transform.backward(value + sd*normalQuantile((float64(k)+0.5)/backwardQuantiles))
+		}
+		return sum / backwardQuantiles
+	}
+	return value
+}
+
+// needsVariance 反变换是否需要克里金方差
+func (transform *Transform) needsVariance() bool {
+	return transform.Type != TransformNone && !transform.Median
+}
+
+// backward 不做偏差校正的反变换
+func (transform *Transform) backward(value float64) float64 {
+	switch transform.Type {
+	case TransformLog:
+		return math.Exp(value) - transform.Shift
+	case TransformBoxCox:
+		return inverseBoxCox(value, transform.BoxCoxLambda()) - transform.Shift
+	case TransformNormalScore:
+		return transform.NormalScore.Backward(value)
+	}
+	return value
+}
+
+// estimateLambda 在 [-3, 3] 内按 0.01 的步长取 Box-Cox 轮廓对数似然最大的 λ
+func (transform *Transform) estimateLambda(values []float64) float64 {
+	n := float64(len(values))
+	var sumLog float64
+	for _, value := range values {
+		sumLog += math.Log(value + transform.Shift)
+	}
+	best, bestLikelihood := 1.0, math.Inf(-1)
+	transformed := make([]float64, len(values))
+	for step := -300; step <= 300; step++ {
+		lambda := float64(step) / 100
+		var mean float64
+		for i, value := range values {
+			transformed[i] = boxCox(value+transform.Shift, lambda)
+			mean += transformed[i]
+		}
+		mean /= n
+		var variance float64
+		for _, value := range transformed {
+			variance += pow2(value - mean)
+		}
+		variance /= n
+		if !(variance > 0) {
+			continue
+		}
+		if likelihood := -n/2*math.Log(variance) + (lambda-1)*sumLog; likelihood > bestLikelihood {
+			best, bestLikelihood = lambda, likelihood
+		}
+	}
+	return best
+}
+
+// boxCox Box-Cox 变换，λ 为 0 时为自然对数
+func boxCox(value, lambda float64) float64 {
+	if lambda == 0 {
+		return math.Log(value)
+	}
+	return (math.Pow(value, lambda) - 1) / lambda
+}
+
+// inverseBoxCox Box-Cox 逆变换，超出定义域时取 0
+func inverseBoxCox(value, lambda float64) float64 {
+	if lambda == 0 {
+		return math.Exp(value)
+	}
+	base := lambda*value + 1
+	if base <= 0 {
+		return 0
+	}
+	return math.Pow(base, 1/lambda)
+}
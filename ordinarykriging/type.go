@@ -1,6 +1,9 @@
 package ordinarykriging
 
-import "image/color"
+import (
+	"image/color"
+	"math"
+)
 
 type ModelType string
 
@@ -80,6 +83,61 @@ type Ring []Point
 
 type PolygonCoordinates []Ring
 
+// MultiPolygonCoordinates GeoJSON MultiPolygon 坐标
+// 每个 PolygonCoordinates 的第一个环为外环，其余为内环（洞）
+type MultiPolygonCoordinates []PolygonCoordinates
+
+// bbox 环的范围
+func (ring Ring) bbox() ([2]float64, [2]float64) {
+	xlim := [2]float64{ring[0][0], ring[0][0]}
+	ylim := [2]float64{ring[0][1], ring[0][1]}
+	for _, point := range ring[1:] {
+		xlim[0] = math.Min(xlim[0], point[0])
+		xlim[1] = math.Max(xlim[1], point[0])
+		ylim[0] = math.Min(ylim[0], point[1])
+		ylim[1] = math.Max(ylim[1], point[1])
+	}
+	return xlim, ylim
+}
+
+// bbox 所有外环的范围
+func (multiPolygon MultiPolygonCoordinates) bbox() ([2]float64, [2]float64) {
+	var xlim, ylim [2]float64
+	first := true
+	for _, polygon := range multiPolygon {
+		if len(polygon) == 0 || len(polygon[0]) == 0 {
+			continue
+		}
+		lxlim, lylim := polygon[0].bbox()
+		if first {
+			xlim, ylim = lxlim, lylim
+			first = false
+			continue
+		}
+		xlim[0] = math.Min(xlim[0], lxlim[0])
+		xlim[1] = math.Max(xlim[1], lxlim[1])
+		ylim[0] = math.Min(ylim[0], lylim[0])
+		ylim[1] = math.Max(ylim[1], lylim[1])
+	}
+	return xlim, ylim
+}
+
+// BBox 所有外环的范围 minX,minY,maxX,maxY
+func (multiPolygon MultiPolygonCoordinates) BBox() [4]float64 {
+	xlim, ylim := multiPolygon.bbox()
+	return [4]float64{xlim[0], ylim[0], xlim[1], ylim[1]}
+}
+
+// Contains 点是否在多面内，落在洞内的点不在多面内
+func (multiPolygon MultiPolygonCoordinates) Contains(x, y float64) bool {
+	for _, polygon := range multiPolygon {
+		if pipPolygon(polygon, x, y) {
+			return true
+		}
+	}
+	return false
+}
+
 type PolygonGeometry struct {
 	Type        string `json:"type" default:"Polygon"` // Polygon
 	Coordinates []Ring `json:"coordinates,omitempty"`  // coordinates
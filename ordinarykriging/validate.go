@@ -0,0 +1,110 @@
+package ordinarykriging
+
+import (
+	"math"
+)
+
+// CrossValidationPoint 交叉验证中单个样本的结果
+type CrossValidationPoint struct {
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Value     float64 `json:"value"`
+	Predicted float64 `json:"predicted"`
+	Error     float64 `json:"error"` // Predicted - Value
+}
+
+// CrossValidation 交叉验证报告
+type CrossValidation struct {
+	Folds               int                    `json:"folds"`
+	N                   int                    `json:"n"`
+	MeanError           float64                `json:"meanError"`
+	MeanAbsoluteError   float64                `json:"meanAbsoluteError"`
+	RootMeanSquareError float64                `json:"rootMeanSquareError"`
+	Correlation         float64                `json:"correlation"` // 实测值与预测值的皮尔逊相关系数
+	Points              []CrossValidationPoint `json:"points"`
+}
+
+// CrossValidate k-fold cross validation
+// k 折交叉验证，第 i 个样本属于第 i%folds 折；folds 小于 2 或不小于样本数时为留一法
+// 设置了 Transform 时每折使用相同类型的变换，参数由该折的训练样本确定，误差为原始单位
+func (variogram *Variogram) CrossValidate(model ModelType, sigma2 float64, alpha float64, folds int) (*CrossValidation, error) {
+	if err := variogram.checkSamples(); err != nil {
+		return nil, err
+	}
+	n := len(variogram.t)
+	if folds < 2 || folds > n {
+		folds = n
+	}
+
+	points := make([]CrossValidationPoint, n)
+	for fold := 0; fold < folds; fold++ {
+		var t, x, y, weights []float64
+		for i := 0; i < n; i++ {
+			if i%folds != fold {
+				t = append(t, variogram.t[i])
+				x = append(x, variogram.x[i])
+				y = append(y, variogram.y[i])
+				if len(variogram.Weights) > 0 {
+					weights = append(weights, variogram.Weights[i])
+				}
+			}
+		}
+
+		ordinary := NewOrdinary(t, x, y)
+		ordinary.Weights = weights
+		// 每折由训练的样本重新估计 FittedLambda 与 NormalScore
+		ordinary.Transform = variogram.Transform
+		trained, err := ordinary.Train(model, sigma2, alpha)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := fold; i < n; i += folds {
+			predicted := trained.Predict(variogram.x[i], variogram.y[i])
+			points[i] = CrossValidationPoint{
+				X:         variogram.x[i],
+				Y:         variogram.y[i],
+				Value:     variogram.t[i],
+				Predicted: predicted,
+				Error:     predicted - variogram.t[i],
+			}
+		}
+	}
+
+	return newCrossValidation(folds, points), nil
+}
+
+// newCrossValidation 汇总交叉验证误差
+func newCrossValidation(folds int, points []CrossValidationPoint) *CrossValidation {
+	report := &CrossValidation{Folds: folds, N: len(points), Points: points}
+	if len(points) == 0 {
+		return report
+	}
+
+	n := float64(len(points))
+	var meanValue, meanPredicted float64
+	for _, p := range points {
+		report.MeanError += p.Error
+		report.MeanAbsoluteError += math.Abs(p.Error)
+		report.RootMeanSquareError += pow2(p.Error)
+		meanValue += p.Value
+		meanPredicted += p.Predicted
+	}
+	report.MeanError /= n
+	report.MeanAbsoluteError /= n
+	report.RootMeanSquareError = math.Sqrt(report.RootMeanSquareError / n)
+	meanValue /= n
+	meanPredicted /= n
+
+	var cov, varValue, varPredicted float64
+	for _, p := range points {
+		cov += (p.Value - meanValue) * (p.Predicted - meanPredicted)
+		varValue += pow2(p.Value - meanValue)
+		varPredicted += pow2(p.Predicted - meanPredicted)
+	}
+	if varValue > 0 && varPredicted > 0 {
+		report.Correlation = cov / math.Sqrt(varValue*varPredicted)
+	}
+
+	return report
+}
@@ -0,0 +1,172 @@
+// Package asciigrid
+// ESRI ASCII Grid (.asc) 格式读写
+
+package asciigrid
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/raster"
+)
+
+// DefaultNodataValue 栅格没有无数据值时写入的 NODATA_value
+const DefaultNodataValue = -9999
+
+// Encode 将栅格写为 ESRI ASCII Grid
+// x、y 分辨率不一致时按 GDAL 的扩展写 dx、dy
+func Encode(w io.Writer, r *raster.Raster) error {
+	if r == nil || r.Width <= 0 || r.Height <= 0 || len(r.Data) != r.Width*r.Height {
+		return errors.New("asciigrid: invalid raster")
+	}
+
+	nodataValue := float64(DefaultNodataValue)
+	if r.HasNodata && !math.IsNaN(r.NodataValue) {
+		nodataValue = r.NodataValue
+	}
+
+	bbox := r.BBox()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "ncols %d\n", r.Width)
+	fmt.Fprintf(bw, "nrows %d\n", r.Height)
+	fmt.Fprintf(bw, "xllcorner %s\n", formatFloat(bbox[0]))
+	fmt.Fprintf(bw, "yllcorner %s\n", formatFloat(bbox[1]))
+	if r.XResolution == r.YResolution {
+		fmt.Fprintf(bw, "cellsize %s\n", formatFloat(r.XResolution))
+	} else {
+		fmt.Fprintf(bw, "dx %s\n", formatFloat(r.XResolution))
+		fmt.Fprintf(bw, "dy %s\n", formatFloat(r.YResolution))
+	}
+	fmt.Fprintf(bw, "NODATA_value %s\n", formatFloat(nodataValue))
+
+	for row := 0; row < r.Height; row++ {
+		for col := 0; col < r.Width; col++ {
+			if col > 0 {
+				bw.WriteByte(' ')
+			}
+			value := r.At(col, row)
+			if r.IsNodata(value) {
+				value = nodataValue
+			}
+			bw.WriteString(formatFloat(value))
+		}
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+// EncodeGridMatrices 将 Grid 的结果写为 ESRI ASCII Grid
+func EncodeGridMatrices(w io.Writer, gridMatrices *ordinarykriging.GridMatrices) error {
+	return Encode(w, raster.FromGridMatrices(gridMatrices))
+}
+
+// EncodeContourRectangle 将 ContourWithBBox 的结果写为 ESRI ASCII Grid
+func EncodeContourRectangle(w io.Writer, contourRectangle *ordinarykriging.ContourRectangle) error {
+	return Encode(w, raster.FromContourRectangle(contourRectangle))
+}
+
+// ReadFile 读取 ESRI ASCII Grid 文件
+func ReadFile(path string) (*raster.Raster, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Decode 读取 ESRI ASCII Grid，支持 xllcenter、yllcenter 与 GDAL 的 dx、dy
+func Decode(rd io.Reader) (*raster.Raster, error) {
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	scanner.Split(bufio.ScanWords)
+
+	header := map[string]float64{}
+	var first string
+	for scanner.Scan() {
+		key := strings.ToLower(scanner.Text())
+		if key == "" || (key[0] >= '0' && key[0] <= '9') || key[0] == '-' || key[0] == '+' || key[0] == '.' {
+			first = scanner.Text()
+			break
+		}
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("asciigrid: missing value of %s", key)
+		}
+		v, err := strconv.ParseFloat(scanner.Text(), 64)
+		if err != nil {
+			return nil, fmt.Errorf("asciigrid: invalid %s %q", key, scanner.Text())
+		}
+		header[key] = v
+	}
+
+	width, height := int(header["ncols"]), int(header["nrows"])
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("asciigrid: missing ncols or nrows")
+	}
+	xResolution, ok := header["cellsize"]
+	yResolution := xResolution
+	if !ok {
+		xResolution, yResolution = header["dx"], header["dy"]
+	}
+	if xResolution <= 0 || yResolution <= 0 {
+		return nil, errors.New("asciigrid: missing cellsize")
+	}
+	x0, err := lowerLeft(header, "x", xResolution)
+	if err != nil {
+		return nil, err
+	}
+	y0, err := lowerLeft(header, "y", yResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	r := raster.New(width, height, x0, y0+float64(height)*yResolution, xResolution, yResolution)
+	r.NodataValue, r.HasNodata = header["nodata_value"]
+
+	token := first
+	for i := range r.Data {
+		if i > 0 {
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return nil, err
+				}
+				return nil, fmt.Errorf("asciigrid: expected %d values, got %d", len(r.Data), i)
+			}
+			token = scanner.Text()
+		}
+		v, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("asciigrid: invalid value %q", token)
+		}
+		r.Data[i] = v
+	}
+	return r, nil
+}
+
+// lowerLeft 左下角的坐标，由 xllcorner 或 xllcenter 得到（y 同理）
+func lowerLeft(header map[string]float64, axis string, resolution float64) (float64, error) {
+	if corner, ok := header[axis+"llcorner"]; ok {
+		return corner, nil
+	}
+	if center, ok := header[axis+"llcenter"]; ok {
+		return center - resolution/2, nil
+	}
+	return 0, fmt.Errorf("asciigrid: missing %sllcorner or %sllcenter", axis, axis)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
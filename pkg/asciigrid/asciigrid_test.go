@@ -0,0 +1,54 @@
+package asciigrid
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lvisei/go-kriging/pkg/raster"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	r := raster.New(3, 2, 100, 30, 0.5, 0.5)
+	copy(r.Data, []float64{1, 2, 3, 4, -9999, 6.5})
+	r.HasNodata = true
+	r.NodataValue = -9999
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Width != 3 || decoded.Height != 2 || decoded.X0 != 100 || decoded.Y0 != 30 || decoded.XResolution != 0.5 {
+		t.Fatalf("unexpected raster %+v", decoded)
+	}
+	if !decoded.HasNodata || !decoded.IsNodata(decoded.At(1, 1)) || decoded.At(2, 1) != 6.5 {
+		t.Fatalf("unexpected data %v", decoded.Data)
+	}
+	if v, ok := decoded.Lookup(100.7, 29.1); ok || v != -9999 {
+		t.Fatalf("Lookup nodata %v %v", v, ok)
+	}
+	if v, ok := decoded.Lookup(100.1, 29.9); !ok || v != 1 {
+		t.Fatalf("Lookup %v %v", v, ok)
+	}
+}
+
+func TestDecode_Center(t *testing.T) {
+	input := "NCOLS 2\nNROWS 2\nXLLCENTER 0.5\nYLLCENTER 0.5\nCELLSIZE 1\n1 2\n3\n4\n"
+	r, err := Decode(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.X0 != 0 || r.Y0 != 2 || r.HasNodata || r.At(0, 1) != 3 {
+		t.Fatalf("unexpected raster %+v", r)
+	}
+	if _, err := Decode(strings.NewReader("ncols 2\nnrows 2\nxllcorner 0\nyllcorner 0\ncellsize 1\n1 2 3\n")); err == nil {
+		t.Fatal("expected error for missing values")
+	}
+	if _, err := Decode(strings.NewReader("ncols 1\nnrows 1\nxllcorner 0\ncellsize 1\n1\n")); err == nil || !strings.Contains(err.Error(), "yllcorner") {
+		t.Fatalf("expected error for missing yllcorner, got %v", err)
+	}
+}
@@ -0,0 +1,447 @@
+package contour
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/geojson"
+	"github.com/lvisei/go-kriging/pkg/raster"
+)
+
+// Isoband 一个值区间 [Lower, Upper) 的填充面，最后一个区间包含 Upper
+// 外环逆时针、内环顺时针，首尾点相同
+type Isoband struct {
+	Lower        float64
+	Upper        float64
+	MultiPolygon ordinarykriging.MultiPolygonCoordinates
+}
+
+// BandOptions 等值面选项
+type BandOptions struct {
+	// Clip 裁剪多面，一般为 GridMultiPolygon 使用的多面
+	// 为空时等值面止于有数据的像元中心，不为空时先向外延伸两个像元再裁剪，使等值面铺满多面
+	Clip ordinarykriging.MultiPolygonCoordinates
+	// Tolerance Douglas-Peucker 简化的容差，单位与坐标相同，0 为不简化
+	// 相邻区间共用的边界只简化一次，简化后的等值面仍然无缝衔接
+	Tolerance float64
+	// Smooth Chaikin 平滑的次数，每次使边界的点数加倍，0 为不平滑
+	Smooth int
+}
+
+// GridMatricesBands Grid 结果的等值面，opt.Clip 一般为生成 gridMatrices 的多面
+func GridMatricesBands(gridMatrices *ordinarykriging.GridMatrices, breaks []float64, opt *BandOptions) ([]*Isoband, error) {
+	return Bands(raster.FromGridMatrices(gridMatrices), breaks, opt)
+}
+
+// ContourRectangleBands ContourWithBBox 结果的等值面
+func ContourRectangleBands(contourRectangle *ordinarykriging.ContourRectangle, breaks []float64, opt *BandOptions) ([]*Isoband, error) {
+	return Bands(raster.FromContourRectangle(contourRectangle), breaks, opt)
+}
+
+// GridLevelColorBreaks 颜色分级的区间分界值，如 DefaultGridLevelColor
+func GridLevelColorBreaks(colors []ordinarykriging.GridLevelColor) []float64 {
+	var breaks []float64
+	for _, levelColor := range colors {
+		breaks = append(breaks, levelColor.Value[0], levelColor.Value[1])
+	}
+	sort.Float64s(breaks)
+	unique := breaks[:0]
+	for i, value := range breaks {
+		if i == 0 || value != breaks[i-1] {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// Bands 相邻两个分界值之间的等值面
+// 每个格网单元以中心点分为四个三角形，在三角形内线性插值，相邻区间的等值面边界重合
+func Bands(r *raster.Raster, breaks []float64, opt *BandOptions) ([]*Isoband, error) {
+	if r == nil || r.Width < 2 || r.Height < 2 || len(r.Data) != r.Width*r.Height {
+		return nil, errors.New("contour: raster needs at least 2x2 cells")
+	}
+	if len(breaks) < 2 {
+		return nil, errors.New("contour: at least 2 breaks are required")
+	}
+	breaks = append([]float64(nil), breaks...)
+	sort.Float64s(breaks)
+	for _, value := range breaks {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, errors.New("contour: non-finite break")
+		}
+	}
+	var o BandOptions
+	if opt != nil {
+		o = *opt
+	}
+	if len(o.Clip) > 0 {
+		r = extend(r, 2)
+	}
+
+	sets := make([]*edgeSet, len(breaks)-1)
+	for i := range sets {
+		sets[i] = newEdgeSet()
+	}
+	for row := 0; row+1 < r.Height; row++ {
+		for col := 0; col+1 < r.Width; col++ {
+			cellPieces(r, col, row, breaks, sets)
+		}
+	}
+
+	bands := make([][][]ordinarykriging.Point, len(sets))
+	for i, set := range sets {
+		bands[i] = link(set.alive())
+	}
+	if o.Tolerance > 0 || o.Smooth > 0 {
+		bands = simplifyBands(bands, math.Max(o.Tolerance, 0), o.Smooth)
+	} else {
+		for _, rings := range bands {
+			for k, ring := range rings {
+				rings[k] = removeCollinear(ring)
+			}
+		}
+	}
+
+	isobands := make([]*Isoband, 0, len(bands))
+	for i, rings := range bands {
+		if len(o.Clip) > 0 {
+			rings = clipRings(rings, o.Clip)
+		}
+		isobands = append(isobands, &Isoband{
+			Lower:        breaks[i],
+			Upper:        breaks[i+1],
+			MultiPolygon: assemble(rings),
+		})
+	}
+	return isobands, nil
+}
+
+// vertex 三角形的顶点及其值
+type vertex struct {
+	p ordinarykriging.Point
+	v float64
+}
+
+// cellPieces 将以 (col, row) 为左上角的格网单元按区间切分，各块的边加入对应区间
+func cellPieces(r *raster.Raster, col, row int, breaks []float64, sets []*edgeSet) {
+	var corners [4]vertex
+	for k, offset := range [4][2]int{{0, 0}, {1, 0}, {1, 1}, {0, 1}} {
+		v := r.At(col+offset[0], row+offset[1])
+		if r.IsNodata(v) {
+			return
+		}
+		x, y := r.CellCenter(col+offset[0], row+offset[1])
+		corners[k] = vertex{ordinarykriging.Point{x, y}, v}
+	}
+	center := vertex{
+		ordinarykriging.Point{(corners[0].p[0] + corners[1].p[0]) / 2, (corners[0].p[1] + corners[3].p[1]) / 2},
+		(corners[0].v + corners[1].v + corners[2].v + corners[3].v) / 4,
+	}
+
+	last := len(breaks) - 2
+	for k := range corners {
+		// 栅格北向朝上，左上、右上、中心为顺时针，反转为逆时针
+		triangle := []vertex{corners[(k+1)%4], corners[k], center}
+		low := math.Min(triangle[0].v, math.Min(triangle[1].v, triangle[2].v))
+		high := math.Max(triangle[0].v, math.Max(triangle[1].v, triangle[2].v))
+		first := sort.SearchFloat64s(breaks, low)
+		if first > 0 {
+			first--
+		}
+		for i := first; i <= last && breaks[i] <= high; i++ {
+			piece := clipValue(triangle, breaks[i], true, true)
+			piece = clipValue(piece, breaks[i+1], false, i == last)
+			addPiece(sets[i], piece)
+		}
+	}
+}
+
+// clipValue 保留值不小于 level（above 为 true）或小于 level 的部分，inclusive 时保留等于 level 的部分
+func clipValue(polygon []vertex, level float64, above, inclusive bool) []vertex {
+	inside := func(v float64) bool {
+		if above {
+			return v >= level
+		}
+		if inclusive {
+			return v <= level
+		}
+		return v < level
+	}
+	var result []vertex
+	for k, b := range polygon {
+		a := polygon[(k+len(polygon)-1)%len(polygon)]
+		if inside(b.v) {
+			if !inside(a.v) {
+				result = append(result, interpolate(a, b, level))
+			}
+			result = append(result, b)
+		} else if inside(a.v) {
+			result = append(result, interpolate(a, b, level))
+		}
+	}
+	return result
+}
+
+// interpolate 边上值为 level 的点，端点按坐标排序后计算，相邻三角形共用的边得到相同的点
+func interpolate(a, b vertex, level float64) vertex {
+	if b.p[0] < a.p[0] || (b.p[0] == a.p[0] && b.p[1] < a.p[1]) {
+		a, b = b, a
+	}
+	t := (level - a.v) / (b.v - a.v)
+	return vertex{ordinarykriging.Point{a.p[0] + t*(b.p[0]-a.p[0]), a.p[1] + t*(b.p[1]-a.p[1])}, level}
+}
+
+// addPiece 将一块的边加入区间，去掉重复点，退化为线或点的块被丢弃
+func addPiece(set *edgeSet, piece []vertex) {
+	points := make([]ordinarykriging.Point, 0, len(piece))
+	for _, v := range piece {
+		if len(points) == 0 || points[len(points)-1] != v.p {
+			points = append(points, v.p)
+		}
+	}
+	for len(points) > 1 && points[0] == points[len(points)-1] {
+		points = points[:len(points)-1]
+	}
+	if len(points) < 3 || signedArea(points) == 0 {
+		return
+	}
+	for k, p := range points {
+		set.add(edge{p, points[(k+1)%len(points)]})
+	}
+}
+
+// edge 有向边
+type edge struct {
+	from, to ordinarykriging.Point
+}
+
+// edgeSet 有向边的集合，加入与已有边方向相反的边时两者抵消，剩下的即为并集的边界
+type edgeSet struct {
+	edges []edge
+	dead  []bool
+	index map[edge]int
+}
+
+func newEdgeSet() *edgeSet {
+	return &edgeSet{index: map[edge]int{}}
+}
+
+func (set *edgeSet) add(e edge) {
+	reverse := edge{e.to, e.from}
+	if i, ok := set.index[reverse]; ok {
+		set.dead[i] = true
+		delete(set.index, reverse)
+		return
+	}
+	set.index[e] = len(set.edges)
+	set.edges = append(set.edges, e)
+	set.dead = append(set.dead, false)
+}
+
+// alive 未被抵消的边，按加入的顺序
+func (set *edgeSet) alive() []edge {
+	edges := make([]edge, 0, len(set.index))
+	for i, e := range set.edges {
+		if !set.dead[i] {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// link 将首尾相接的有向边连成环，环不含重复的首尾点
+// 区域总在边的左侧，多个环交于一点时取左转最大的边，使每个环都是简单环
+func link(edges []edge) [][]ordinarykriging.Point {
+	out := make(map[ordinarykriging.Point][]int, len(edges))
+	for i, e := range edges {
+		out[e.from] = append(out[e.from], i)
+	}
+	used := make([]bool, len(edges))
+
+	var rings [][]ordinarykriging.Point
+	for start := range edges {
+		if used[start] {
+			continue
+		}
+		ring := []ordinarykriging.Point{edges[start].from}
+		closed := false
+		for i := start; ; {
+			used[i] = true
+			e := edges[i]
+			if e.to == edges[start].from {
+				closed = true
+				break
+			}
+			ring = append(ring, e.to)
+			next, best := -1, math.Inf(-1)
+			for _, j := range out[e.to] {
+				if used[j] {
+					continue
+				}
+				if turn := turnAngle(e, edges[j]); turn > best {
+					next, best = j, turn
+				}
+			}
+			if next < 0 {
+				break
+			}
+			i = next
+		}
+		if closed && len(ring) >= 3 {
+			rings = append(rings, ring)
+		}
+	}
+	return rings
+}
+
+// turnAngle 由边 a 转向边 b 的角度，左转为正
+func turnAngle(a, b edge) float64 {
+	ax, ay := a.to[0]-a.from[0], a.to[1]-a.from[1]
+	bx, by := b.to[0]-b.from[0], b.to[1]-b.from[1]
+	return math.Atan2(ax*by-ay*bx, ax*bx+ay*by)
+}
+
+// removeCollinear 去掉三点共线的中间点，如整个落在区间内的格网单元留下的点
+func removeCollinear(ring []ordinarykriging.Point) []ordinarykriging.Point {
+	n := len(ring)
+	result := make([]ordinarykriging.Point, 0, n)
+	for i, p := range ring {
+		prev, next := ring[(i+n-1)%n], ring[(i+1)%n]
+		if (p[0]-prev[0])*(next[1]-p[1])-(p[1]-prev[1])*(next[0]-p[0]) != 0 {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// signedArea 环的有向面积，逆时针为正
+func signedArea(ring []ordinarykriging.Point) float64 {
+	area := 0.0
+	for i, p := range ring {
+		q := ring[(i+1)%len(ring)]
+		area += p[0]*q[1] - q[0]*p[1]
+	}
+	return area / 2
+}
+
+// assemble 逆时针的环为外环，顺时针的环为洞，洞归入包含它的最小外环并闭合各环
+func assemble(rings [][]ordinarykriging.Point) ordinarykriging.MultiPolygonCoordinates {
+	type exterior struct {
+		area    float64
+		polygon ordinarykriging.PolygonCoordinates
+	}
+	var exteriors []*exterior
+	var holes [][]ordinarykriging.Point
+	for _, ring := range rings {
+		if len(ring) < 3 {
+			continue
+		}
+		if area := signedArea(ring); area > 0 {
+			exteriors = append(exteriors, &exterior{area, ordinarykriging.PolygonCoordinates{closeRing(ring)}})
+		} else if area < 0 {
+			holes = append(holes, ring)
+		}
+	}
+	sort.SliceStable(exteriors, func(i, j int) bool { return exteriors[i].area < exteriors[j].area })
+
+	for _, hole := range holes {
+		// 洞第一条边左侧的点在区间内，也在包含洞的外环内
+		a, b := hole[0], hole[1]
+		dx, dy := b[0]-a[0], b[1]-a[1]
+		x, y := (a[0]+b[0])/2-dy*1e-6, (a[1]+b[1])/2+dx*1e-6
+		for _, e := range exteriors {
+			if (ordinarykriging.MultiPolygonCoordinates{{e.polygon[0]}}).Contains(x, y) {
+				e.polygon = append(e.polygon, closeRing(hole))
+				break
+			}
+		}
+	}
+
+	multiPolygon := make(ordinarykriging.MultiPolygonCoordinates, 0, len(exteriors))
+	for i := len(exteriors) - 1; i >= 0; i-- {
+		multiPolygon = append(multiPolygon, exteriors[i].polygon)
+	}
+	return multiPolygon
+}
+
+// closeRing 首尾点相同的环
+func closeRing(ring []ordinarykriging.Point) ordinarykriging.Ring {
+	closed := make(ordinarykriging.Ring, len(ring)+1)
+	copy(closed, ring)
+	closed[len(ring)] = ring[0]
+	return closed
+}
+
+// extend 四周各加一个像元，再将无数据像元向外延伸 n 个像元，值为相邻有效像元的均值
+func extend(r *raster.Raster, n int) *raster.Raster {
+	nodata := math.NaN()
+	e := raster.New(r.Width+2, r.Height+2, r.X0-r.XResolution, r.Y0+r.YResolution, r.XResolution, r.YResolution)
+	for i := range e.Data {
+		e.Data[i] = nodata
+	}
+	for row := 0; row < r.Height; row++ {
+		for col := 0; col < r.Width; col++ {
+			if v := r.At(col, row); !r.IsNodata(v) {
+				e.Set(col+1, row+1, v)
+			}
+		}
+	}
+
+	for ; n > 0; n-- {
+		data := append([]float64(nil), e.Data...)
+		for row := 0; row < e.Height; row++ {
+			for col := 0; col < e.Width; col++ {
+				if !math.IsNaN(e.At(col, row)) {
+					continue
+				}
+				sum, count := 0.0, 0
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						c, w := col+dx, row+dy
+						if c < 0 || w < 0 || c >= e.Width || w >= e.Height {
+							continue
+						}
+						if v := e.At(c, w); !math.IsNaN(v) {
+							sum += v
+							count++
+						}
+					}
+				}
+				if count > 0 {
+					data[row*e.Width+col] = sum / float64(count)
+				}
+			}
+		}
+		e.Data = data
+	}
+	return e
+}
+
+// BandsFeatureCollection 每个非空的等值面为一个 MultiPolygon 要素，属性 lower、upper 为值区间
+func BandsFeatureCollection(isobands []*Isoband) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, isoband := range isobands {
+		if len(isoband.MultiPolygon) == 0 {
+			continue
+		}
+		polygons := make([]interface{}, len(isoband.MultiPolygon))
+		for i, polygon := range isoband.MultiPolygon {
+			rings := make([]interface{}, len(polygon))
+			for j, ring := range polygon {
+				coordinates := make([]interface{}, len(ring))
+				for k, point := range ring {
+					coordinates[k] = []interface{}{point[0], point[1]}
+				}
+				rings[j] = coordinates
+			}
+			polygons[i] = rings
+		}
+		fc.Features = append(fc.Features, geojson.NewFeature(
+			&geojson.Geometry{Type: geojson.TypeMultiPolygon, Coordinates: polygons},
+			map[string]interface{}{"lower": isoband.Lower, "upper": isoband.Upper},
+		))
+	}
+	return fc
+}
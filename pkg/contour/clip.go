@@ -0,0 +1,228 @@
+package contour
+
+import (
+	"math"
+	"sort"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+)
+
+// cut 边上的交点，t 为交点在边上的参数
+type cut struct {
+	t float64
+	p ordinarykriging.Point
+}
+
+// clipRings 环围成的区域（外环逆时针、内环顺时针）与多面的交
+// 两组环在交点处打断，保留落在对方内部的边，再连成环
+func clipRings(rings [][]ordinarykriging.Point, clip ordinarykriging.MultiPolygonCoordinates) [][]ordinarykriging.Point {
+	clipRings := orientedRings(clip)
+	if len(rings) == 0 || len(clipRings) == 0 {
+		return nil
+	}
+
+	index := newSegmentIndex(clipRings)
+	ringCuts := make([][][]cut, len(rings))
+	for i, ring := range rings {
+		ringCuts[i] = make([][]cut, len(ring))
+	}
+	clipCuts := make([][][]cut, len(clipRings))
+	for i, ring := range clipRings {
+		clipCuts[i] = make([][]cut, len(ring))
+	}
+
+	for i, ring := range rings {
+		for k, p := range ring {
+			q := ring[(k+1)%len(ring)]
+			index.query(p, q, func(ci, ck int) {
+				a := clipRings[ci][ck]
+				b := clipRings[ci][(ck+1)%len(clipRings[ci])]
+				t, u, ok := intersect(p, q, a, b)
+				if !ok {
+					return
+				}
+				point := ordinarykriging.Point{p[0] + t*(q[0]-p[0]), p[1] + t*(q[1]-p[1])}
+				ringCuts[i][k] = append(ringCuts[i][k], cut{t, point})
+				clipCuts[ci][ck] = append(clipCuts[ci][ck], cut{u, point})
+			})
+		}
+	}
+
+	insideRings := func(x, y float64) bool {
+		c := false
+		for _, ring := range rings {
+			if pointInRing(ring, x, y) {
+				c = !c
+			}
+		}
+		return c
+	}
+
+	var edges []edge
+	for i, ring := range rings {
+		edges = append(edges, splitRing(ring, ringCuts[i], clip.Contains)...)
+	}
+	for i, ring := range clipRings {
+		edges = append(edges, splitRing(ring, clipCuts[i], insideRings)...)
+	}
+	return link(edges)
+}
+
+// orientedRings 多面的环，外环逆时针、洞顺时针，去掉重复的首尾点
+func orientedRings(multiPolygon ordinarykriging.MultiPolygonCoordinates) [][]ordinarykriging.Point {
+	var rings [][]ordinarykriging.Point
+	for _, polygon := range multiPolygon {
+		for k, ring := range polygon {
+			points := []ordinarykriging.Point(ring)
+			if len(points) > 1 && points[0] == points[len(points)-1] {
+				points = points[:len(points)-1]
+			}
+			if len(points) < 3 {
+				continue
+			}
+			points = append([]ordinarykriging.Point(nil), points...)
+			if area := signedArea(points); (k == 0) != (area > 0) {
+				for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+					points[i], points[j] = points[j], points[i]
+				}
+			}
+			rings = append(rings, points)
+		}
+	}
+	return rings
+}
+
+// splitRing 在交点处打断环，返回落在另一组环内的边
+// 每经过一个交点内外互换，只需判断第一段
+func splitRing(ring []ordinarykriging.Point, cuts [][]cut, inside func(x, y float64) bool) []edge {
+	var points []ordinarykriging.Point
+	var crossings []bool
+	for k, p := range ring {
+		points = append(points, p)
+		crossings = append(crossings, false)
+		sort.Slice(cuts[k], func(i, j int) bool { return cuts[k][i].t < cuts[k][j].t })
+		for _, c := range cuts[k] {
+			points = append(points, c.p)
+			crossings = append(crossings, true)
+		}
+	}
+
+	next := points[1%len(points)]
+	in := inside((points[0][0]+next[0])/2, (points[0][1]+next[1])/2)
+	var edges []edge
+	for k, p := range points {
+		if k > 0 && crossings[k] {
+			in = !in
+		}
+		q := points[(k+1)%len(points)]
+		if in && p != q {
+			edges = append(edges, edge{p, q})
+		}
+	}
+	return edges
+}
+
+// intersect 线段 pq 与 ab 在两者内部的交点参数
+func intersect(p, q, a, b ordinarykriging.Point) (t, u float64, ok bool) {
+	rx, ry := q[0]-p[0], q[1]-p[1]
+	sx, sy := b[0]-a[0], b[1]-a[1]
+	d := rx*sy - ry*sx
+	if d == 0 {
+		return 0, 0, false
+	}
+	wx, wy := a[0]-p[0], a[1]-p[1]
+	t = (wx*sy - wy*sx) / d
+	u = (wx*ry - wy*rx) / d
+	return t, u, t > 0 && t < 1 && u > 0 && u < 1
+}
+
+// pointInRing 射线法判断点是否在环内
+func pointInRing(ring []ordinarykriging.Point, x, y float64) bool {
+	c := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		if (ring[i][1] > y) != (ring[j][1] > y) &&
+			x < (ring[j][0]-ring[i][0])*(y-ring[i][1])/(ring[j][1]-ring[i][1])+ring[i][0] {
+			c = !c
+		}
+	}
+	return c
+}
+
+// segmentIndex 按规则格网索引环的边，加速求交
+type segmentIndex struct {
+	x0, y0, size float64
+	nx, ny       int
+	cells        [][][2]int
+}
+
+func newSegmentIndex(rings [][]ordinarykriging.Point) *segmentIndex {
+	xmin, ymin := math.Inf(1), math.Inf(1)
+	xmax, ymax := math.Inf(-1), math.Inf(-1)
+	count := 0
+	for _, ring := range rings {
+		for _, p := range ring {
+			xmin, xmax = math.Min(xmin, p[0]), math.Max(xmax, p[0])
+			ymin, ymax = math.Min(ymin, p[1]), math.Max(ymax, p[1])
+			count++
+		}
+	}
+	// 每个格子平均约一条边
+	size := math.Max(xmax-xmin, ymax-ymin) / math.Max(1, math.Sqrt(float64(count)))
+	if size <= 0 {
+		size = 1
+	}
+	index := &segmentIndex{x0: xmin, y0: ymin, size: size}
+	index.nx = int((xmax-xmin)/size) + 1
+	index.ny = int((ymax-ymin)/size) + 1
+	index.cells = make([][][2]int, index.nx*index.ny)
+	for i, ring := range rings {
+		for k, p := range ring {
+			q := ring[(k+1)%len(ring)]
+			index.each(p, q, func(cell int) {
+				index.cells[cell] = append(index.cells[cell], [2]int{i, k})
+			})
+		}
+	}
+	return index
+}
+
+// each 与线段 pq 范围相交的格子
+func (index *segmentIndex) each(p, q ordinarykriging.Point, fn func(cell int)) {
+	clamp := func(v float64, n int) int {
+		i := int(math.Floor(v / index.size))
+		if i < 0 {
+			return 0
+		}
+		if i >= n {
+			return n - 1
+		}
+		return i
+	}
+	x0 := clamp(math.Min(p[0], q[0])-index.x0, index.nx)
+	x1 := clamp(math.Max(p[0], q[0])-index.x0, index.nx)
+	y0 := clamp(math.Min(p[1], q[1])-index.y0, index.ny)
+	y1 := clamp(math.Max(p[1], q[1])-index.y0, index.ny)
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			fn(y*index.nx + x)
+		}
+	}
+}
+
+// query 可能与线段 pq 相交的边，每条边只回调一次
+func (index *segmentIndex) query(p, q ordinarykriging.Point, fn func(ring, k int)) {
+	if math.Max(p[0], q[0]) < index.x0 || math.Max(p[1], q[1]) < index.y0 ||
+		math.Min(p[0], q[0]) > index.x0+float64(index.nx)*index.size ||
+		math.Min(p[1], q[1]) > index.y0+float64(index.ny)*index.size {
+		return
+	}
+	seen := map[[2]int]bool{}
+	index.each(p, q, func(cell int) {
+		for _, segment := range index.cells[cell] {
+			if !seen[segment] {
+				seen[segment] = true
+				fn(segment[0], segment[1])
+			}
+		}
+	})
+}
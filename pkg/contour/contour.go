@@ -0,0 +1,234 @@
+// Package contour
+// 由插值栅格生成等值线（marching squares），输出 GeoJSON
+
+package contour
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/geojson"
+	"github.com/lvisei/go-kriging/pkg/raster"
+)
+
+// Isoline 一条等值线，Closed 为 true 时首尾点相同
+type Isoline struct {
+	Level  float64
+	Points []ordinarykriging.Point
+	Closed bool
+}
+
+// Breaks 将 zlim 等分为 n 个区间，返回包含两端的 n+1 个分界值
+func Breaks(zlim [2]float64, n int) []float64 {
+	if n < 1 {
+		n = 1
+	}
+	step := (zlim[1] - zlim[0]) / float64(n)
+	breaks := make([]float64, n+1)
+	for i := range breaks {
+		breaks[i] = zlim[0] + float64(i)*step
+	}
+	breaks[n] = zlim[1]
+	return breaks
+}
+
+// Levels 将 zlim 等分为 n 个区间，返回不含两端的 n-1 个等值线值
+func Levels(zlim [2]float64, n int) []float64 {
+	breaks := Breaks(zlim, n)
+	return breaks[1 : len(breaks)-1]
+}
+
+// GridMatricesLines Grid 结果的等值线，NodataValue 处断开
+func GridMatricesLines(gridMatrices *ordinarykriging.GridMatrices, levels []float64) ([]*Isoline, error) {
+	return Lines(raster.FromGridMatrices(gridMatrices), levels)
+}
+
+// ContourRectangleLines ContourWithBBox 结果的等值线
+func ContourRectangleLines(contourRectangle *ordinarykriging.ContourRectangle, levels []float64) ([]*Isoline, error) {
+	return Lines(raster.FromContourRectangle(contourRectangle), levels)
+}
+
+// Lines 以像元中心为格点用 marching squares 追踪各值的等值线
+// 四个格点中有无数据值的格网单元被跳过，等值线在此处断开
+func Lines(r *raster.Raster, levels []float64) ([]*Isoline, error) {
+	if r == nil || r.Width < 2 || r.Height < 2 || len(r.Data) != r.Width*r.Height {
+		return nil, errors.New("contour: raster needs at least 2x2 cells")
+	}
+	levels = append([]float64(nil), levels...)
+	sort.Float64s(levels)
+
+	var isolines []*Isoline
+	for _, level := range levels {
+		if math.IsNaN(level) || math.IsInf(level, 0) {
+			return nil, errors.New("contour: non-finite level")
+		}
+		isolines = append(isolines, trace(r, level)...)
+	}
+	return isolines, nil
+}
+
+// segment 格网单元内的一段等值线，两端以所在的格网边标识
+type segment struct {
+	from, to int
+}
+
+// trace 追踪一个值的全部等值线
+func trace(r *raster.Raster, level float64) []*Isoline {
+	var segments []segment
+	for row := 0; row+1 < r.Height; row++ {
+		for col := 0; col+1 < r.Width; col++ {
+			segments = append(segments, cellSegments(r, col, row, level)...)
+		}
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+
+	// 每条格网边最多被相邻的两个格网单元共用
+	ends := make(map[int][]int, 2*len(segments))
+	for i, s := range segments {
+		ends[s.from] = append(ends[s.from], i)
+		ends[s.to] = append(ends[s.to], i)
+	}
+
+	used := make([]bool, len(segments))
+	var isolines []*Isoline
+	follow := func(start int, edge int) {
+		edges := []int{edge}
+		for i := start; i >= 0; {
+			used[i] = true
+			next := segments[i].to
+			if next == edge {
+				next = segments[i].from
+			}
+			edges = append(edges, next)
+			edge = next
+			i = -1
+			for _, j := range ends[edge] {
+				if !used[j] {
+					i = j
+					break
+				}
+			}
+		}
+		isoline := &Isoline{Level: level, Points: make([]ordinarykriging.Point, len(edges))}
+		for k, e := range edges {
+			isoline.Points[k] = edgePoint(r, e, level)
+		}
+		isoline.Closed = len(edges) > 2 && edges[0] == edges[len(edges)-1]
+		isolines = append(isolines, isoline)
+	}
+
+	// 先从只属于一个线段的边开始追踪开放的线，剩下的都是闭合的环
+	for i, s := range segments {
+		if used[i] {
+			continue
+		}
+		if len(ends[s.from]) == 1 {
+			follow(i, s.from)
+		} else if len(ends[s.to]) == 1 {
+			follow(i, s.to)
+		}
+	}
+	for i, s := range segments {
+		if !used[i] {
+			follow(i, s.from)
+		}
+	}
+	return isolines
+}
+
+// 格网边的编号，(col, row) 右侧的水平边为 2*(row*width+col)，下方的竖直边为 2*(row*width+col)+1
+func horizontalEdge(r *raster.Raster, col, row int) int {
+	return 2 * (row*r.Width + col)
+}
+
+func verticalEdge(r *raster.Raster, col, row int) int {
+	return 2*(row*r.Width+col) + 1
+}
+
+// edgePoint 等值线与格网边交点的坐标，按两端的值线性插值
+func edgePoint(r *raster.Raster, edge int, level float64) ordinarykriging.Point {
+	index := edge / 2
+	col, row := index%r.Width, index/r.Width
+	col1, row1 := col+1, row
+	if edge%2 == 1 {
+		col1, row1 = col, row+1
+	}
+	a, b := r.At(col, row), r.At(col1, row1)
+	t := 0.5
+	if a != b {
+		t = (level - a) / (b - a)
+	}
+	x0, y0 := r.CellCenter(col, row)
+	x1, y1 := r.CellCenter(col1, row1)
+	return ordinarykriging.Point{x0 + t*(x1-x0), y0 + t*(y1-y0)}
+}
+
+// cellSegments 以 (col, row) 为左上角的格网单元内的线段
+func cellSegments(r *raster.Raster, col, row int, level float64) []segment {
+	tl, tr := r.At(col, row), r.At(col+1, row)
+	br, bl := r.At(col+1, row+1), r.At(col, row+1)
+	if r.IsNodata(tl) || r.IsNodata(tr) || r.IsNodata(br) || r.IsNodata(bl) {
+		return nil
+	}
+
+	index := 0
+	for _, v := range []float64{tl, tr, br, bl} {
+		index <<= 1
+		if v >= level {
+			index |= 1
+		}
+	}
+
+	top, bottom := horizontalEdge(r, col, row), horizontalEdge(r, col, row+1)
+	left, right := verticalEdge(r, col, row), verticalEdge(r, col+1, row)
+	center := (tl+tr+br+bl)/4 >= level
+
+	// 角点顺序为左上、右上、右下、左下，对应 index 的 8、4、2、1 位
+	switch index {
+	case 1, 14:
+		return []segment{{left, bottom}}
+	case 2, 13:
+		return []segment{{bottom, right}}
+	case 3, 12:
+		return []segment{{left, right}}
+	case 4, 11:
+		return []segment{{top, right}}
+	case 6, 9:
+		return []segment{{top, bottom}}
+	case 7, 8:
+		return []segment{{left, top}}
+	case 5:
+		// 右上与左下高，中心高时两个高角相连
+		if center {
+			return []segment{{left, top}, {bottom, right}}
+		}
+		return []segment{{left, bottom}, {top, right}}
+	case 10:
+		// 左上与右下高
+		if center {
+			return []segment{{top, right}, {left, bottom}}
+		}
+		return []segment{{left, top}, {bottom, right}}
+	}
+	return nil
+}
+
+// LinesFeatureCollection 每条等值线为一个 LineString 要素，属性 level 为等值线的值
+func LinesFeatureCollection(isolines []*Isoline) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, isoline := range isolines {
+		coordinates := make([]interface{}, len(isoline.Points))
+		for i, point := range isoline.Points {
+			coordinates[i] = []interface{}{point[0], point[1]}
+		}
+		fc.Features = append(fc.Features, geojson.NewFeature(
+			&geojson.Geometry{Type: geojson.TypeLineString, Coordinates: coordinates},
+			map[string]interface{}{"level": isoline.Level},
+		))
+	}
+	return fc
+}
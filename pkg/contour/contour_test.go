@@ -0,0 +1,275 @@
+package contour
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/raster"
+)
+
+// cone 以 (0, 0) 为中心、值为到中心距离的栅格
+func cone(size int) *raster.Raster {
+	r := raster.New(size, size, -float64(size)/2, float64(size)/2, 1, 1)
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			x, y := r.CellCenter(col, row)
+			r.Set(col, row, math.Hypot(x, y))
+		}
+	}
+	return r
+}
+
+func TestLines(t *testing.T) {
+	r := cone(20)
+	isolines, err := Lines(r, []float64{5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(isolines) != 1 || !isolines[0].Closed {
+		t.Fatalf("expected one closed isoline, got %d", len(isolines))
+	}
+	for _, point := range isolines[0].Points {
+		if d := math.Hypot(point[0], point[1]); math.Abs(d-5) > 0.1 {
+			t.Fatalf("point %v is %v away from the center", point, d)
+		}
+	}
+
+	// 无数据值使等值线断开
+	r.HasNodata = true
+	r.NodataValue = -9999
+	r.Set(15, 10, -9999)
+	isolines, err = Lines(r, Levels([2]float64{0, 10}, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(isolines) != 1 || isolines[0].Closed || isolines[0].Level != 5 {
+		t.Fatalf("expected one open isoline, got %+v", isolines)
+	}
+
+	fc := LinesFeatureCollection(isolines)
+	if len(fc.Features) != 1 || fc.Features[0].Properties["level"] != 5.0 {
+		t.Fatalf("unexpected features %+v", fc.Features)
+	}
+}
+
+func TestBreaks(t *testing.T) {
+	breaks := Breaks([2]float64{0, 1}, 4)
+	if len(breaks) != 5 || breaks[1] != 0.25 || breaks[4] != 1 {
+		t.Fatalf("unexpected breaks %v", breaks)
+	}
+	if levels := Levels([2]float64{0, 1}, 4); len(levels) != 3 || levels[0] != 0.25 {
+		t.Fatalf("unexpected levels %v", levels)
+	}
+}
+
+// area 多面的面积，洞的面积为负
+func area(multiPolygon ordinarykriging.MultiPolygonCoordinates) float64 {
+	sum := 0.0
+	for _, polygon := range multiPolygon {
+		for _, ring := range polygon {
+			sum += signedArea(ring[:len(ring)-1])
+		}
+	}
+	return sum
+}
+
+func TestBands(t *testing.T) {
+	r := cone(20)
+	isobands, err := Bands(r, []float64{0, 3, 6, 100}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(isobands) != 3 {
+		t.Fatalf("expected 3 bands, got %d", len(isobands))
+	}
+	// 圆盘与圆环，圆环有一个洞
+	if got := area(isobands[0].MultiPolygon); math.Abs(got-math.Pi*9) > 1 {
+		t.Fatalf("disk area %v", got)
+	}
+	if len(isobands[1].MultiPolygon) != 1 || len(isobands[1].MultiPolygon[0]) != 2 {
+		t.Fatalf("expected an annulus, got %d polygons", len(isobands[1].MultiPolygon))
+	}
+	if got := area(isobands[1].MultiPolygon); math.Abs(got-math.Pi*27) > 1 {
+		t.Fatalf("annulus area %v", got)
+	}
+	// 各区间铺满像元中心围成的范围
+	total := 0.0
+	for _, isoband := range isobands {
+		total += area(isoband.MultiPolygon)
+	}
+	if math.Abs(total-19*19) > 1e-9 {
+		t.Fatalf("total area %v", total)
+	}
+
+	// 裁剪到有洞的正方形
+	square := ordinarykriging.MultiPolygonCoordinates{{
+		{{-4, -4}, {4, -4}, {4, 4}, {-4, 4}, {-4, -4}},
+		{{-1, -1}, {-1, 1}, {1, 1}, {1, -1}, {-1, -1}},
+	}}
+	isobands, err = Bands(r, []float64{0, 3, 100}, &BandOptions{Clip: square})
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk, rest := area(isobands[0].MultiPolygon), area(isobands[1].MultiPolygon)
+	if math.Abs(disk-(math.Pi*9-4)) > 1 || math.Abs(disk+rest-60) > 1e-9 {
+		t.Fatalf("clipped areas %v, %v", disk, rest)
+	}
+
+	// 简化减少点数，平滑后面积略小
+	isobands, err = Bands(r, []float64{0, 6}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := len(isobands[0].MultiPolygon[0][0])
+	isobands, err = Bands(r, []float64{0, 6}, &BandOptions{Tolerance: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring := isobands[0].MultiPolygon[0][0]
+	if len(ring) >= full || ring[0] != ring[len(ring)-1] {
+		t.Fatalf("simplified ring has %d of %d points", len(ring), full)
+	}
+	isobands, err = Bands(r, []float64{0, 6}, &BandOptions{Tolerance: 0.1, Smooth: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := area(isobands[0].MultiPolygon); got > math.Pi*36 || got < math.Pi*36*0.95 {
+		t.Fatalf("smoothed area %v", got)
+	}
+
+	fc := BandsFeatureCollection(isobands)
+	if len(fc.Features) != 1 || fc.Features[0].Properties["upper"] != 6.0 {
+		t.Fatalf("unexpected features %+v", fc.Features)
+	}
+}
+
+func TestBands_Valid(t *testing.T) {
+	// 多个峰谷使区间有多个面与洞，裁剪多面也有洞；容差 3 时不检查拓扑的简化会使边界相交
+	r := raster.New(40, 30, 0, 30, 1, 1)
+	for row := 0; row < r.Height; row++ {
+		for col := 0; col < r.Width; col++ {
+			x, y := r.CellCenter(col, row)
+			r.Set(col, row, math.Sin(x/3)*math.Cos(y/4)+0.02*x)
+		}
+	}
+	clip := ordinarykriging.MultiPolygonCoordinates{{
+		{{2, 2}, {38, 3}, {36, 28}, {4, 27}, {2, 2}},
+		{{15, 12}, {15, 18}, {22, 18}, {22, 12}, {15, 12}},
+	}}
+	for _, opt := range []*BandOptions{
+		nil,
+		{Clip: clip},
+		{Tolerance: 0.5},
+		{Tolerance: 3, Smooth: 2},
+		{Tolerance: 3, Smooth: 1, Clip: clip},
+	} {
+		isobands, err := Bands(r, Breaks(r.Zlim(), 8), opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opt == nil || opt.Clip == nil {
+			checkWatertight(t, isobands)
+		}
+		for _, isoband := range isobands {
+			for _, polygon := range isoband.MultiPolygon {
+				for k, ring := range polygon {
+					if len(ring) < 4 || ring[0] != ring[len(ring)-1] {
+						t.Fatalf("band %v: ring %d not closed", isoband.Lower, k)
+					}
+					if selfIntersects(ring) {
+						t.Fatalf("band %v: ring %d self-intersects", isoband.Lower, k)
+					}
+				}
+				exterior := ordinarykriging.MultiPolygonCoordinates{{polygon[0]}}
+				for k, hole := range polygon[1:] {
+					if ringsCross(polygon[0], hole) {
+						t.Fatalf("band %v: hole %d crosses its exterior", isoband.Lower, k)
+					}
+					if p := interiorPoint(hole); !exterior.Contains(p[0], p[1]) {
+						t.Fatalf("band %v: hole %d outside its exterior", isoband.Lower, k)
+					}
+				}
+			}
+		}
+	}
+}
+
+// checkWatertight 各区间的边两两不相交，相邻区间共用的边方向相反，只属于一个区间的边围成唯一的外边界
+func checkWatertight(t *testing.T, isobands []*Isoband) {
+	t.Helper()
+	uses := map[edge]int{}
+	var all []edge
+	for _, isoband := range isobands {
+		for _, polygon := range isoband.MultiPolygon {
+			for _, ring := range polygon {
+				for k := 0; k+1 < len(ring); k++ {
+					e := edge{ring[k], ring[k+1]}
+					if uses[e] > 0 {
+						t.Fatalf("edge %v used twice in the same direction", e)
+					}
+					uses[e]++
+					all = append(all, e)
+				}
+			}
+		}
+	}
+	for i, a := range all {
+		for _, b := range all[i+1:] {
+			if segmentsCross(a.from, a.to, b.from, b.to) {
+				t.Fatalf("edges %v and %v cross", a, b)
+			}
+		}
+	}
+	var boundary []edge
+	for _, e := range all {
+		if uses[edge{e.to, e.from}] == 0 {
+			boundary = append(boundary, e)
+		}
+	}
+	if rings := link(boundary); len(rings) != 1 || signedArea(rings[0]) <= 0 {
+		t.Fatalf("expected one outer boundary, got %d rings", len(rings))
+	}
+}
+
+// segmentsCross 两条线段是否在端点以外相交
+func segmentsCross(a, b, c, d ordinarykriging.Point) bool {
+	cross := func(o, p, q ordinarykriging.Point) float64 {
+		return (p[0]-o[0])*(q[1]-o[1]) - (p[1]-o[1])*(q[0]-o[0])
+	}
+	d1, d2 := cross(c, d, a), cross(c, d, b)
+	d3, d4 := cross(a, b, c), cross(a, b, d)
+	return d1*d2 < 0 && d3*d4 < 0
+}
+
+// selfIntersects 环是否有不相邻的边相交
+func selfIntersects(ring ordinarykriging.Ring) bool {
+	n := len(ring) - 1
+	for i := 0; i < n; i++ {
+		for j := i + 2; j < n; j++ {
+			if segmentsCross(ring[i], ring[i+1], ring[j], ring[j+1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ringsCross 两个环是否有边相交
+func ringsCross(a, b ordinarykriging.Ring) bool {
+	for i := 0; i+1 < len(a); i++ {
+		for j := 0; j+1 < len(b); j++ {
+			if segmentsCross(a[i], a[i+1], b[j], b[j+1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// interiorPoint 顺时针的洞第一条边右侧紧邻的点，在洞内
+func interiorPoint(hole ordinarykriging.Ring) ordinarykriging.Point {
+	a, b := hole[0], hole[1]
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	return ordinarykriging.Point{(a[0]+b[0])/2 + dy*1e-6, (a[1]+b[1])/2 - dx*1e-6}
+}
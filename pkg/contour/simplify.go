@@ -0,0 +1,382 @@
+package contour
+
+import (
+	"math"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+)
+
+// simplifyBands 简化并平滑各区间的环，环不含重复的首尾点
+// 相邻区间的边界重合，先将所有环在三条以上边界的交点处打断为弧段，每条弧段只简化、平滑一次，
+// 再由弧段重建各环，使相邻区间仍然无缝衔接；
+// 改变弧段前检查新的线段不与其他线段相交，新旧弧段之间也不包含其他顶点，使环不自交、洞不越出外环
+func simplifyBands(bands [][][]ordinarykriging.Point, tolerance float64, iterations int) [][][]ordinarykriging.Point {
+	nodes := junctions(bands)
+	t := newTopology(bands)
+	arcs := map[edge]*arc{}
+	var order []*arc
+	for _, rings := range bands {
+		for _, ring := range rings {
+			for _, points := range splitArcs(ring, nodes) {
+				if _, ok := arcs[edge{points[0], points[1]}]; ok {
+					continue
+				}
+				a := t.addArc(points)
+				arcs[edge{points[0], points[1]}] = a
+				arcs[edge{points[len(points)-1], points[len(points)-2]}] = a
+				order = append(order, a)
+			}
+		}
+	}
+	for _, a := range order {
+		t.simplify(a, tolerance)
+	}
+	for _, a := range order {
+		t.smooth(a, iterations)
+	}
+
+	result := make([][][]ordinarykriging.Point, len(bands))
+	for i, rings := range bands {
+		for _, ring := range rings {
+			var rebuilt []ordinarykriging.Point
+			for _, points := range splitArcs(ring, nodes) {
+				a := arcs[edge{points[0], points[1]}]
+				current := a.current()
+				if current[0] != points[0] || a.points[1] != points[1] {
+					current = reversed(current)
+				}
+				rebuilt = append(rebuilt, current[:len(current)-1]...)
+			}
+			if len(rebuilt) >= 3 {
+				result[i] = append(result[i], rebuilt)
+			}
+		}
+	}
+	return result
+}
+
+// junctions 与两个以外的点相邻的点，即弧段的端点
+func junctions(bands [][][]ordinarykriging.Point) map[ordinarykriging.Point]bool {
+	neighbors := map[ordinarykriging.Point]map[ordinarykriging.Point]bool{}
+	for _, rings := range bands {
+		for _, ring := range rings {
+			for k, p := range ring {
+				if neighbors[p] == nil {
+					neighbors[p] = map[ordinarykriging.Point]bool{}
+				}
+				neighbors[p][ring[(k+1)%len(ring)]] = true
+				neighbors[p][ring[(k+len(ring)-1)%len(ring)]] = true
+			}
+		}
+	}
+	nodes := map[ordinarykriging.Point]bool{}
+	for p, adjacent := range neighbors {
+		if len(adjacent) != 2 {
+			nodes[p] = true
+		}
+	}
+	return nodes
+}
+
+// splitArcs 在端点处将环打断为首尾为端点的弧段，没有端点的环以坐标最小的点为首尾
+func splitArcs(ring []ordinarykriging.Point, nodes map[ordinarykriging.Point]bool) [][]ordinarykriging.Point {
+	n := len(ring)
+	start := -1
+	for k, p := range ring {
+		if nodes[p] {
+			start = k
+			break
+		}
+	}
+	if start < 0 {
+		start = 0
+		for k, p := range ring {
+			if p[0] < ring[start][0] || (p[0] == ring[start][0] && p[1] < ring[start][1]) {
+				start = k
+			}
+		}
+	}
+
+	var arcs [][]ordinarykriging.Point
+	points := []ordinarykriging.Point{ring[start]}
+	for k := 1; k <= n; k++ {
+		p := ring[(start+k)%n]
+		points = append(points, p)
+		if nodes[p] || k == n {
+			arcs = append(arcs, points)
+			points = []ordinarykriging.Point{p}
+		}
+	}
+	return arcs
+}
+
+func reversed(points []ordinarykriging.Point) []ordinarykriging.Point {
+	result := make([]ordinarykriging.Point, len(points))
+	for i, p := range points {
+		result[len(points)-1-i] = p
+	}
+	return result
+}
+
+// arc 两个端点之间的弧段
+type arc struct {
+	points   []ordinarykriging.Point // 原始的点
+	keep     []bool                  // 简化后保留的点
+	smoothed []ordinarykriging.Point // 平滑后的点，为 nil 时未平滑
+	segments []int                   // 当前各线段在 topology 中的序号
+}
+
+// current 简化、平滑后的点
+func (a *arc) current() []ordinarykriging.Point {
+	if a.smoothed != nil {
+		return a.smoothed
+	}
+	var points []ordinarykriging.Point
+	for i, p := range a.points {
+		if a.keep[i] {
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+// topologySegment 线段，dead 为被替换的线段
+type topologySegment struct {
+	a, b ordinarykriging.Point
+	dead bool
+}
+
+// topology 全部弧段的线段及其格网索引，用于检查替换弧段是否改变拓扑
+type topology struct {
+	segments     []topologySegment
+	x0, y0, size float64
+	nx, ny       int
+	cells        [][]int
+}
+
+func newTopology(bands [][][]ordinarykriging.Point) *topology {
+	xmin, ymin := math.Inf(1), math.Inf(1)
+	xmax, ymax := math.Inf(-1), math.Inf(-1)
+	count := 0
+	for _, rings := range bands {
+		for _, ring := range rings {
+			for _, p := range ring {
+				xmin, xmax = math.Min(xmin, p[0]), math.Max(xmax, p[0])
+				ymin, ymax = math.Min(ymin, p[1]), math.Max(ymax, p[1])
+				count++
+			}
+		}
+	}
+	// 每个格子平均约一条线段
+	size := math.Max(xmax-xmin, ymax-ymin) / math.Max(1, math.Sqrt(float64(count)))
+	if !(size > 0) {
+		size = 1
+	}
+	t := &topology{x0: xmin, y0: ymin, size: size}
+	t.nx = int((xmax-xmin)/size) + 1
+	t.ny = int((ymax-ymin)/size) + 1
+	t.cells = make([][]int, t.nx*t.ny)
+	return t
+}
+
+// cellRange 与矩形范围相交的格子
+func (t *topology) cellRange(xmin, ymin, xmax, ymax float64) (x0, y0, x1, y1 int) {
+	clamp := func(v float64, n int) int {
+		i := int(math.Floor(v / t.size))
+		if i < 0 {
+			return 0
+		}
+		if i >= n {
+			return n - 1
+		}
+		return i
+	}
+	return clamp(xmin-t.x0, t.nx), clamp(ymin-t.y0, t.ny), clamp(xmax-t.x0, t.nx), clamp(ymax-t.y0, t.ny)
+}
+
+// insert 加入线段，返回序号
+func (t *topology) insert(a, b ordinarykriging.Point) int {
+	id := len(t.segments)
+	t.segments = append(t.segments, topologySegment{a: a, b: b})
+	x0, y0, x1, y1 := t.cellRange(math.Min(a[0], b[0]), math.Min(a[1], b[1]), math.Max(a[0], b[0]), math.Max(a[1], b[1]))
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			t.cells[y*t.nx+x] = append(t.cells[y*t.nx+x], id)
+		}
+	}
+	return id
+}
+
+// each 范围内未被替换的线段，同一线段可能回调多次
+func (t *topology) each(xmin, ymin, xmax, ymax float64, fn func(id int) bool) bool {
+	x0, y0, x1, y1 := t.cellRange(xmin, ymin, xmax, ymax)
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			for _, id := range t.cells[y*t.nx+x] {
+				if !t.segments[id].dead && !fn(id) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func (t *topology) addArc(points []ordinarykriging.Point) *arc {
+	a := &arc{points: points, keep: make([]bool, len(points))}
+	for i := range points {
+		a.keep[i] = true
+		if i > 0 {
+			a.segments = append(a.segments, t.insert(points[i-1], points[i]))
+		}
+	}
+	return a
+}
+
+// replace 将线段 old 替换为折线 points，old 为首尾相同的折线 chain 的线段
+// 新线段与其他线段相交、与已有线段重合，或新旧折线之间有其他顶点时撤销替换并返回 false
+func (t *topology) replace(old []int, chain, points []ordinarykriging.Point) ([]int, bool) {
+	for _, id := range old {
+		t.segments[id].dead = true
+	}
+	var added []int
+	undo := func() ([]int, bool) {
+		for _, id := range added {
+			t.segments[id].dead = true
+		}
+		for _, id := range old {
+			t.segments[id].dead = false
+		}
+		return nil, false
+	}
+
+	for i := 1; i < len(points); i++ {
+		p, q := points[i-1], points[i]
+		valid := t.each(math.Min(p[0], q[0]), math.Min(p[1], q[1]), math.Max(p[0], q[0]), math.Max(p[1], q[1]), func(id int) bool {
+			s := t.segments[id]
+			if (s.a == p && s.b == q) || (s.a == q && s.b == p) {
+				return false
+			}
+			_, _, crosses := intersect(p, q, s.a, s.b)
+			return !crosses
+		})
+		if !valid {
+			return undo()
+		}
+		added = append(added, t.insert(p, q))
+	}
+
+	// 新旧折线围成的区域内不能有其他顶点
+	region := append(append([]ordinarykriging.Point(nil), chain...), reversed(points)...)
+	xmin, ymin := math.Inf(1), math.Inf(1)
+	xmax, ymax := math.Inf(-1), math.Inf(-1)
+	for _, p := range region {
+		xmin, xmax = math.Min(xmin, p[0]), math.Max(xmax, p[0])
+		ymin, ymax = math.Min(ymin, p[1]), math.Max(ymax, p[1])
+	}
+	own := map[int]bool{}
+	for _, id := range added {
+		own[id] = true
+	}
+	first, last := chain[0], chain[len(chain)-1]
+	valid := t.each(xmin, ymin, xmax, ymax, func(id int) bool {
+		if own[id] {
+			return true
+		}
+		for _, p := range [2]ordinarykriging.Point{t.segments[id].a, t.segments[id].b} {
+			if p != first && p != last && pointInRing(region, p[0], p[1]) {
+				return false
+			}
+		}
+		return true
+	})
+	if !valid {
+		return undo()
+	}
+	return added, true
+}
+
+// simplify Douglas-Peucker 简化弧段，首尾点不变，改变拓扑的简化改为多保留一个点
+func (t *topology) simplify(a *arc, tolerance float64) {
+	n := len(a.points)
+	for i := 1; i < n-1; i++ {
+		a.keep[i] = false
+	}
+	// segments[i] 为 points[i] 到 points[i+1] 的线段，简化后 segments[i] 为由 points[i] 出发的线段
+	segments := a.segments
+	a.segments = nil
+	starts := map[int]int{}
+	stack := [][2]int{{0, n - 1}}
+	for len(stack) > 0 {
+		span := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		i, j := span[0], span[1]
+		if j-i < 2 {
+			starts[i] = segments[i]
+			continue
+		}
+		farthest, distance := farthestPoint(a.points, i, j)
+		if distance <= tolerance && a.points[i] != a.points[j] {
+			added, ok := t.replace(segments[i:j], a.points[i:j+1], []ordinarykriging.Point{a.points[i], a.points[j]})
+			if ok {
+				starts[i] = added[0]
+				continue
+			}
+		}
+		a.keep[farthest] = true
+		stack = append(stack, [2]int{farthest, j}, [2]int{i, farthest})
+	}
+	for i := 0; i < n-1; i++ {
+		if a.keep[i] {
+			a.segments = append(a.segments, starts[i])
+		}
+	}
+}
+
+// farthestPoint i、j 之间离线段 points[i]points[j] 最远的点，距离都为 0 时为中间的点
+func farthestPoint(points []ordinarykriging.Point, i, j int) (int, float64) {
+	farthest, distance := (i+j)/2, 0.0
+	for k := i + 1; k < j; k++ {
+		if d := segmentDistance(points[k], points[i], points[j]); d > distance {
+			farthest, distance = k, d
+		}
+	}
+	return farthest, distance
+}
+
+// segmentDistance 点到线段 ab 的距离
+func segmentDistance(p, a, b ordinarykriging.Point) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((p[0]-a[0])*dx+(p[1]-a[1])*dy)/l))
+	}
+	return math.Hypot(p[0]-a[0]-t*dx, p[1]-a[1]-t*dy)
+}
+
+// smooth 对弧段做 iterations 次 Chaikin 切角，首尾点不变，改变拓扑时保持不平滑
+func (t *topology) smooth(a *arc, iterations int) {
+	points := a.current()
+	if iterations <= 0 || len(points) < 3 {
+		return
+	}
+	smoothed := points
+	for ; iterations > 0; iterations-- {
+		n := len(smoothed) - 1
+		next := make([]ordinarykriging.Point, 0, 2*n)
+		next = append(next, smoothed[0])
+		for i := 0; i < n; i++ {
+			p, q := smoothed[i], smoothed[i+1]
+			if i > 0 {
+				next = append(next, ordinarykriging.Point{0.75*p[0] + 0.25*q[0], 0.75*p[1] + 0.25*q[1]})
+			}
+			if i < n-1 {
+				next = append(next, ordinarykriging.Point{0.25*p[0] + 0.75*q[0], 0.25*p[1] + 0.75*q[1]})
+			}
+		}
+		smoothed = append(next, smoothed[n])
+	}
+	if added, ok := t.replace(a.segments, points, smoothed); ok {
+		a.smoothed, a.segments = smoothed, added
+	}
+}
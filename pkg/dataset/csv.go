@@ -0,0 +1,293 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// HeaderMode 表头模式
+type HeaderMode int
+
+const (
+	HeaderAuto HeaderMode = iota // 第一行的坐标列不是数字时作为表头
+	HeaderYes
+	HeaderNo
+)
+
+// DefaultMissingValues 默认的缺失值标记
+var DefaultMissingValues = []string{"", "NA", "N/A", "NaN", "null"}
+
+// CSVOptions CSV/TSV 读取参数
+// 列可以是表头中的列名（不区分大小写），也可以是从 0 开始的列序号
+type CSVOptions struct {
+	X             string     // x 列，默认 x
+	Y             string     // y 列，默认 y
+	Value         string     // 值列，默认 value；为 "-" 时不读取值，只读取坐标
+	Covariates    []string   // 协变量列
+	Delimiter     rune       // 分隔符，为 0 时根据第一行自动识别
+	Comment       rune       // 注释行起始字符，为 0 时不支持注释
+	Header        HeaderMode // 表头模式
+	MissingValues []string   // 缺失值标记，为 nil 时使用 DefaultMissingValues
+	SkipInvalid   bool       // 跳过无法解析的行并记录在 Samples.Skipped 中，否则返回 RowErrors
+}
+
+// NoValue 只读取坐标时的值列
+const NoValue = "-"
+
+func (opt *CSVOptions) withDefaults() CSVOptions {
+	o := CSVOptions{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.X == "" {
+		o.X = "x"
+	}
+	if o.Y == "" {
+		o.Y = "y"
+	}
+	if o.Value == "" {
+		o.Value = "value"
+	}
+	if o.MissingValues == nil {
+		o.MissingValues = DefaultMissingValues
+	}
+	return o
+}
+
+// ReadCSVFile 读取 CSV/TSV 文件，未指定分隔符时 .tsv 文件使用制表符
+func ReadCSVFile(path string, opt *CSVOptions) (*Samples, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	o := opt.withDefaults()
+	if o.Delimiter == 0 && strings.EqualFold(filepath.Ext(path), ".tsv") {
+		o.Delimiter = '\t'
+	}
+	samples, err := ReadCSV(f, &o)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return samples, nil
+}
+
+// ReadCSV 读取 CSV/TSV
+func ReadCSV(r io.Reader, opt *CSVOptions) (*Samples, error) {
+	o := opt.withDefaults()
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	if o.Delimiter == 0 {
+		o.Delimiter = sniffDelimiter(content)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma = o.Delimiter
+	reader.Comment = o.Comment
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	// 逐条读取并记录每条记录起始的行号，注释行与含换行的引号字段不影响行号
+	var records [][]string
+	var lines []int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseError *csv.ParseError
+			if errors.As(err, &parseError) {
+				return nil, &RowError{Row: parseError.StartLine, Err: parseError.Err}
+			}
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty csv")
+	}
+
+	var header []string
+	if hasHeader(records[0], o) {
+		header = records[0]
+		records, lines = records[1:], lines[1:]
+	}
+
+	xIndex, err := columnIndex(header, o.X)
+	if err != nil {
+		return nil, err
+	}
+	yIndex, err := columnIndex(header, o.Y)
+	if err != nil {
+		return nil, err
+	}
+	valueIndex := -1
+	if o.Value != NoValue {
+		if valueIndex, err = columnIndex(header, o.Value); err != nil {
+			return nil, err
+		}
+	}
+	covariateIndexes := make([]int, len(o.Covariates))
+	for i, column := range o.Covariates {
+		if covariateIndexes[i], err = columnIndex(header, column); err != nil {
+			return nil, err
+		}
+	}
+
+	samples := &Samples{}
+	if len(o.Covariates) > 0 {
+		samples.Covariates = map[string][]float64{}
+	}
+	var invalid RowErrors
+
+	for i, record := range records {
+		row := lines[i]
+		x, xErr := parseField(record, xIndex, o)
+		y, yErr := parseField(record, yIndex, o)
+		value, valueErr := 0.0, error(nil)
+		if valueIndex >= 0 {
+			value, valueErr = parseField(record, valueIndex, o)
+		}
+
+		var rowError *RowError
+		for _, field := range []struct {
+			column string
+			index  int
+			err    error
+		}{{o.X, xIndex, xErr}, {o.Y, yIndex, yErr}, {o.Value, valueIndex, valueErr}} {
+			if field.err != nil {
+				rowError = &RowError{Row: row, Column: field.column, Value: fieldAt(record, field.index), Err: field.err}
+				break
+			}
+		}
+		// 缺失的协变量为 NaN，无法解析的协变量与坐标、值一样按无效行处理
+		covariates := make([]float64, len(o.Covariates))
+		for j, column := range o.Covariates {
+			if rowError != nil {
+				break
+			}
+			covariate, err := parseField(record, covariateIndexes[j], o)
+			if errors.Is(err, ErrMissingValue) {
+				covariate = math.NaN()
+			} else if err != nil {
+				rowError = &RowError{Row: row, Column: column, Value: fieldAt(record, covariateIndexes[j]), Err: err}
+			}
+			covariates[j] = covariate
+		}
+		if rowError != nil {
+			if errors.Is(rowError.Err, ErrMissingValue) || o.SkipInvalid {
+				samples.Skipped = append(samples.Skipped, rowError)
+			} else {
+				invalid = append(invalid, rowError)
+			}
+			continue
+		}
+
+		samples.X = append(samples.X, x)
+		samples.Y = append(samples.Y, y)
+		if valueIndex >= 0 {
+			samples.Values = append(samples.Values, value)
+		}
+		for j, column := range o.Covariates {
+			samples.Covariates[column] = append(samples.Covariates[column], covariates[j])
+		}
+	}
+
+	if len(invalid) > 0 {
+		return nil, invalid
+	}
+	return samples, nil
+}
+
+// sniffDelimiter 根据第一行识别分隔符
+func sniffDelimiter(content []byte) rune {
+	line := content
+	if i := bytes.IndexByte(content, '\n'); i >= 0 {
+		line = content[:i]
+	}
+	delimiter, count := ',', 0
+	for _, candidate := range []rune{',', '\t', ';', '|'} {
+		if n := bytes.Count(line, []byte(string(candidate))); n > count {
+			delimiter, count = candidate, n
+		}
+	}
+	return delimiter
+}
+
+// hasHeader 第一行是否为表头
+func hasHeader(record []string, o CSVOptions) bool {
+	switch o.Header {
+	case HeaderYes:
+		return true
+	case HeaderNo:
+		return false
+	}
+	for _, column := range []string{o.X, o.Y} {
+		index, err := strconv.Atoi(column)
+		if err != nil {
+			// 按列名取列时必须有表头
+			return true
+		}
+		if _, err := strconv.ParseFloat(strings.TrimSpace(fieldAt(record, index)), 64); err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// columnIndex 按列名（不区分大小写）或序号查找列
+func columnIndex(header []string, column string) (int, error) {
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i, nil
+		}
+	}
+	if index, err := strconv.Atoi(column); err == nil && index >= 0 && (header == nil || index < len(header)) {
+		return index, nil
+	}
+	return 0, fmt.Errorf("column %q not found in %v", column, header)
+}
+
+func fieldAt(record []string, index int) string {
+	if index < 0 || index >= len(record) {
+		return ""
+	}
+	return record[index]
+}
+
+// parseField 解析数值，缺失值返回 ErrMissingValue
+func parseField(record []string, index int, o CSVOptions) (float64, error) {
+	if index >= len(record) {
+		return 0, ErrMissingValue
+	}
+	field := strings.TrimSpace(record[index])
+	for _, marker := range o.MissingValues {
+		if strings.EqualFold(field, marker) {
+			return 0, ErrMissingValue
+		}
+	}
+	value, err := strconv.ParseFloat(field, 64)
+	if err != nil {
+		return 0, errors.New("invalid number")
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, errors.New("non-finite number")
+	}
+	return value, nil
+}
@@ -0,0 +1,85 @@
+package dataset
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV_Header(t *testing.T) {
+	input := "\xef\xbb\xbfStation_Name,Lon,Lat,Alti,TEM_Avg\n" +
+		"a,102.68,25.95,2304,9.6\n" +
+		"b,102.88,26.04,2334,NA\n" +
+		"c,102.9,26.1,,5.3\n"
+	samples, err := ReadCSV(strings.NewReader(input), &CSVOptions{
+		X: "lon", Y: "lat", Value: "TEM_Avg", Covariates: []string{"Alti"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples.Len() != 2 || samples.X[1] != 102.9 || samples.Values[1] != 5.3 {
+		t.Fatalf("unexpected samples %+v", samples)
+	}
+	if alti := samples.Covariates["Alti"]; alti[0] != 2304 || !math.IsNaN(alti[1]) {
+		t.Fatalf("unexpected covariate %v", alti)
+	}
+	if len(samples.Skipped) != 1 || samples.Skipped[0].Row != 3 || !errors.Is(samples.Skipped[0], ErrMissingValue) {
+		t.Fatalf("unexpected skipped %v", samples.Skipped)
+	}
+}
+
+func TestReadCSV_NoHeader(t *testing.T) {
+	input := "1\t2\t3\n4\t5\t-9999\n7\t8\t9\n"
+	samples, err := ReadCSV(strings.NewReader(input), &CSVOptions{
+		X: "0", Y: "1", Value: "2", MissingValues: []string{"-9999"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples.Len() != 2 || samples.Values[1] != 9 || len(samples.Skipped) != 1 {
+		t.Fatalf("unexpected samples %+v", samples)
+	}
+}
+
+func TestReadCSV_Invalid(t *testing.T) {
+	input := "x;y;value\n1;2;3\n4;five;6\n7;8;x\n"
+	_, err := ReadCSV(strings.NewReader(input), nil)
+	var rowErrors RowErrors
+	if !errors.As(err, &rowErrors) || len(rowErrors) != 2 || rowErrors[0].Row != 3 || rowErrors[1].Column != "value" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	samples, err := ReadCSV(strings.NewReader(input), &CSVOptions{SkipInvalid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples.Len() != 1 || len(samples.Skipped) != 2 {
+		t.Fatalf("unexpected samples %+v", samples)
+	}
+}
+
+func TestReadCSV_RowNumbers(t *testing.T) {
+	input := "# comment\nname,x,y,value,alti\n\"a\nb\",1,2,3,4\nc,4,5,,7\nd,7,8,9,high\n"
+	options := &CSVOptions{Comment: '#', Covariates: []string{"alti"}}
+	_, err := ReadCSV(strings.NewReader(input), options)
+	var rowErrors RowErrors
+	if !errors.As(err, &rowErrors) || len(rowErrors) != 1 || rowErrors[0].Row != 6 || rowErrors[0].Column != "alti" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	options.SkipInvalid = true
+	samples, err := ReadCSV(strings.NewReader(input), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples.Len() != 1 || len(samples.Skipped) != 2 || samples.Skipped[0].Row != 5 || samples.Skipped[1].Row != 6 {
+		t.Fatalf("unexpected samples %+v", samples)
+	}
+
+	_, err = ReadCSV(strings.NewReader("x,y,value\n1,2,3\n4,\"5,6\n"), nil)
+	var rowError *RowError
+	if !errors.As(err, &rowError) || rowError.Row != 3 {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+// Package dataset
+// 读取 CSV/TSV、GeoJSON 与 Shapefile 点要素样本数据，输出可直接用于 NewOrdinary 的 t, x, y
+
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/lvisei/go-kriging/pkg/raster"
+)
+
+// ErrMissingValue 值为缺失值标记
+var ErrMissingValue = errors.New("missing value")
+
+// Samples 样本数据
+type Samples struct {
+	Values     []float64            `json:"values"`
+	X          []float64            `json:"x"`
+	Y          []float64            `json:"y"`
+	Covariates map[string][]float64 `json:"covariates,omitempty"` // 协变量，缺失为 NaN
+	Skipped    []*RowError          `json:"skipped,omitempty"`    // 被跳过的行及原因
+}
+
+// Len 样本数
+func (s *Samples) Len() int {
+	return len(s.Values)
+}
+
+// RowError 行级错误
+type RowError struct {
+	Row    int    `json:"row"`    // CSV 中记录起始的行号（从 1 开始），GeoJSON 中从 0 开始的要素序号
+	Column string `json:"column"` // 出错的列或属性
+	Value  string `json:"value"`
+	Err    error  `json:"-"`
+}
+
+func (e *RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+	}
+	return fmt.Sprintf("row %d, column %s: %v %q", e.Row, e.Column, e.Err, e.Value)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// RowErrors 所有无效行
+type RowErrors []*RowError
+
+func (e RowErrors) Error() string {
+	const maxErrors = 10
+	messages := make([]string, 0, maxErrors)
+	for i, rowError := range e {
+		if i == maxErrors {
+			messages = append(messages, fmt.Sprintf("and %d more", len(e)-maxErrors))
+			break
+		}
+		messages = append(messages, rowError.Error())
+	}
+	return fmt.Sprintf("%d invalid rows: %s", len(e), strings.Join(messages, "; "))
+}
+
+// SampleRaster 在各样本点处读取栅格的值作为协变量，超出范围或为无数据值时为 NaN
+func (s *Samples) SampleRaster(name string, r *raster.Raster) {
+	covariate := make([]float64, len(s.X))
+	for i := range s.X {
+		value, ok := r.Lookup(s.X[i], s.Y[i])
+		if !ok {
+			value = math.NaN()
+		}
+		covariate[i] = value
+	}
+	if s.Covariates == nil {
+		s.Covariates = map[string][]float64{}
+	}
+	s.Covariates[name] = covariate
+}
@@ -0,0 +1,185 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/geojson"
+)
+
+// GeoJSONOptions GeoJSON 点要素读取参数
+type GeoJSONOptions struct {
+	Value         string                              // 值属性，默认 value；为 "-" 时不读取值，只读取坐标
+	Covariates    []string                            // 协变量属性
+	Where         map[string]string                   // 属性过滤，属性值转为字符串后与给定值相等的要素才被读取
+	Filter        func(feature *geojson.Feature) bool // 自定义过滤，返回 false 的要素不被读取
+	MissingValues []string                            // 字符串属性的缺失值标记，为 nil 时使用 DefaultMissingValues
+	SkipInvalid   bool                                // 跳过无法解析的要素并记录在 Samples.Skipped 中，否则返回 RowErrors
+}
+
+func (opt *GeoJSONOptions) withDefaults() GeoJSONOptions {
+	o := GeoJSONOptions{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.Value == "" {
+		o.Value = "value"
+	}
+	if o.MissingValues == nil {
+		o.MissingValues = DefaultMissingValues
+	}
+	return o
+}
+
+// ReadGeoJSONFile 读取 GeoJSON 点要素文件
+func ReadGeoJSONFile(path string, opt *GeoJSONOptions) (*Samples, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	samples, err := ReadGeoJSON(f, opt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return samples, nil
+}
+
+// ReadGeoJSON 读取 GeoJSON 点要素，可以是 FeatureCollection、Feature 或 Point
+func ReadGeoJSON(r io.Reader, opt *GeoJSONOptions) (*Samples, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	fc, err := geojson.Decode(content)
+	if err != nil {
+		return nil, err
+	}
+	return FromFeatureCollection(fc, opt)
+}
+
+// FromFeatureCollection 从要素集合中读取点坐标与属性值
+// 坐标或值缺失的要素总是记录在 Samples.Skipped 中，被过滤的要素不记录
+func FromFeatureCollection(fc *geojson.FeatureCollection, opt *GeoJSONOptions) (*Samples, error) {
+	o := opt.withDefaults()
+
+	samples := &Samples{}
+	if len(o.Covariates) > 0 {
+		samples.Covariates = map[string][]float64{}
+	}
+	var invalid RowErrors
+
+	for i, feature := range fc.Features {
+		if feature == nil || !o.match(feature) {
+			continue
+		}
+
+		point, rowError := featurePoint(i, feature)
+		value := 0.0
+		if rowError == nil && o.Value != NoValue {
+			var err error
+			if value, err = o.property(feature, o.Value); err != nil {
+				rowError = &RowError{Row: i, Column: o.Value, Value: propertyString(feature, o.Value), Err: err}
+			}
+		}
+		if rowError != nil {
+			if errors.Is(rowError.Err, ErrMissingValue) || o.SkipInvalid {
+				samples.Skipped = append(samples.Skipped, rowError)
+			} else {
+				invalid = append(invalid, rowError)
+			}
+			continue
+		}
+
+		samples.X = append(samples.X, point[0])
+		samples.Y = append(samples.Y, point[1])
+		if o.Value != NoValue {
+			samples.Values = append(samples.Values, value)
+		}
+		for _, name := range o.Covariates {
+			covariate, err := o.property(feature, name)
+			if err != nil {
+				covariate = math.NaN()
+			}
+			samples.Covariates[name] = append(samples.Covariates[name], covariate)
+		}
+	}
+
+	if len(invalid) > 0 {
+		return nil, invalid
+	}
+	return samples, nil
+}
+
+// match 要素是否通过过滤
+func (o GeoJSONOptions) match(feature *geojson.Feature) bool {
+	for name, want := range o.Where {
+		if _, ok := feature.Properties[name]; !ok || propertyString(feature, name) != want {
+			return false
+		}
+	}
+	return o.Filter == nil || o.Filter(feature)
+}
+
+// featurePoint 要素的点坐标，几何为空时返回 ErrMissingValue
+func featurePoint(i int, feature *geojson.Feature) (ordinarykriging.Point, *RowError) {
+	if feature.Geometry == nil {
+		return ordinarykriging.Point{}, &RowError{Row: i, Column: "geometry", Err: ErrMissingValue}
+	}
+	point, err := feature.Geometry.Point()
+	if err != nil {
+		return ordinarykriging.Point{}, &RowError{Row: i, Column: "geometry", Value: feature.Geometry.Type, Err: err}
+	}
+	if math.IsNaN(point[0]) || math.IsInf(point[0], 0) || math.IsNaN(point[1]) || math.IsInf(point[1], 0) {
+		return ordinarykriging.Point{}, &RowError{Row: i, Column: "geometry", Err: errors.New("non-finite coordinates")}
+	}
+	return point, nil
+}
+
+// property 解析数值属性，数字字符串同样被接受，属性不存在、为 null 或缺失值标记时返回 ErrMissingValue
+func (o GeoJSONOptions) property(feature *geojson.Feature, name string) (float64, error) {
+	switch v := feature.Properties[name].(type) {
+	case nil:
+		return 0, ErrMissingValue
+	case float64:
+		return v, nil
+	case string:
+		field := strings.TrimSpace(v)
+		for _, marker := range o.MissingValues {
+			if strings.EqualFold(field, marker) {
+				return 0, ErrMissingValue
+			}
+		}
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return 0, errors.New("invalid number")
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return 0, errors.New("non-finite number")
+		}
+		return value, nil
+	}
+	return 0, errors.New("not a number")
+}
+
+// propertyString 属性值的字符串形式，用于过滤与错误信息
+func propertyString(feature *geojson.Feature, name string) string {
+	switch v := feature.Properties[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,44 @@
+package dataset
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+const stations = `{
+  "type": "FeatureCollection",
+  "features": [
+    {"type": "Feature", "geometry": {"type": "Point", "coordinates": [102.68, 25.95]}, "properties": {"TEM_Avg": 9.6, "Alti": 2304, "type": "auto"}},
+    {"type": "Feature", "geometry": {"type": "Point", "coordinates": [102.88, 26.04]}, "properties": {"TEM_Avg": null, "type": "auto"}},
+    {"type": "Feature", "geometry": {"type": "Point", "coordinates": [102.9, 26.1]}, "properties": {"TEM_Avg": "5.3", "type": "auto"}},
+    {"type": "Feature", "geometry": {"type": "Point", "coordinates": [103.1, 26.2]}, "properties": {"TEM_Avg": "warm", "type": "auto"}},
+    {"type": "Feature", "geometry": {"type": "Point", "coordinates": [103.2, 26.3]}, "properties": {"TEM_Avg": 7.1, "type": "manual"}},
+    {"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]}, "properties": {"TEM_Avg": 1, "type": "auto"}}
+  ]
+}`
+
+func TestReadGeoJSON(t *testing.T) {
+	opt := &GeoJSONOptions{Value: "TEM_Avg", Covariates: []string{"Alti"}, Where: map[string]string{"type": "auto"}}
+	_, err := ReadGeoJSON(strings.NewReader(stations), opt)
+	var rowErrors RowErrors
+	if !errors.As(err, &rowErrors) || len(rowErrors) != 2 || rowErrors[0].Row != 3 || rowErrors[1].Column != "geometry" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	opt.SkipInvalid = true
+	samples, err := ReadGeoJSON(strings.NewReader(stations), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples.Len() != 2 || samples.X[1] != 102.9 || samples.Values[1] != 5.3 {
+		t.Fatalf("unexpected samples %+v", samples)
+	}
+	if alti := samples.Covariates["Alti"]; alti[0] != 2304 || !math.IsNaN(alti[1]) {
+		t.Fatalf("unexpected covariate %v", alti)
+	}
+	if len(samples.Skipped) != 3 || samples.Skipped[0].Row != 1 || !errors.Is(samples.Skipped[0], ErrMissingValue) {
+		t.Fatalf("unexpected skipped %v", samples.Skipped)
+	}
+}
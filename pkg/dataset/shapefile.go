@@ -0,0 +1,20 @@
+package dataset
+
+import (
+	"fmt"
+
+	"github.com/lvisei/go-kriging/pkg/shapefile"
+)
+
+// ReadShapefile 读取 Shapefile 点要素，DBF 属性作为要素属性，参数与 GeoJSON 相同
+func ReadShapefile(path string, opt *GeoJSONOptions) (*Samples, error) {
+	s, err := shapefile.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := FromFeatureCollection(s.FeatureCollection(), opt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return samples, nil
+}
@@ -0,0 +1,230 @@
+// Package geojson
+// GeoJSON 读写，以及与 ordinarykriging 几何类型之间的转换
+
+package geojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+)
+
+// GeoJSON 对象类型
+const (
+	TypePoint              = "Point"
+	TypeMultiPoint         = "MultiPoint"
+	TypeLineString         = "LineString"
+	TypeMultiLineString    = "MultiLineString"
+	TypePolygon            = "Polygon"
+	TypeMultiPolygon       = "MultiPolygon"
+	TypeGeometryCollection = "GeometryCollection"
+	TypeFeature            = "Feature"
+	TypeFeatureCollection  = "FeatureCollection"
+)
+
+// Geometry 几何对象，Coordinates 解码后为嵌套的 []interface{}
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates,omitempty"`
+	Geometries  []*Geometry `json:"geometries,omitempty"`
+}
+
+// Feature 要素
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         interface{}            `json:"id,omitempty"`
+	Geometry   *Geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// FeatureCollection 要素集合
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+// object 任意 GeoJSON 对象
+type object struct {
+	Type        string                 `json:"type"`
+	Coordinates interface{}            `json:"coordinates"`
+	Geometries  []*Geometry            `json:"geometries"`
+	ID          interface{}            `json:"id"`
+	Geometry    *Geometry              `json:"geometry"`
+	Properties  map[string]interface{} `json:"properties"`
+	Features    []*Feature             `json:"features"`
+}
+
+// NewFeature 创建要素
+func NewFeature(geometry *Geometry, properties map[string]interface{}) *Feature {
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	return &Feature{Type: TypeFeature, Geometry: geometry, Properties: properties}
+}
+
+// NewFeatureCollection 创建要素集合
+func NewFeatureCollection(features ...*Feature) *FeatureCollection {
+	if features == nil {
+		features = []*Feature{}
+	}
+	return &FeatureCollection{Type: TypeFeatureCollection, Features: features}
+}
+
+// Decode 解码任意 GeoJSON 对象，几何对象与要素统一为要素集合
+func Decode(data []byte) (*FeatureCollection, error) {
+	var o object
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("geojson: %v", err)
+	}
+
+	switch o.Type {
+	case TypeFeatureCollection:
+		return &FeatureCollection{Type: o.Type, Features: o.Features}, nil
+	case TypeFeature:
+		return NewFeatureCollection(&Feature{Type: o.Type, ID: o.ID, Geometry: o.Geometry, Properties: o.Properties}), nil
+	case TypePoint, TypeMultiPoint, TypeLineString, TypeMultiLineString, TypePolygon, TypeMultiPolygon, TypeGeometryCollection:
+		return NewFeatureCollection(NewFeature(&Geometry{Type: o.Type, Coordinates: o.Coordinates, Geometries: o.Geometries}, nil)), nil
+	case "":
+		return nil, errors.New("geojson: missing type")
+	}
+	return nil, fmt.Errorf("geojson: unknown type %q", o.Type)
+}
+
+// ReadFile 读取 GeoJSON 文件
+func ReadFile(path string) (*FeatureCollection, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(content)
+}
+
+// Marshal 编码为 GeoJSON
+// 属性为 map，使用标准库编码，jsoniter v1.1.10 在新版本 Go 上遍历 map 会崩溃
+func (fc *FeatureCollection) Marshal() ([]byte, error) {
+	return json.Marshal(fc)
+}
+
+// MultiPolygon 所有要素中的 Polygon 与 MultiPolygon 合并为一个多面，其它几何类型被忽略
+func (fc *FeatureCollection) MultiPolygon() (ordinarykriging.MultiPolygonCoordinates, error) {
+	var multiPolygon ordinarykriging.MultiPolygonCoordinates
+	for i, feature := range fc.Features {
+		if feature == nil || feature.Geometry == nil {
+			continue
+		}
+		polygons, err := feature.Geometry.MultiPolygon()
+		if err != nil {
+			return nil, fmt.Errorf("geojson: feature %d: %v", i, err)
+		}
+		multiPolygon = append(multiPolygon, polygons...)
+	}
+	if len(multiPolygon) == 0 {
+		return nil, errors.New("geojson: no polygon")
+	}
+	return multiPolygon, nil
+}
+
+// ReadMultiPolygon 从 Polygon、MultiPolygon、Feature、FeatureCollection 中读取多面，用于 GridMultiPolygon 裁剪
+func ReadMultiPolygon(data []byte) (ordinarykriging.MultiPolygonCoordinates, error) {
+	fc, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return fc.MultiPolygon()
+}
+
+// MultiPolygon 几何对象中的面，Polygon、MultiPolygon 与 GeometryCollection 以外的类型返回空
+func (g *Geometry) MultiPolygon() (ordinarykriging.MultiPolygonCoordinates, error) {
+	switch g.Type {
+	case TypePolygon:
+		polygon, err := toPolygon(g.Coordinates)
+		if err != nil {
+			return nil, err
+		}
+		return ordinarykriging.MultiPolygonCoordinates{polygon}, nil
+	case TypeMultiPolygon:
+		items, ok := g.Coordinates.([]interface{})
+		if !ok {
+			return nil, errors.New("invalid MultiPolygon coordinates")
+		}
+		multiPolygon := make(ordinarykriging.MultiPolygonCoordinates, 0, len(items))
+		for _, item := range items {
+			polygon, err := toPolygon(item)
+			if err != nil {
+				return nil, err
+			}
+			multiPolygon = append(multiPolygon, polygon)
+		}
+		return multiPolygon, nil
+	case TypeGeometryCollection:
+		var multiPolygon ordinarykriging.MultiPolygonCoordinates
+		for _, geometry := range g.Geometries {
+			polygons, err := geometry.MultiPolygon()
+			if err != nil {
+				return nil, err
+			}
+			multiPolygon = append(multiPolygon, polygons...)
+		}
+		return multiPolygon, nil
+	}
+	return nil, nil
+}
+
+// Point 点几何对象的坐标
+func (g *Geometry) Point() (ordinarykriging.Point, error) {
+	if g.Type != TypePoint {
+		return ordinarykriging.Point{}, fmt.Errorf("not a Point but %q", g.Type)
+	}
+	return toPoint(g.Coordinates)
+}
+
+func toPoint(v interface{}) (ordinarykriging.Point, error) {
+	items, ok := v.([]interface{})
+	if !ok || len(items) < 2 {
+		return ordinarykriging.Point{}, errors.New("invalid position")
+	}
+	x, xOk := items[0].(float64)
+	y, yOk := items[1].(float64)
+	if !xOk || !yOk {
+		return ordinarykriging.Point{}, errors.New("invalid position")
+	}
+	return ordinarykriging.Point{x, y}, nil
+}
+
+func toRing(v interface{}) (ordinarykriging.Ring, error) {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.New("invalid linear ring")
+	}
+	ring := make(ordinarykriging.Ring, 0, len(items))
+	for _, item := range items {
+		point, err := toPoint(item)
+		if err != nil {
+			return nil, err
+		}
+		ring = append(ring, point)
+	}
+	if len(ring) < 3 {
+		return nil, errors.New("linear ring needs at least 3 positions")
+	}
+	return ring, nil
+}
+
+func toPolygon(v interface{}) (ordinarykriging.PolygonCoordinates, error) {
+	items, ok := v.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, errors.New("invalid Polygon coordinates")
+	}
+	polygon := make(ordinarykriging.PolygonCoordinates, 0, len(items))
+	for _, item := range items {
+		ring, err := toRing(item)
+		if err != nil {
+			return nil, err
+		}
+		polygon = append(polygon, ring)
+	}
+	return polygon, nil
+}
@@ -0,0 +1,81 @@
+package geojson
+
+import (
+	"testing"
+)
+
+const featureCollection = `{
+  "type": "FeatureCollection",
+  "features": [
+    {
+      "type": "Feature",
+      "properties": {"name": "lake"},
+      "geometry": {
+        "type": "Polygon",
+        "coordinates": [
+          [[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]],
+          [[4, 4], [6, 4], [6, 6], [4, 6], [4, 4]]
+        ]
+      }
+    },
+    {
+      "type": "Feature",
+      "properties": {},
+      "geometry": {
+        "type": "MultiPolygon",
+        "coordinates": [
+          [[[20, 0], [30, 0], [30, 10], [20, 0]]],
+          [[[40, 0], [50, 0], [50, 10], [40, 0]]]
+        ]
+      }
+    },
+    {
+      "type": "Feature",
+      "properties": {},
+      "geometry": {"type": "Point", "coordinates": [1, 2]}
+    }
+  ]
+}`
+
+func TestReadMultiPolygon(t *testing.T) {
+	multiPolygon, err := ReadMultiPolygon([]byte(featureCollection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(multiPolygon) != 3 || len(multiPolygon[0]) != 2 {
+		t.Fatalf("unexpected multi polygon %v", multiPolygon)
+	}
+
+	for _, c := range []struct {
+		x, y     float64
+		contains bool
+	}{
+		{1, 1, true},
+		{5, 5, false}, // hole
+		{28, 2, true},
+		{45, 1, true},
+		{35, 1, false},
+	} {
+		if multiPolygon.Contains(c.x, c.y) != c.contains {
+			t.Fatalf("Contains(%v, %v) != %v", c.x, c.y, c.contains)
+		}
+	}
+}
+
+func TestDecode_Geometry(t *testing.T) {
+	fc, err := Decode([]byte(`{"type":"Point","coordinates":[103.6,27.0]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	point, err := fc.Features[0].Geometry.Point()
+	if err != nil || point[0] != 103.6 || point[1] != 27.0 {
+		t.Fatalf("unexpected point %v %v", point, err)
+	}
+
+	if _, err := ReadMultiPolygon([]byte(`{"type":"Point","coordinates":[0,0]}`)); err == nil {
+		t.Fatal("expected error without polygon")
+	}
+	if _, err := Decode([]byte(`{"type":"Unknown"}`)); err == nil {
+		t.Fatal("expected error for unknown type")
+	}
+}
@@ -0,0 +1,383 @@
+// Package geotiff
+// 纯 Go 实现的 GeoTIFF 写入，支持 Float32/Float64、多波段、分块与 Deflate 压缩
+
+package geotiff
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/raster"
+)
+
+// TIFF 数据类型
+const (
+	typeASCII  = 2
+	typeShort  = 3
+	typeLong   = 4
+	typeDouble = 12
+)
+
+// TIFF 与 GeoTIFF 标签
+const (
+	tagImageWidth                = 256
+	tagImageLength               = 257
+	tagBitsPerSample             = 258
+	tagCompression               = 259
+	tagPhotometricInterpretation = 262
+	tagStripOffsets              = 273
+	tagSamplesPerPixel           = 277
+	tagRowsPerStrip              = 278
+	tagStripByteCounts           = 279
+	tagPlanarConfiguration       = 284
+	tagSampleFormat              = 339
+	tagModelPixelScale           = 33550
+	tagModelTiepoint             = 33922
+	tagGeoKeyDirectory           = 34735
+	tagTileWidth                 = 322
+	tagTileLength                = 323
+	tagTileOffsets               = 324
+	tagTileByteCounts            = 325
+	tagGDALNodata                = 42113
+)
+
+// GeoKey
+const (
+	keyGTModelType      = 1024
+	keyGTRasterType     = 1025
+	keyGeographicType   = 2048
+	keyProjectedCSType  = 3072
+	modelTypeProjected  = 1
+	modelTypeGeographic = 2
+	rasterPixelIsArea   = 1
+)
+
+// DefaultEPSG 默认坐标参考系 WGS 84
+const DefaultEPSG = 4326
+
+// DataType 像元数据类型
+type DataType int
+
+const (
+	Float64 DataType = iota
+	Float32
+)
+
+// Compression 压缩方式
+type Compression int
+
+const (
+	NoCompression Compression = iota
+	Deflate
+)
+
+// Options 写入参数
+type Options struct {
+	EPSG        int                        // 坐标参考系 EPSG 代码，默认 4326
+	DataType    DataType                   // 像元数据类型，默认 Float64
+	Compression Compression                // 压缩方式，默认不压缩
+	TileSize    int                        // 大于 0 时按 TileSize x TileSize 分块存储，须为 16 的倍数
+	Variogram   *ordinarykriging.Variogram // 不为 nil 时追加克里金方差波段
+}
+
+func (opt *Options) withDefaults() Options {
+	o := Options{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.EPSG <= 0 {
+		o.EPSG = DefaultEPSG
+	}
+	return o
+}
+
+// entry IFD 条目
+type entry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func shortEntry(tag uint16, values ...uint16) entry {
+	data := make([]byte, 2*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint16(data[2*i:], v)
+	}
+	return entry{tag: tag, typ: typeShort, count: uint32(len(values)), data: data}
+}
+
+func longEntry(tag uint16, values ...uint32) entry {
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], v)
+	}
+	return entry{tag: tag, typ: typeLong, count: uint32(len(values)), data: data}
+}
+
+func doubleEntry(tag uint16, values ...float64) entry {
+	data := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(data[8*i:], math.Float64bits(v))
+	}
+	return entry{tag: tag, typ: typeDouble, count: uint32(len(values)), data: data}
+}
+
+func asciiEntry(tag uint16, value string) entry {
+	data := append([]byte(value), 0)
+	return entry{tag: tag, typ: typeASCII, count: uint32(len(data)), data: data}
+}
+
+// geoKeys GeoKeyDirectory 内容
+func geoKeys(epsg int) []uint16 {
+	modelType, crsKey := uint16(modelTypeGeographic), uint16(keyGeographicType)
+	if !raster.IsGeographicEPSG(epsg) {
+		modelType, crsKey = modelTypeProjected, keyProjectedCSType
+	}
+	return []uint16{
+		1, 1, 0, 3,
+		keyGTModelType, 0, 1, modelType,
+		keyGTRasterType, 0, 1, rasterPixelIsArea,
+		crsKey, 0, 1, uint16(epsg),
+	}
+}
+
+// Encode 将栅格写为 GeoTIFF，Options.Variogram 不为 nil 时第二个波段为克里金方差
+func Encode(w io.Writer, r *raster.Raster, opt *Options) error {
+	if r == nil || r.Width <= 0 || r.Height <= 0 || len(r.Data) != r.Width*r.Height {
+		return errors.New("geotiff: invalid raster")
+	}
+	bands := []*raster.Raster{r}
+	if opt != nil && opt.Variogram != nil {
+		bands = append(bands, r.Variance(opt.Variogram))
+	}
+	return EncodeBands(w, bands, opt)
+}
+
+// EncodeGridMatrices 将 Grid 的结果写为 GeoTIFF，范围外的像元为 NodataValue
+func EncodeGridMatrices(w io.Writer, gridMatrices *ordinarykriging.GridMatrices, opt *Options) error {
+	return Encode(w, raster.FromGridMatrices(gridMatrices), opt)
+}
+
+// EncodeContourRectangle 将 ContourWithBBox 的结果写为 GeoTIFF
+func EncodeContourRectangle(w io.Writer, contourRectangle *ordinarykriging.ContourRectangle, opt *Options) error {
+	return Encode(w, raster.FromContourRectangle(contourRectangle), opt)
+}
+
+// EncodeBands 将宽高与地理参考相同的多个栅格写为多波段 GeoTIFF，无数据值取第一个波段的
+// IFD 位于文件头之后、影像数据之前，分块存储时与 Cloud Optimized GeoTIFF 的布局一致（不含金字塔）
+func EncodeBands(w io.Writer, bands []*raster.Raster, opt *Options) error {
+	if len(bands) == 0 {
+		return errors.New("geotiff: no band")
+	}
+	r := bands[0]
+	for _, band := range bands {
+		if band == nil || band.Width <= 0 || band.Height <= 0 || len(band.Data) != band.Width*band.Height ||
+			band.Width != r.Width || band.Height != r.Height {
+			return errors.New("geotiff: invalid raster")
+		}
+	}
+	o := opt.withDefaults()
+	if o.TileSize < 0 || o.TileSize%16 != 0 {
+		return fmt.Errorf("geotiff: tile size %d is not a multiple of 16", o.TileSize)
+	}
+
+	chunks, err := encodeChunks(bands, o)
+	if err != nil {
+		return err
+	}
+
+	bitsPerSample := uint16(64)
+	if o.DataType == Float32 {
+		bitsPerSample = 32
+	}
+	compression := uint16(1)
+	if o.Compression == Deflate {
+		compression = 8
+	}
+	planarConfiguration := uint16(1)
+	if len(bands) > 1 {
+		planarConfiguration = 2
+	}
+	samples := make([]uint16, len(bands))
+	sampleFormats := make([]uint16, len(bands))
+	for i := range bands {
+		samples[i] = bitsPerSample
+		sampleFormats[i] = 3
+	}
+
+	entries := []entry{
+		longEntry(tagImageWidth, uint32(r.Width)),
+		longEntry(tagImageLength, uint32(r.Height)),
+		shortEntry(tagBitsPerSample, samples...),
+		shortEntry(tagCompression, compression),
+		shortEntry(tagPhotometricInterpretation, 1),
+		shortEntry(tagSamplesPerPixel, uint16(len(bands))),
+		shortEntry(tagPlanarConfiguration, planarConfiguration),
+		shortEntry(tagSampleFormat, sampleFormats...),
+		doubleEntry(tagModelPixelScale, r.XResolution, r.YResolution, 0),
+		doubleEntry(tagModelTiepoint, 0, 0, 0, r.X0, r.Y0, 0),
+		shortEntry(tagGeoKeyDirectory, geoKeys(o.EPSG)...),
+	}
+	if r.HasNodata {
+		entries = append(entries, asciiEntry(tagGDALNodata, strconv.FormatFloat(r.NodataValue, 'g', -1, 64)))
+	}
+
+	offsetsTag, byteCountsTag := uint16(tagStripOffsets), uint16(tagStripByteCounts)
+	if o.TileSize > 0 {
+		offsetsTag, byteCountsTag = tagTileOffsets, tagTileByteCounts
+		entries = append(entries, shortEntry(tagTileWidth, uint16(o.TileSize)), shortEntry(tagTileLength, uint16(o.TileSize)))
+	} else {
+		entries = append(entries, longEntry(tagRowsPerStrip, uint32(r.Height)))
+	}
+	byteCounts := make([]uint32, len(chunks))
+	for i, chunk := range chunks {
+		byteCounts[i] = uint32(len(chunk))
+	}
+	entries = append(entries, longEntry(byteCountsTag, byteCounts...))
+
+	// 偏移量条目的长度与取值无关，先按占位计算 IFD 大小，再得到影像数据的起始位置
+	const headerSize = 8
+	offsets := make([]uint32, len(chunks))
+	entries = append(entries, longEntry(offsetsTag, offsets...))
+	offset := uint32(headerSize + ifdSize(entries))
+	for i, chunk := range chunks {
+		offsets[i] = offset
+		offset += uint32(len(chunk))
+	}
+	entries[len(entries)-1] = longEntry(offsetsTag, offsets...)
+
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{'I', 'I', 42, 0})
+	binary.Write(buf, binary.LittleEndian, uint32(headerSize))
+	writeIFD(buf, entries)
+	for _, chunk := range chunks {
+		buf.Write(chunk)
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// encodeChunks 按波段依次切分条带或分块并编码，分块超出栅格的部分以无数据值填充
+func encodeChunks(bands []*raster.Raster, o Options) ([][]byte, error) {
+	r := bands[0]
+	fill := math.NaN()
+	if r.HasNodata {
+		fill = r.NodataValue
+	}
+
+	var chunks [][]byte
+	for _, band := range bands {
+		if o.TileSize <= 0 {
+			chunk, err := encodeChunk(band.Data, o)
+			if err != nil {
+				return nil, err
+			}
+			chunks = append(chunks, chunk)
+			continue
+		}
+
+		tile := make([]float64, o.TileSize*o.TileSize)
+		for tileRow := 0; tileRow < r.Height; tileRow += o.TileSize {
+			for tileCol := 0; tileCol < r.Width; tileCol += o.TileSize {
+				for i := range tile {
+					row, col := tileRow+i/o.TileSize, tileCol+i%o.TileSize
+					if row < r.Height && col < r.Width {
+						tile[i] = band.At(col, row)
+					} else {
+						tile[i] = fill
+					}
+				}
+				chunk, err := encodeChunk(tile, o)
+				if err != nil {
+					return nil, err
+				}
+				chunks = append(chunks, chunk)
+			}
+		}
+	}
+	return chunks, nil
+}
+
+// encodeChunk 编码一个条带或分块，长度补齐为偶数
+func encodeChunk(data []float64, o Options) ([]byte, error) {
+	var pixels []byte
+	if o.DataType == Float32 {
+		pixels = make([]byte, 4*len(data))
+		for i, v := range data {
+			binary.LittleEndian.PutUint32(pixels[4*i:], math.Float32bits(float32(v)))
+		}
+	} else {
+		pixels = make([]byte, 8*len(data))
+		for i, v := range data {
+			binary.LittleEndian.PutUint64(pixels[8*i:], math.Float64bits(v))
+		}
+	}
+
+	if o.Compression == Deflate {
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(pixels); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		pixels = compressed.Bytes()
+	}
+	if len(pixels)%2 == 1 {
+		pixels = append(pixels, 0)
+	}
+	return pixels, nil
+}
+
+// ifdSize IFD 及其溢出数据的字节数
+func ifdSize(entries []entry) int {
+	size := 2 + 12*len(entries) + 4
+	for _, e := range entries {
+		if len(e.data) > 4 {
+			size += len(e.data) + len(e.data)%2
+		}
+	}
+	return size
+}
+
+// writeIFD 在 buf 末尾写入 IFD 及其溢出数据
+func writeIFD(buf *bytes.Buffer, entries []entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].tag < entries[j].tag
+	})
+
+	ifdSize := 2 + 12*len(entries) + 4
+	extraOffset := buf.Len() + ifdSize
+	var extra []byte
+
+	binary.Write(buf, binary.LittleEndian, uint16(len(entries)))
+	for _, e := range entries {
+		binary.Write(buf, binary.LittleEndian, e.tag)
+		binary.Write(buf, binary.LittleEndian, e.typ)
+		binary.Write(buf, binary.LittleEndian, e.count)
+		if len(e.data) <= 4 {
+			value := make([]byte, 4)
+			copy(value, e.data)
+			buf.Write(value)
+			continue
+		}
+		binary.Write(buf, binary.LittleEndian, uint32(extraOffset+len(extra)))
+		extra = append(extra, e.data...)
+		if len(extra)%2 == 1 {
+			extra = append(extra, 0)
+		}
+	}
+	binary.Write(buf, binary.LittleEndian, uint32(0))
+	buf.Write(extra)
+}
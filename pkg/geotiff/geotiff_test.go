@@ -0,0 +1,135 @@
+package geotiff
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/lvisei/go-kriging/pkg/raster"
+)
+
+// readIFD 读取第一个 IFD 的全部条目，返回 tag 到原始数据的映射
+func readIFD(t *testing.T, b []byte) map[uint16][]byte {
+	if string(b[:4]) != "II*\x00" {
+		t.Fatalf("unexpected header %q", b[:4])
+	}
+	sizes := map[uint16]uint32{typeASCII: 1, typeShort: 2, typeLong: 4, typeDouble: 8}
+	offset := binary.LittleEndian.Uint32(b[4:])
+	if offset != 8 {
+		t.Fatalf("IFD should follow the header, got offset %d", offset)
+	}
+	n := int(binary.LittleEndian.Uint16(b[offset:]))
+	tags := map[uint16][]byte{}
+	for i := 0; i < n; i++ {
+		e := b[int(offset)+2+12*i:]
+		tag := binary.LittleEndian.Uint16(e)
+		size := sizes[binary.LittleEndian.Uint16(e[2:])] * binary.LittleEndian.Uint32(e[4:])
+		if size <= 4 {
+			tags[tag] = e[8 : 8+size]
+		} else {
+			start := binary.LittleEndian.Uint32(e[8:])
+			tags[tag] = b[start : start+size]
+		}
+	}
+	return tags
+}
+
+func TestEncode(t *testing.T) {
+	r := raster.New(3, 2, 100, 30, 0.5, 0.25)
+	for i := range r.Data {
+		r.Data[i] = float64(i)
+	}
+	r.HasNodata = true
+	r.NodataValue = -9999
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, r, nil); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	tags := readIFD(t, b)
+
+	if width := binary.LittleEndian.Uint32(tags[tagImageWidth]); width != 3 {
+		t.Fatalf("unexpected width %d", width)
+	}
+	if height := binary.LittleEndian.Uint32(tags[tagImageLength]); height != 2 {
+		t.Fatalf("unexpected height %d", height)
+	}
+	if nodata := string(tags[tagGDALNodata]); nodata != "-9999\x00" {
+		t.Fatalf("unexpected nodata %q", nodata)
+	}
+	tiepoint := tags[tagModelTiepoint]
+	if x := math.Float64frombits(binary.LittleEndian.Uint64(tiepoint[24:])); x != 100 {
+		t.Fatalf("unexpected tiepoint x %v", x)
+	}
+	if y := math.Float64frombits(binary.LittleEndian.Uint64(tiepoint[32:])); y != 30 {
+		t.Fatalf("unexpected tiepoint y %v", y)
+	}
+
+	offset := binary.LittleEndian.Uint32(tags[tagStripOffsets])
+	for i := range r.Data {
+		v := math.Float64frombits(binary.LittleEndian.Uint64(b[int(offset)+8*i:]))
+		if v != r.Data[i] {
+			t.Fatalf("unexpected pixel %d: %v", i, v)
+		}
+	}
+}
+
+func TestEncodeBands_TiledDeflate(t *testing.T) {
+	value := raster.New(20, 18, 100, 30, 0.5, 0.5)
+	variance := raster.New(20, 18, 100, 30, 0.5, 0.5)
+	for i := range value.Data {
+		value.Data[i] = float64(i)
+		variance.Data[i] = float64(i) / 10
+	}
+	value.HasNodata = true
+	value.NodataValue = -9999
+
+	var buf bytes.Buffer
+	opt := &Options{EPSG: 3857, DataType: Float32, Compression: Deflate, TileSize: 16}
+	if err := EncodeBands(&buf, []*raster.Raster{value, variance}, opt); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	tags := readIFD(t, b)
+
+	if samples := binary.LittleEndian.Uint16(tags[tagSamplesPerPixel]); samples != 2 {
+		t.Fatalf("unexpected samples per pixel %d", samples)
+	}
+	if compression := binary.LittleEndian.Uint16(tags[tagCompression]); compression != 8 {
+		t.Fatalf("unexpected compression %d", compression)
+	}
+	if keys := tags[tagGeoKeyDirectory]; binary.LittleEndian.Uint16(keys[12*2:]) != keyProjectedCSType ||
+		binary.LittleEndian.Uint16(keys[15*2:]) != 3857 {
+		t.Fatalf("unexpected geo keys %v", keys)
+	}
+	offsets, byteCounts := tags[tagTileOffsets], tags[tagTileByteCounts]
+	if len(offsets) != 4*8 {
+		t.Fatalf("unexpected tile count %d", len(offsets)/4)
+	}
+
+	// 第二个波段右下角的分块，(17, 16) 在分块内为 (1, 0)，超出栅格的部分为无数据值
+	tile := 7
+	offset := binary.LittleEndian.Uint32(offsets[4*tile:])
+	size := binary.LittleEndian.Uint32(byteCounts[4*tile:])
+	zr, err := zlib.NewReader(bytes.NewReader(b[offset : offset+size]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pixels, err := ioutil.ReadAll(zr)
+	if err != nil || len(pixels) != 16*16*4 {
+		t.Fatalf("unexpected tile %d bytes %v", len(pixels), err)
+	}
+	pixel := func(i int) float32 {
+		return math.Float32frombits(binary.LittleEndian.Uint32(pixels[4*i:]))
+	}
+	if v := pixel(1); v != float32(variance.At(17, 16)) {
+		t.Fatalf("unexpected pixel %v", v)
+	}
+	if v := pixel(4); v != -9999 {
+		t.Fatalf("unexpected fill %v", v)
+	}
+}
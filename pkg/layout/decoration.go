@@ -0,0 +1,129 @@
+package layout
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/lvisei/go-kriging/canvas"
+	"github.com/lvisei/go-kriging/pkg/palette"
+)
+
+const (
+	legendSwatch = 14.0
+	legendGap    = 6.0
+	legendTicks  = 5
+)
+
+// legendLabels 分级的区间文字（由低到高），或连续色条的刻度文字（由低到高）
+func legendLabels(p *palette.Palette) []string {
+	var labels []string
+	if p.Classified() {
+		for i := 0; i+1 < len(p.Breaks); i++ {
+			labels = append(labels, formatValue(p.Breaks[i])+" - "+formatValue(p.Breaks[i+1]))
+		}
+		return labels
+	}
+	for i := 0; i < legendTicks; i++ {
+		labels = append(labels, formatValue(p.Domain[0]+float64(i)*(p.Domain[1]-p.Domain[0])/(legendTicks-1)))
+	}
+	return labels
+}
+
+// drawLegend 高值在上的色块或色条
+func (l *Layout) drawLegend(ctx *canvas.Canvas, b *box) error {
+	x, y := b.legendX, b.legendY
+	lineHeight := l.lineHeight(l.textSize())
+	if l.LegendTitle != "" {
+		if err := l.drawText(ctx, l.LegendTitle, l.textSize(), color.Black, x, y+lineHeight/2, 0, 0.5); err != nil {
+			return err
+		}
+		y += lineHeight * 1.6
+	}
+	border := color.Gray{Y: 120}
+
+	if l.Palette.Classified() {
+		for i := len(b.legendLabels) - 1; i >= 0; i-- {
+			ctx.DrawRect(x, y, legendSwatch, legendSwatch, l.Palette.ClassColor(i))
+			ctx.DrawPath(rectangle(x, y, legendSwatch, legendSwatch), border, 1)
+			if err := l.drawText(ctx, b.legendLabels[i], l.textSize(), color.Black, x+legendSwatch+legendGap, y+legendSwatch/2, 0, 0.5); err != nil {
+				return err
+			}
+			y += legendSwatch + legendGap/2
+		}
+		return nil
+	}
+
+	height := math.Min(float64(b.frame.Dy())*0.6, 200)
+	domain := l.Palette.Domain
+	for k := 0.0; k < height; k++ {
+		value := domain[1] - (k+0.5)/height*(domain[1]-domain[0])
+		ctx.DrawRect(x, y+k, legendSwatch, 1, l.Palette.Color(value))
+	}
+	ctx.DrawPath(rectangle(x, y, legendSwatch, height), border, 1)
+	for i, label := range b.legendLabels {
+		ty := y + height - float64(i)*height/(legendTicks-1)
+		ctx.DrawLine(border, x+legendSwatch, ty, 3)
+		if err := l.drawText(ctx, label, l.textSize(), color.Black, x+legendSwatch+legendGap, ty, 0, 0.5); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drawScaleBar 地图左下角的比例尺，长度取约四分之一图宽的 1、2、5 倍整数
+func (l *Layout) drawScaleBar(ctx *canvas.Canvas, frame image.Rectangle, xlim, ylim [2]float64) error {
+	metersPerUnit := 1.0
+	if l.Geographic {
+		metersPerUnit = metersPerDegree * math.Cos((ylim[0]+ylim[1])/2*math.Pi/180)
+	}
+	metersPerPixel := (xlim[1] - xlim[0]) / float64(frame.Dx()) * metersPerUnit
+	if !(metersPerPixel > 0) {
+		return nil
+	}
+	meters := niceLength(float64(frame.Dx()) / 4 * metersPerPixel)
+	width := meters / metersPerPixel
+
+	label := fmt.Sprintf("%g m", meters)
+	if meters >= 1000 {
+		label = fmt.Sprintf("%g km", meters/1000)
+	}
+	lineHeight := l.lineHeight(l.textSize())
+	x, y := float64(frame.Min.X)+12, float64(frame.Max.Y)-12
+	ctx.DrawRect(x-6, y-lineHeight-14, width+12, lineHeight+20, color.NRGBA{R: 255, G: 255, B: 255, A: 200})
+	ctx.DrawRect(x, y-5, width/2, 5, color.Black)
+	ctx.DrawRect(x+width/2, y-5, width/2, 5, color.White)
+	ctx.DrawPath(rectangle(x, y-5, width, 5), color.Black, 1)
+	return l.drawText(ctx, label, l.textSize(), color.Black, x+width/2, y-9, 0.5, 0)
+}
+
+// niceLength 不大于 target 的 1、2、5 乘 10 的整数次幂
+func niceLength(target float64) float64 {
+	unit := math.Pow(10, math.Floor(math.Log10(target)))
+	for _, f := range []float64{5, 2, 1} {
+		if f*unit <= target {
+			return f * unit
+		}
+	}
+	return unit
+}
+
+// drawNorthArrow 地图右上角的指北针
+func (l *Layout) drawNorthArrow(ctx *canvas.Canvas, frame image.Rectangle) error {
+	lineHeight := l.lineHeight(l.textSize())
+	cx, top := float64(frame.Max.X)-24, float64(frame.Min.Y)+10
+	if err := l.drawText(ctx, "N", l.textSize(), color.Black, cx, top+lineHeight/2, 0.5, 0.5); err != nil {
+		return err
+	}
+	tip, base := top+lineHeight+4, top+lineHeight+30
+	ctx.DrawPolygon([][2]float64{{cx, tip}, {cx - 9, base}, {cx, base - 7}}, color.Black)
+	ctx.DrawPolygon([][2]float64{{cx, tip}, {cx + 9, base}, {cx, base - 7}}, color.White)
+	ctx.DrawPath([][2]float64{{cx, tip}, {cx - 9, base}, {cx, base - 7}, {cx + 9, base}, {cx, tip}}, color.Black, 1)
+	return nil
+}
+
+// rectangle 矩形的闭合折线
+func rectangle(x, y, w, h float64) [][2]float64 {
+	return [][2]float64{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}, {x, y}}
+}
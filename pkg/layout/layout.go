@@ -0,0 +1,232 @@
+// Package layout
+// 地图排版：在 canvas.Canvas 上组合插值图片、标题、图例、比例尺、指北针与数据来源
+
+package layout
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/lvisei/go-kriging/canvas"
+	"github.com/lvisei/go-kriging/pkg/palette"
+	"github.com/lvisei/go-kriging/pkg/worldfile"
+)
+
+// metersPerDegree 赤道上一度经度的长度
+const metersPerDegree = 111320.0
+
+// Layout 地图排版
+// 标题与副标题在上方，图例在右侧，数据来源在右下角，地图按 xlim、ylim 的宽高比放在剩余区域的中间
+type Layout struct {
+	Width      int
+	Height     int
+	Margin     float64     // 页边距，0 时为 20
+	Background color.Color // 背景色，nil 时为白色
+
+	Title    string
+	Subtitle string
+	Credits  string // 数据来源
+	// FontPath TrueType 字体文件，为空时使用内置的 7x13 点阵字体，字号不生效
+	FontPath  string
+	TitleSize float64 // 0 时为 24
+	TextSize  float64 // 0 时为 12
+
+	// Palette 图例的颜色，分级的 palette 绘制为色块，连续的绘制为色条
+	Palette     *palette.Palette
+	Legend      bool
+	LegendTitle string
+
+	ScaleBar bool
+	// Geographic 坐标为经纬度，比例尺按地图中心的纬度换算为米；否则坐标单位为米
+	Geographic bool
+	NorthArrow bool
+}
+
+// box 排版的结果
+type box struct {
+	frame        image.Rectangle // 地图在画布中的位置
+	titleY       float64
+	subtitleY    float64
+	legendX      float64
+	legendY      float64
+	legendLabels []string
+}
+
+func (l *Layout) margin() float64 {
+	if l.Margin <= 0 {
+		return 20
+	}
+	return l.Margin
+}
+
+func (l *Layout) text(text string, size float64, c color.Color) *canvas.TextConfig {
+	if size <= 0 {
+		size = 12
+	}
+	return &canvas.TextConfig{Text: text, FontName: l.FontPath, FontSize: size, Color: c}
+}
+
+func (l *Layout) titleSize() float64 {
+	if l.TitleSize <= 0 {
+		return 24
+	}
+	return l.TitleSize
+}
+
+func (l *Layout) textSize() float64 {
+	if l.TextSize <= 0 {
+		return 12
+	}
+	return l.TextSize
+}
+
+// lineHeight 一行文字的高度
+func (l *Layout) lineHeight(size float64) float64 {
+	if l.FontPath == "" {
+		return 13
+	}
+	return size
+}
+
+// Frame 地图图片在画布中的位置，插值图片应按此大小绘制
+func (l *Layout) Frame(xlim, ylim [2]float64) (image.Rectangle, error) {
+	b, err := l.layout(canvas.NewCanvas(1, 1), xlim, ylim)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	return b.frame, nil
+}
+
+func (l *Layout) layout(ctx *canvas.Canvas, xlim, ylim [2]float64) (*box, error) {
+	if l.Width <= 0 || l.Height <= 0 {
+		return nil, errors.New("layout: invalid size")
+	}
+	if !(xlim[1] > xlim[0]) || !(ylim[1] > ylim[0]) {
+		return nil, errors.New("layout: empty map extent")
+	}
+	margin := l.margin()
+	b := &box{}
+	left, top := margin, margin
+	right, bottom := float64(l.Width)-margin, float64(l.Height)-margin
+
+	if l.Title != "" {
+		b.titleY = top + l.lineHeight(l.titleSize())/2
+		top += l.lineHeight(l.titleSize()) * 1.5
+	}
+	if l.Subtitle != "" {
+		b.subtitleY = top + l.lineHeight(l.textSize())/2
+		top += l.lineHeight(l.textSize()) * 1.8
+	}
+	if l.Credits != "" {
+		bottom -= l.lineHeight(l.textSize()) * 1.5
+	}
+	if l.Legend && l.Palette != nil {
+		b.legendLabels = legendLabels(l.Palette)
+		width := ctx.MeasureString(l.text(l.LegendTitle, l.textSize(), nil))
+		for _, label := range b.legendLabels {
+			width = math.Max(width, legendSwatch+legendGap+ctx.MeasureString(l.text(label, l.textSize(), nil)))
+		}
+		right -= width + margin
+		b.legendX, b.legendY = right+margin, top
+	}
+	if right-left < 1 || bottom-top < 1 {
+		return nil, errors.New("layout: size too small")
+	}
+
+	scale := math.Min((right-left)/(xlim[1]-xlim[0]), (bottom-top)/(ylim[1]-ylim[0]))
+	width := int(math.Round(scale * (xlim[1] - xlim[0])))
+	height := int(math.Round(scale * (ylim[1] - ylim[0])))
+	x0 := int(math.Round(left + (right-left-float64(width))/2))
+	y0 := int(math.Round(top + (bottom-top-float64(height))/2))
+	b.frame = image.Rect(x0, y0, x0+width, y0+height)
+	return b, nil
+}
+
+// WorldFile Compose 输出图片的地理参考
+func (l *Layout) WorldFile(xlim, ylim [2]float64) (*worldfile.WorldFile, error) {
+	frame, err := l.Frame(xlim, ylim)
+	if err != nil {
+		return nil, err
+	}
+	xResolution := (xlim[1] - xlim[0]) / float64(frame.Dx())
+	yResolution := (ylim[1] - ylim[0]) / float64(frame.Dy())
+	return &worldfile.WorldFile{
+		A: xResolution,
+		E: -yResolution,
+		C: xlim[0] - float64(frame.Min.X)*xResolution + xResolution/2,
+		F: ylim[1] + float64(frame.Min.Y)*yResolution - yResolution/2,
+	}, nil
+}
+
+// Compose 组合地图，mapImage 为按 Frame 大小绘制的 xlim、ylim 范围的插值图片，大小不同时按左上角对齐
+func (l *Layout) Compose(mapImage image.Image, xlim, ylim [2]float64) (*canvas.Canvas, error) {
+	ctx := canvas.NewCanvas(l.Width, l.Height)
+	b, err := l.layout(ctx, xlim, ylim)
+	if err != nil {
+		return nil, err
+	}
+	var background color.Color = color.White
+	if l.Background != nil {
+		background = l.Background
+	}
+	ctx.DrawRect(0, 0, float64(l.Width), float64(l.Height), background)
+
+	frame := b.frame
+	if mapImage != nil {
+		ctx.DrawImage(mapImage, frame.Min.X, frame.Min.Y)
+	}
+	outline := [][2]float64{
+		{float64(frame.Min.X), float64(frame.Min.Y)}, {float64(frame.Max.X), float64(frame.Min.Y)},
+		{float64(frame.Max.X), float64(frame.Max.Y)}, {float64(frame.Min.X), float64(frame.Max.Y)},
+		{float64(frame.Min.X), float64(frame.Min.Y)},
+	}
+	ctx.DrawPath(outline, color.Gray{Y: 64}, 1)
+
+	center := float64(l.Width) / 2
+	if l.Title != "" {
+		if err := l.drawText(ctx, l.Title, l.titleSize(), color.Black, center, b.titleY, 0.5, 0.5); err != nil {
+			return nil, err
+		}
+	}
+	if l.Subtitle != "" {
+		if err := l.drawText(ctx, l.Subtitle, l.textSize(), color.Gray{Y: 80}, center, b.subtitleY, 0.5, 0.5); err != nil {
+			return nil, err
+		}
+	}
+	if l.Credits != "" {
+		y := float64(l.Height) - l.margin() - l.lineHeight(l.textSize())/2
+		if err := l.drawText(ctx, l.Credits, l.textSize(), color.Gray{Y: 100}, float64(l.Width)-l.margin(), y, 1, 0.5); err != nil {
+			return nil, err
+		}
+	}
+	if l.Legend && l.Palette != nil {
+		if err := l.drawLegend(ctx, b); err != nil {
+			return nil, err
+		}
+	}
+	if l.ScaleBar {
+		if err := l.drawScaleBar(ctx, frame, xlim, ylim); err != nil {
+			return nil, err
+		}
+	}
+	if l.NorthArrow {
+		if err := l.drawNorthArrow(ctx, frame); err != nil {
+			return nil, err
+		}
+	}
+	return ctx, nil
+}
+
+func (l *Layout) drawText(ctx *canvas.Canvas, text string, size float64, c color.Color, x, y, alignX, alignY float64) error {
+	opt := l.text(text, size, c)
+	opt.OffsetX, opt.OffsetY, opt.AlignX, opt.AlignY = x, y, alignX, alignY
+	return ctx.DrawText(opt)
+}
+
+// formatValue 图例数值，最多四位有效数字
+func formatValue(v float64) string {
+	return fmt.Sprintf("%.4g", v)
+}
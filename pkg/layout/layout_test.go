@@ -0,0 +1,69 @@
+package layout
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/lvisei/go-kriging/pkg/palette"
+)
+
+func TestLayout(t *testing.T) {
+	l := &Layout{
+		Width:      600,
+		Height:     400,
+		Title:      "Title",
+		Credits:    "Data",
+		Palette:    palette.NewColors([]color.Color{color.Black, color.White}, []float64{0, 1, 2}),
+		Legend:     true,
+		ScaleBar:   true,
+		Geographic: true,
+		NorthArrow: true,
+	}
+	xlim, ylim := [2]float64{100, 104}, [2]float64{20, 22}
+	frame, err := l.Frame(xlim, ylim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 地图保持宽高比，留出标题、图例与数据来源的位置
+	if math.Abs(float64(frame.Dx())/float64(frame.Dy())-2) > 0.02 {
+		t.Fatalf("frame %v does not keep the aspect ratio", frame)
+	}
+	if frame.Min.Y <= 20 || frame.Max.Y >= 380 || frame.Max.X >= 540 {
+		t.Fatalf("frame %v overlaps the title, credits or legend", frame)
+	}
+
+	// 地图图片左上角像元中心的坐标
+	wf, err := l.WorldFile(xlim, ylim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y := wf.Coordinates(float64(frame.Min.X)-0.5, float64(frame.Min.Y)-0.5)
+	if math.Abs(x-xlim[0]) > 1e-9 || math.Abs(y-ylim[1]) > 1e-9 {
+		t.Fatalf("world file maps the frame corner to %v, %v", x, y)
+	}
+
+	red := image.NewUniform(color.RGBA{R: 255, A: 255})
+	mapImage := image.NewRGBA(image.Rect(0, 0, frame.Dx(), frame.Dy()))
+	for i := range mapImage.Pix {
+		mapImage.Pix[i] = 255
+	}
+	mapImage.Set(frame.Dx()/2, frame.Dy()/2, red.C)
+	ctx, err := l.Compose(mapImage, xlim, ylim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, g, _, _ := ctx.Image().At(frame.Min.X+frame.Dx()/2, frame.Min.Y+frame.Dy()/2).RGBA()
+	if r>>8 != 255 || g != 0 {
+		t.Fatal("map image is not drawn at the frame")
+	}
+}
+
+func TestNiceLength(t *testing.T) {
+	for _, c := range [][2]float64{{1, 1}, {180, 100}, {260, 200}, {99000, 50000}, {0.3, 0.2}} {
+		if got := niceLength(c[0]); got != c[1] {
+			t.Fatalf("nice length of %v is %v, expected %v", c[0], got, c[1])
+		}
+	}
+}
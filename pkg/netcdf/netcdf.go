@@ -0,0 +1,539 @@
+// Package netcdf
+// 纯 Go 实现的 NetCDF-3（64 位偏移格式）写入，按 CF 约定保存多时刻、多变量的插值栅格
+
+package netcdf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/raster"
+)
+
+// NetCDF 数据类型
+const (
+	ncChar   = 2
+	ncInt    = 4
+	ncFloat  = 5
+	ncDouble = 6
+)
+
+// 头部各列表的标记
+const (
+	ncDimension = 0x0a
+	ncVariable  = 0x0b
+	ncAttribute = 0x0c
+)
+
+// DataType 栅格变量的数据类型
+type DataType int
+
+const (
+	Float64 DataType = iota
+	Float32
+)
+
+// Conventions CF 约定版本
+const Conventions = "CF-1.8"
+
+// DefaultEPSG 默认坐标参考系 WGS 84
+const DefaultEPSG = 4326
+
+// Variable 一个变量在各时刻的栅格
+type Variable struct {
+	Name         string
+	LongName     string
+	StandardName string // CF standard_name，如 air_temperature
+	Units        string
+	Rasters      []*raster.Raster // 与 Dataset.Times 一一对应，格网须一致
+	// Variograms 为 1 个时记录为变量属性，与 Dataset.Times 一一对应时
+	// 模型记录为属性，参数记录为以 time 为维度的 <name>_nugget、<name>_range、<name>_sill 变量
+	Variograms []*ordinarykriging.Variogram
+}
+
+// Dataset 多时刻、多变量的栅格集合
+type Dataset struct {
+	Times     []time.Time
+	Variables []*Variable
+}
+
+// Options 写入参数
+type Options struct {
+	EPSG        int           // 坐标参考系 EPSG 代码，默认 4326；地理坐标系的坐标变量为 lon、lat，否则为 x、y
+	CRSWKT      string        // 坐标参考系的 OGC WKT，写为 crs 变量的 crs_wkt 与 spatial_ref；为空时使用内置的定义，投影坐标系没有内置定义时返回错误
+	DataType    DataType      // 栅格变量的数据类型，默认 Float64
+	TimeUnit    time.Duration // 时间单位，支持秒、分、时、天，默认小时
+	Title       string
+	Institution string
+	Source      string
+	History     string
+}
+
+func (opt *Options) withDefaults() Options {
+	o := Options{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.EPSG <= 0 {
+		o.EPSG = DefaultEPSG
+	}
+	if o.TimeUnit <= 0 {
+		o.TimeUnit = time.Hour
+	}
+	return o
+}
+
+// timeUnits CF 时间单位
+func timeUnits(unit time.Duration) (string, error) {
+	var name string
+	switch unit {
+	case time.Second:
+		name = "seconds"
+	case time.Minute:
+		name = "minutes"
+	case time.Hour:
+		name = "hours"
+	case 24 * time.Hour:
+		name = "days"
+	default:
+		return "", fmt.Errorf("netcdf: unsupported time unit %v", unit)
+	}
+	return name + " since 1970-01-01 00:00:00", nil
+}
+
+// dimension 维度，记录维度的长度为 0
+type dimension struct {
+	name   string
+	length int
+}
+
+// attribute 属性，value 为 string、int32、float32、float64
+type attribute struct {
+	name  string
+	value interface{}
+}
+
+// variable 头部中的变量定义
+type variable struct {
+	name       string
+	dimensions []int
+	attributes []attribute
+	typ        int32
+	record     bool
+	// values 非记录变量为全部值，记录变量为第 i 条记录的值
+	values func(record int) []float64
+	size   int // 非记录变量为全部值个数，记录变量为一条记录的值个数
+	begin  int64
+}
+
+func (v *variable) vsize() int64 {
+	return padding(int64(v.size) * typeSize(v.typ))
+}
+
+func typeSize(typ int32) int64 {
+	switch typ {
+	case ncChar:
+		return 1
+	case ncInt, ncFloat:
+		return 4
+	}
+	return 8
+}
+
+func padding(n int64) int64 {
+	return (n + 3) / 4 * 4
+}
+
+// Encode 按 CF 约定写出 NetCDF-3 64 位偏移格式文件，time 为记录维度
+func Encode(w io.Writer, dataset *Dataset, opt *Options) error {
+	o := opt.withDefaults()
+	units, err := timeUnits(o.TimeUnit)
+	if err != nil {
+		return err
+	}
+	grid, err := validate(dataset)
+	if err != nil {
+		return err
+	}
+
+	geographic := raster.IsGeographicEPSG(o.EPSG)
+	wkt := o.CRSWKT
+	if wkt == "" {
+		wkt = builtinWKT(o.EPSG)
+	}
+	if wkt == "" && !geographic {
+		return fmt.Errorf("netcdf: no WKT for projected EPSG:%d, set Options.CRSWKT", o.EPSG)
+	}
+	xName, yName := "x", "y"
+	xAttributes := []attribute{{"standard_name", "projection_x_coordinate"}, {"long_name", "x coordinate of projection"}, {"units", "m"}, {"axis", "X"}}
+	yAttributes := []attribute{{"standard_name", "projection_y_coordinate"}, {"long_name", "y coordinate of projection"}, {"units", "m"}, {"axis", "Y"}}
+	crsAttributes := []attribute{{"epsg_code", fmt.Sprintf("EPSG:%d", o.EPSG)}}
+	if geographic {
+		xName, yName = "lon", "lat"
+		xAttributes = []attribute{{"standard_name", "longitude"}, {"long_name", "longitude"}, {"units", "degrees_east"}, {"axis", "X"}}
+		yAttributes = []attribute{{"standard_name", "latitude"}, {"long_name", "latitude"}, {"units", "degrees_north"}, {"axis", "Y"}}
+		crsAttributes = append([]attribute{{"grid_mapping_name", "latitude_longitude"}}, crsAttributes...)
+	}
+	if wkt != "" {
+		// crs_wkt 为 CF 的属性，spatial_ref 供 GDAL 读取
+		crsAttributes = append(crsAttributes, attribute{"crs_wkt", wkt}, attribute{"spatial_ref", wkt})
+	}
+
+	// 维度：time（记录维度）、y、x
+	const timeDim, yDim, xDim = 0, 1, 2
+	dimensions := []dimension{{"time", 0}, {yName, grid.Height}, {xName, grid.Width}}
+
+	xs := make([]float64, grid.Width)
+	for col := range xs {
+		xs[col], _ = grid.CellCenter(col, 0)
+	}
+	ys := make([]float64, grid.Height)
+	for row := range ys {
+		_, ys[row] = grid.CellCenter(0, row)
+	}
+
+	variables := []*variable{
+		{name: "crs", attributes: crsAttributes, typ: ncInt, size: 1, values: func(int) []float64 { return []float64{0} }},
+		{name: xName, dimensions: []int{xDim}, attributes: xAttributes, typ: ncDouble, size: len(xs), values: func(int) []float64 { return xs }},
+		{name: yName, dimensions: []int{yDim}, attributes: yAttributes, typ: ncDouble, size: len(ys), values: func(int) []float64 { return ys }},
+		{
+			name:       "time",
+			dimensions: []int{timeDim},
+			attributes: []attribute{{"standard_name", "time"}, {"long_name", "time"}, {"units", units}, {"calendar", "standard"}, {"axis", "T"}},
+			typ:        ncDouble,
+			record:     true,
+			size:       1,
+			values: func(record int) []float64 {
+				return []float64{float64(dataset.Times[record].Sub(time.Unix(0, 0))) / float64(o.TimeUnit)}
+			},
+		},
+	}
+
+	dataType := int32(ncDouble)
+	if o.DataType == Float32 {
+		dataType = ncFloat
+	}
+	for _, v := range dataset.Variables {
+		variables = append(variables, dataVariables(v, dataType, len(dataset.Times), grid.Width*grid.Height, timeDim, yDim, xDim)...)
+	}
+
+	globalAttributes := []attribute{{"Conventions", Conventions}}
+	for _, a := range []attribute{{"title", o.Title}, {"institution", o.Institution}, {"source", o.Source}, {"history", o.History}} {
+		if a.value != "" {
+			globalAttributes = append(globalAttributes, a)
+		}
+	}
+
+	// 先以 0 偏移计算头部长度，再确定各变量的起始位置
+	var header writer
+	header.header(dimensions, globalAttributes, variables, len(dataset.Times))
+	begin := int64(header.Len())
+	for _, v := range variables {
+		if !v.record {
+			v.begin = begin
+			begin += v.vsize()
+		}
+	}
+	for _, v := range variables {
+		if v.record {
+			v.begin = begin
+			begin += v.vsize()
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	out := writer{w: bw}
+	out.header(dimensions, globalAttributes, variables, len(dataset.Times))
+	for _, v := range variables {
+		if !v.record {
+			out.values(v.typ, v.values(0))
+		}
+	}
+	for record := range dataset.Times {
+		for _, v := range variables {
+			if v.record {
+				out.values(v.typ, v.values(record))
+			}
+		}
+	}
+	if out.err != nil {
+		return out.err
+	}
+	return bw.Flush()
+}
+
+// validate 检查各栅格的格网是否一致，返回第一个栅格
+func validate(dataset *Dataset) (*raster.Raster, error) {
+	if dataset == nil || len(dataset.Times) == 0 || len(dataset.Variables) == 0 {
+		return nil, errors.New("netcdf: empty dataset")
+	}
+	var grid *raster.Raster
+	names := map[string]bool{"crs": true, "time": true, "x": true, "y": true, "lon": true, "lat": true}
+	for _, v := range dataset.Variables {
+		if v.Name == "" || names[v.Name] {
+			return nil, fmt.Errorf("netcdf: invalid or duplicate variable name %q", v.Name)
+		}
+		names[v.Name] = true
+		if len(v.Rasters) != len(dataset.Times) {
+			return nil, fmt.Errorf("netcdf: variable %s has %d rasters for %d times", v.Name, len(v.Rasters), len(dataset.Times))
+		}
+		if n := len(v.Variograms); n != 0 && n != 1 && n != len(dataset.Times) {
+			return nil, fmt.Errorf("netcdf: variable %s has %d variograms for %d times", v.Name, n, len(dataset.Times))
+		}
+		for _, r := range v.Rasters {
+			if r == nil || r.Width <= 0 || r.Height <= 0 || len(r.Data) != r.Width*r.Height {
+				return nil, fmt.Errorf("netcdf: variable %s has an invalid raster", v.Name)
+			}
+			if grid == nil {
+				grid = r
+				continue
+			}
+			if r.Width != grid.Width || r.Height != grid.Height || r.X0 != grid.X0 || r.Y0 != grid.Y0 ||
+				r.XResolution != grid.XResolution || r.YResolution != grid.YResolution {
+				return nil, fmt.Errorf("netcdf: variable %s is not on the same grid", v.Name)
+			}
+		}
+	}
+	return grid, nil
+}
+
+// dataVariables 栅格变量及其变异函数参数变量
+func dataVariables(v *Variable, dataType int32, times, size, timeDim, yDim, xDim int) []*variable {
+	attributes := []attribute{}
+	for _, a := range []attribute{{"long_name", v.LongName}, {"standard_name", v.StandardName}, {"units", v.Units}} {
+		if a.value != "" {
+			attributes = append(attributes, a)
+		}
+	}
+	attributes = append(attributes, attribute{"grid_mapping", "crs"})
+
+	// 无数据值取第一个有无数据值的栅格，其它栅格的无数据值与 NaN 写为该值
+	fill, hasFill := 0.0, false
+	for _, r := range v.Rasters {
+		if r.HasNodata && !math.IsNaN(r.NodataValue) {
+			fill, hasFill = r.NodataValue, true
+			break
+		}
+	}
+	if hasFill {
+		// _FillValue 的类型须与变量一致
+		if dataType == ncFloat {
+			attributes = append(attributes, attribute{"_FillValue", float32(fill)})
+		} else {
+			attributes = append(attributes, attribute{"_FillValue", fill})
+		}
+	}
+
+	var parameters []*variable
+	switch {
+	case len(v.Variograms) == 1 && v.Variograms[0] != nil:
+		variogram := v.Variograms[0]
+		attributes = append(attributes,
+			attribute{"variogram_model", string(variogram.Model)},
+			attribute{"variogram_nugget", variogram.Nugget},
+			attribute{"variogram_range", variogram.Range},
+			attribute{"variogram_sill", variogram.Sill},
+			attribute{"variogram_a", variogram.A},
+			attribute{"variogram_n", int32(variogram.N)},
+		)
+	case len(v.Variograms) == times:
+		models := map[string]bool{}
+		for _, variogram := range v.Variograms {
+			if variogram != nil {
+				models[string(variogram.Model)] = true
+			}
+		}
+		names := make([]string, 0, len(models))
+		for model := range models {
+			names = append(names, model)
+		}
+		sort.Strings(names)
+		attributes = append(attributes, attribute{"variogram_model", strings.Join(names, " ")})
+
+		for _, p := range []struct {
+			name  string
+			value func(*ordinarykriging.Variogram) float64
+		}{
+			{"nugget", func(variogram *ordinarykriging.Variogram) float64 { return variogram.Nugget }},
+			{"range", func(variogram *ordinarykriging.Variogram) float64 { return variogram.Range }},
+			{"sill", func(variogram *ordinarykriging.Variogram) float64 { return variogram.Sill }},
+		} {
+			p := p
+			parameters = append(parameters, &variable{
+				name:       v.Name + "_" + p.name,
+				dimensions: []int{timeDim},
+				attributes: []attribute{{"long_name", fmt.Sprintf("variogram %s of %s", p.name, v.Name)}, {"_FillValue", math.NaN()}},
+				typ:        ncDouble,
+				record:     true,
+				size:       1,
+				values: func(record int) []float64 {
+					if variogram := v.Variograms[record]; variogram != nil {
+						return []float64{p.value(variogram)}
+					}
+					return []float64{math.NaN()}
+				},
+			})
+		}
+	}
+
+	data := &variable{
+		name:       v.Name,
+		dimensions: []int{timeDim, yDim, xDim},
+		attributes: attributes,
+		typ:        dataType,
+		record:     true,
+		size:       size,
+		values: func(record int) []float64 {
+			r := v.Rasters[record]
+			if !hasFill && !r.HasNodata {
+				return r.Data
+			}
+			values := make([]float64, len(r.Data))
+			for i, value := range r.Data {
+				if r.IsNodata(value) {
+					value = math.NaN()
+					if hasFill {
+						value = fill
+					}
+				}
+				values[i] = value
+			}
+			return values
+		},
+	}
+	return append([]*variable{data}, parameters...)
+}
+
+// writer 大端序写入，记录第一个错误
+type writer struct {
+	w   io.Writer
+	n   int
+	err error
+	buf []byte
+}
+
+func (w *writer) Len() int {
+	return w.n
+}
+
+func (w *writer) write(b []byte) {
+	w.n += len(b)
+	if w.err != nil || w.w == nil {
+		return
+	}
+	_, w.err = w.w.Write(b)
+}
+
+func (w *writer) int32(v int32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(v))
+	w.write(b[:])
+}
+
+func (w *writer) int64(v int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(v))
+	w.write(b[:])
+}
+
+func (w *writer) pad(n int64) {
+	w.write(make([]byte, padding(n)-n))
+}
+
+func (w *writer) name(name string) {
+	w.int32(int32(len(name)))
+	w.write([]byte(name))
+	w.pad(int64(len(name)))
+}
+
+// header 写出头部，w.w 为 nil 时只计算长度
+func (w *writer) header(dimensions []dimension, globalAttributes []attribute, variables []*variable, numrecs int) {
+	w.write([]byte{'C', 'D', 'F', 2})
+	w.int32(int32(numrecs))
+
+	w.int32(ncDimension)
+	w.int32(int32(len(dimensions)))
+	for _, d := range dimensions {
+		w.name(d.name)
+		w.int32(int32(d.length))
+	}
+
+	w.attributes(globalAttributes)
+
+	w.int32(ncVariable)
+	w.int32(int32(len(variables)))
+	for _, v := range variables {
+		w.name(v.name)
+		w.int32(int32(len(v.dimensions)))
+		for _, id := range v.dimensions {
+			w.int32(int32(id))
+		}
+		w.attributes(v.attributes)
+		w.int32(v.typ)
+		w.int32(int32(v.vsize()))
+		w.int64(v.begin)
+	}
+}
+
+func (w *writer) attributes(attributes []attribute) {
+	if len(attributes) == 0 {
+		w.int64(0)
+		return
+	}
+	w.int32(ncAttribute)
+	w.int32(int32(len(attributes)))
+	for _, a := range attributes {
+		w.name(a.name)
+		switch v := a.value.(type) {
+		case string:
+			w.int32(ncChar)
+			w.int32(int32(len(v)))
+			w.write([]byte(v))
+			w.pad(int64(len(v)))
+		case int32:
+			w.int32(ncInt)
+			w.int32(1)
+			w.int32(v)
+		case float32:
+			w.int32(ncFloat)
+			w.int32(1)
+			w.values(ncFloat, []float64{float64(v)})
+		case float64:
+			w.int32(ncDouble)
+			w.int32(1)
+			w.values(ncDouble, []float64{v})
+		}
+	}
+}
+
+// values 按类型写出数值并补齐 4 字节
+func (w *writer) values(typ int32, values []float64) {
+	size := typeSize(typ)
+	n := int64(len(values)) * size
+	if cap(w.buf) < int(padding(n)) {
+		w.buf = make([]byte, padding(n))
+	}
+	b := w.buf[:padding(n)]
+	for i, v := range values {
+		switch typ {
+		case ncInt:
+			binary.BigEndian.PutUint32(b[4*i:], uint32(int32(v)))
+		case ncFloat:
+			binary.BigEndian.PutUint32(b[4*i:], math.Float32bits(float32(v)))
+		default:
+			binary.BigEndian.PutUint64(b[8*i:], math.Float64bits(v))
+		}
+	}
+	for i := n; i < int64(len(b)); i++ {
+		b[i] = 0
+	}
+	w.write(b)
+}
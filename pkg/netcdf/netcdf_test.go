@@ -0,0 +1,193 @@
+package netcdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lvisei/go-kriging/pkg/raster"
+)
+
+// decoded 测试用的 NetCDF-3 64 位偏移格式头部解析结果
+type decoded struct {
+	numrecs    int
+	dimensions []dimension
+	attributes map[string]interface{}
+	variables  map[string]*decodedVariable
+	recsize    int64
+}
+
+type decodedVariable struct {
+	dimensions []int
+	attributes map[string]interface{}
+	typ        int32
+	vsize      int64
+	begin      int64
+}
+
+type reader struct {
+	b   []byte
+	off int
+}
+
+func (r *reader) int32() int32 {
+	v := int32(binary.BigEndian.Uint32(r.b[r.off:]))
+	r.off += 4
+	return v
+}
+
+func (r *reader) name() string {
+	n := int(r.int32())
+	s := string(r.b[r.off : r.off+n])
+	r.off += int(padding(int64(n)))
+	return s
+}
+
+func (r *reader) attributes() map[string]interface{} {
+	attributes := map[string]interface{}{}
+	tag, n := r.int32(), int(r.int32())
+	if tag == 0 {
+		return attributes
+	}
+	for i := 0; i < n; i++ {
+		name := r.name()
+		typ, count := r.int32(), int64(r.int32())
+		data := r.b[r.off : r.off+int(count*typeSize(typ))]
+		switch typ {
+		case ncChar:
+			attributes[name] = string(data)
+		case ncInt:
+			attributes[name] = int32(binary.BigEndian.Uint32(data))
+		case ncFloat:
+			attributes[name] = math.Float32frombits(binary.BigEndian.Uint32(data))
+		case ncDouble:
+			attributes[name] = math.Float64frombits(binary.BigEndian.Uint64(data))
+		}
+		r.off += int(padding(count * typeSize(typ)))
+	}
+	return attributes
+}
+
+func decode(t *testing.T, b []byte) *decoded {
+	if string(b[:4]) != "CDF\x02" {
+		t.Fatalf("unexpected magic %q", b[:4])
+	}
+	r := &reader{b: b, off: 4}
+	d := &decoded{numrecs: int(r.int32()), variables: map[string]*decodedVariable{}}
+	if r.int32() != ncDimension {
+		t.Fatal("missing dimension list")
+	}
+	for i, n := 0, int(r.int32()); i < n; i++ {
+		d.dimensions = append(d.dimensions, dimension{r.name(), int(r.int32())})
+	}
+	d.attributes = r.attributes()
+	if r.int32() != ncVariable {
+		t.Fatal("missing variable list")
+	}
+	for i, n := 0, int(r.int32()); i < n; i++ {
+		name := r.name()
+		v := &decodedVariable{}
+		for j, m := 0, int(r.int32()); j < m; j++ {
+			v.dimensions = append(v.dimensions, int(r.int32()))
+		}
+		v.attributes = r.attributes()
+		v.typ = r.int32()
+		v.vsize = int64(r.int32())
+		v.begin = int64(binary.BigEndian.Uint64(b[r.off:]))
+		r.off += 8
+		if len(v.dimensions) > 0 && d.dimensions[v.dimensions[0]].length == 0 {
+			d.recsize += v.vsize
+		}
+		d.variables[name] = v
+	}
+	return d
+}
+
+// value 记录变量第 record 条记录的第 i 个值
+func (d *decoded) value(b []byte, name string, record, i int) float64 {
+	v := d.variables[name]
+	offset := v.begin + int64(record)*d.recsize + int64(i)*typeSize(v.typ)
+	if v.typ == ncFloat {
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b[offset:])))
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(b[offset:]))
+}
+
+func TestEncode(t *testing.T) {
+	times := []time.Time{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC)}
+	var rasters []*raster.Raster
+	for k := range times {
+		r := raster.New(3, 2, 100, 30, 0.5, 0.5)
+		r.HasNodata = true
+		r.NodataValue = -9999
+		for i := range r.Data {
+			r.Data[i] = float64(10*k + i)
+		}
+		r.Data[4] = math.NaN()
+		rasters = append(rasters, r)
+	}
+
+	var buf bytes.Buffer
+	dataset := &Dataset{Times: times, Variables: []*Variable{{Name: "tem", Units: "degC", StandardName: "air_temperature", Rasters: rasters}}}
+	if err := Encode(&buf, dataset, &Options{DataType: Float32, Title: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	d := decode(t, b)
+
+	if d.numrecs != 2 || len(d.dimensions) != 3 || d.dimensions[1] != (dimension{"lat", 2}) || d.dimensions[2] != (dimension{"lon", 3}) {
+		t.Fatalf("unexpected dimensions %d %v", d.numrecs, d.dimensions)
+	}
+	if d.attributes["Conventions"] != Conventions || d.attributes["title"] != "test" {
+		t.Fatalf("unexpected global attributes %v", d.attributes)
+	}
+	tem := d.variables["tem"]
+	if tem.typ != ncFloat || tem.attributes["_FillValue"] != float32(-9999) || tem.attributes["grid_mapping"] != "crs" {
+		t.Fatalf("unexpected variable %+v", tem)
+	}
+	if last := d.variables["time"].begin + d.recsize*int64(d.numrecs); last != int64(len(b)) {
+		t.Fatalf("file size %d, expected %d", len(b), last)
+	}
+
+	if v := d.value(b, "time", 1, 0); v != 18262*24+1 {
+		t.Fatalf("unexpected time %v", v)
+	}
+	lat := d.variables["lat"]
+	if y := math.Float64frombits(binary.BigEndian.Uint64(b[lat.begin:])); y != 29.75 {
+		t.Fatalf("unexpected lat %v", y)
+	}
+	if v := d.value(b, "tem", 1, 5); v != 15 {
+		t.Fatalf("unexpected value %v", v)
+	}
+	if v := d.value(b, "tem", 0, 4); v != -9999 {
+		t.Fatalf("unexpected fill %v", v)
+	}
+}
+
+func TestEncode_Projected(t *testing.T) {
+	r := raster.New(2, 2, 500000, 3000000, 1000, 1000)
+	dataset := &Dataset{Times: []time.Time{time.Unix(0, 0)}, Variables: []*Variable{{Name: "tem", Rasters: []*raster.Raster{r}}}}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, dataset, &Options{EPSG: 32650}); err != nil {
+		t.Fatal(err)
+	}
+	d := decode(t, buf.Bytes())
+	wkt, _ := d.variables["crs"].attributes["crs_wkt"].(string)
+	if !strings.HasPrefix(wkt, `PROJCS["WGS 84 / UTM zone 50N"`) || !strings.Contains(wkt, `PARAMETER["central_meridian",117]`) {
+		t.Fatalf("unexpected crs_wkt %q", wkt)
+	}
+	if d.variables["crs"].attributes["spatial_ref"] != wkt || d.dimensions[2].name != "x" {
+		t.Fatalf("unexpected crs %+v", d.variables["crs"])
+	}
+
+	if err := Encode(&buf, dataset, &Options{EPSG: 2385}); err == nil {
+		t.Fatal("expected error for projected EPSG without WKT")
+	}
+	if err := Encode(&buf, dataset, &Options{EPSG: 2385, CRSWKT: `PROJCS["Xian 1980 / 3-degree Gauss-Kruger CM 120E"]`}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,30 @@
+package netcdf
+
+import "fmt"
+
+const wgs84Datum = `DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563,AUTHORITY["EPSG","7030"]],AUTHORITY["EPSG","6326"]]`
+
+const wgs84WKT = `GEOGCS["WGS 84",` + wgs84Datum + `,PRIMEM["Greenwich",0,AUTHORITY["EPSG","8901"]],UNIT["degree",0.0174532925199433,AUTHORITY["EPSG","9122"]],AUTHORITY["EPSG","4326"]]`
+
+const webMercatorWKT = `PROJCS["WGS 84 / Pseudo-Mercator",` + wgs84WKT + `,PROJECTION["Mercator_1SP"],PARAMETER["central_meridian",0],PARAMETER["scale_factor",1],PARAMETER["false_easting",0],PARAMETER["false_northing",0],UNIT["metre",1,AUTHORITY["EPSG","9001"]],AXIS["Easting",EAST],AXIS["Northing",NORTH],EXTENSION["PROJ4","+proj=merc +a=6378137 +b=6378137 +lat_ts=0 +lon_0=0 +x_0=0 +y_0=0 +k=1 +units=m +nadgrids=@null +wktext +no_defs"],AUTHORITY["EPSG","3857"]]`
+
+// builtinWKT 内置的 OGC WKT：WGS 84、Web 墨卡托与 WGS 84 的 UTM 分带，其他代码返回空
+func builtinWKT(epsg int) string {
+	switch {
+	case epsg == 4326:
+		return wgs84WKT
+	case epsg == 3857:
+		return webMercatorWKT
+	case epsg >= 32601 && epsg <= 32660, epsg >= 32701 && epsg <= 32760:
+		zone, hemisphere, falseNorthing := epsg%100, "N", 0
+		if epsg > 32700 {
+			hemisphere, falseNorthing = "S", 10000000
+		}
+		return fmt.Sprintf(`PROJCS["WGS 84 / UTM zone %d%s",%s,PROJECTION["Transverse_Mercator"],`+
+			`PARAMETER["latitude_of_origin",0],PARAMETER["central_meridian",%d],PARAMETER["scale_factor",0.9996],`+
+			`PARAMETER["false_easting",500000],PARAMETER["false_northing",%d],UNIT["metre",1,AUTHORITY["EPSG","9001"]],`+
+			`AXIS["Easting",EAST],AXIS["Northing",NORTH],AUTHORITY["EPSG","%d"]]`,
+			zone, hemisphere, wgs84WKT, 6*zone-183, falseNorthing, epsg)
+	}
+	return ""
+}
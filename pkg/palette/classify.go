@@ -0,0 +1,148 @@
+package palette
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Method 分级方法
+type Method string
+
+const (
+	EqualInterval Method = "equal-interval"
+	Quantile      Method = "quantile"
+	Jenks         Method = "jenks"
+)
+
+// jenksSampleSize 自然断点法的最大样本量，超过时按分位数抽样
+const jenksSampleSize = 1000
+
+// Classify 将 values 分为 n 级，返回包含最小值与最大值的分界值，NaN 被忽略
+// 分位数分级遇到大量相同的值时分界值会重合，重合的分界值被合并，级数少于 n
+func Classify(method Method, values []float64, n int) ([]float64, error) {
+	if n < 1 {
+		return nil, errors.New("palette: at least 1 class is required")
+	}
+	sorted := make([]float64, 0, len(values))
+	for _, v := range values {
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			sorted = append(sorted, v)
+		}
+	}
+	if len(sorted) == 0 {
+		return nil, errors.New("palette: no finite values")
+	}
+	sort.Float64s(sorted)
+
+	var breaks []float64
+	switch method {
+	case EqualInterval, "":
+		breaks = EqualIntervalBreaks([2]float64{sorted[0], sorted[len(sorted)-1]}, n)
+	case Quantile:
+		breaks = quantileBreaks(sorted, n)
+	case Jenks:
+		breaks = jenksBreaks(sorted, n)
+	default:
+		return nil, fmt.Errorf("palette: unknown classification %q", method)
+	}
+	return unique(breaks), nil
+}
+
+// EqualIntervalBreaks 将 zlim 等分为 n 级
+func EqualIntervalBreaks(zlim [2]float64, n int) []float64 {
+	breaks := make([]float64, n+1)
+	for i := range breaks {
+		breaks[i] = zlim[0] + float64(i)*(zlim[1]-zlim[0])/float64(n)
+	}
+	breaks[n] = zlim[1]
+	return breaks
+}
+
+// quantileBreaks 各级样本数相同的分界值，sorted 为升序
+func quantileBreaks(sorted []float64, n int) []float64 {
+	breaks := make([]float64, n+1)
+	for i := range breaks {
+		breaks[i] = quantile(sorted, float64(i)/float64(n))
+	}
+	return breaks
+}
+
+// quantile 线性插值的分位数
+func quantile(sorted []float64, p float64) float64 {
+	position := p * float64(len(sorted)-1)
+	i := int(math.Floor(position))
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (position-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// jenksBreaks Fisher-Jenks 自然断点，使各级内方差之和最小
+func jenksBreaks(sorted []float64, n int) []float64 {
+	data := sorted
+	if len(data) > jenksSampleSize {
+		data = make([]float64, jenksSampleSize)
+		for i := range data {
+			data[i] = quantile(sorted, float64(i)/float64(jenksSampleSize-1))
+		}
+	}
+	m := len(data)
+	if n > m {
+		n = m
+	}
+
+	// lower[i][k] 前 i 个值分为 k 级时最后一级的起始下标，cost[i][k] 为对应的最小方差和
+	lower := make([][]int, m+1)
+	cost := make([][]float64, m+1)
+	for i := range lower {
+		lower[i] = make([]int, n+1)
+		cost[i] = make([]float64, n+1)
+		for k := range cost[i] {
+			cost[i][k] = math.Inf(1)
+		}
+	}
+	cost[0][0] = 0
+	for i := 1; i <= m; i++ {
+		sum, sum2 := 0.0, 0.0
+		// 最后一级为 data[j-1 : i]
+		for j := i; j >= 1; j-- {
+			v := data[j-1]
+			sum += v
+			sum2 += v * v
+			count := float64(i - j + 1)
+			variance := sum2 - sum*sum/count
+			for k := 1; k <= n; k++ {
+				if c := cost[j-1][k-1] + variance; c < cost[i][k] {
+					cost[i][k] = c
+					lower[i][k] = j - 1
+				}
+			}
+		}
+	}
+
+	breaks := make([]float64, n+1)
+	breaks[n] = sorted[len(sorted)-1]
+	breaks[0] = sorted[0]
+	for i, k := m, n; k > 1; k-- {
+		i = lower[i][k]
+		breaks[k-1] = data[i]
+	}
+	return breaks
+}
+
+// unique 去掉重复的分界值
+func unique(breaks []float64) []float64 {
+	result := breaks[:1]
+	for _, v := range breaks[1:] {
+		if v > result[len(result)-1] {
+			result = append(result, v)
+		}
+	}
+	if len(result) == 1 {
+		// 全部值相同时保留一级
+		result = append(result, result[0])
+	}
+	return result
+}
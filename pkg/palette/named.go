@@ -0,0 +1,69 @@
+package palette
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 命名色带，matplotlib 的感知均匀色带与 ColorBrewer 色带
+var named = map[string][]string{
+	// default 与 ordinarykriging.DefaultLegendColor 相同
+	"default": {"#2892c7", "#60a3b5", "#8cb8a4", "#b1cc91", "#d7e37d", "#fafa64", "#fccf51", "#fca43f", "#f24d1f", "#e81014"},
+
+	"viridis": {"#440154", "#482878", "#3e4989", "#31688e", "#26828e", "#1f9e89", "#35b779", "#6ece58", "#b5de2b", "#fde725"},
+	"magma":   {"#000004", "#180f3d", "#440f76", "#721f81", "#9e2f7f", "#cd4071", "#f1605d", "#fd9668", "#feca8d", "#fcfdbf"},
+	"inferno": {"#000004", "#1b0c41", "#4a0c6b", "#781c6d", "#a52c60", "#cf4446", "#ed6925", "#fb9b06", "#f7d13d", "#fcffa4"},
+	"plasma":  {"#0d0887", "#46039f", "#7201a8", "#9c179e", "#bd3786", "#d8576b", "#ed7953", "#fb9f3a", "#fdca26", "#f0f921"},
+	"cividis": {"#00224e", "#123570", "#3b496c", "#575d6d", "#707173", "#8a8779", "#a69d75", "#c4b56c", "#e4cf5b", "#fee838"},
+
+	"RdYlBu":   {"#a50026", "#d73027", "#f46d43", "#fdae61", "#fee090", "#ffffbf", "#e0f3f8", "#abd9e9", "#74add1", "#4575b4", "#313695"},
+	"RdBu":     {"#67001f", "#b2182b", "#d6604d", "#f4a582", "#fddbc7", "#f7f7f7", "#d1e5f0", "#92c5de", "#4393c3", "#2166ac", "#053061"},
+	"Spectral": {"#9e0142", "#d53e4f", "#f46d43", "#fdae61", "#fee08b", "#ffffbf", "#e6f598", "#abdda4", "#66c2a5", "#3288bd", "#5e4fa2"},
+	"YlGnBu":   {"#ffffd9", "#edf8b1", "#c7e9b4", "#7fcdbb", "#41b6c4", "#1d91c0", "#225ea8", "#253494", "#081d58"},
+	"YlOrRd":   {"#ffffcc", "#ffeda0", "#fed976", "#feb24c", "#fd8d3c", "#fc4e2a", "#e31a1c", "#bd0026", "#800026"},
+	"Blues":    {"#f7fbff", "#deebf7", "#c6dbef", "#9ecae1", "#6baed6", "#4292c6", "#2171b5", "#08519c", "#08306b"},
+	"Greens":   {"#f7fcf5", "#e5f5e0", "#c7e9c0", "#a1d99b", "#74c476", "#41ab5d", "#238b45", "#006d2c", "#00441b"},
+	"Reds":     {"#fff5f0", "#fee0d2", "#fcbba1", "#fc9272", "#fb6a4a", "#ef3b2c", "#cb181d", "#a50f15", "#67000d"},
+}
+
+// viridis 未指定色带时的默认色带
+var viridis = mustNamed("viridis")
+
+// Named 命名色带，名称不区分大小写，加 _r 后缀为反向色带
+func Named(name string) (*Ramp, error) {
+	reverse := strings.HasSuffix(name, "_r")
+	base := strings.TrimSuffix(name, "_r")
+	for key, hexes := range named {
+		if !strings.EqualFold(key, base) {
+			continue
+		}
+		ramp, err := ParseRamp(strings.Join(hexes, ","))
+		if err != nil {
+			return nil, err
+		}
+		if reverse {
+			ramp = ramp.Reverse()
+		}
+		return ramp, nil
+	}
+	return nil, fmt.Errorf("palette: unknown ramp %q", name)
+}
+
+// Names 全部命名色带的名称
+func Names() []string {
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func mustNamed(name string) *Ramp {
+	ramp, err := Named(name)
+	if err != nil {
+		panic(err)
+	}
+	return ramp
+}
@@ -0,0 +1,69 @@
+package palette
+
+import (
+	"image/color"
+	"math"
+)
+
+// oklab Oklab 色彩空间中的颜色与不透明度
+type oklab struct {
+	l, a, b, alpha float64
+}
+
+// mix 在 Oklab 中按 t 混合两个颜色，不透明度线性插值
+func mix(c0, c1 color.Color, t float64) color.Color {
+	p, q := toOklab(c0), toOklab(c1)
+	return fromOklab(oklab{
+		l:     p.l + t*(q.l-p.l),
+		a:     p.a + t*(q.a-p.a),
+		b:     p.b + t*(q.b-p.b),
+		alpha: p.alpha + t*(q.alpha-p.alpha),
+	})
+}
+
+func toOklab(c color.Color) oklab {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	r, g, b := toLinear(n.R), toLinear(n.G), toLinear(n.B)
+
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+	return oklab{
+		l:     0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		a:     1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		b:     0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+		alpha: float64(n.A) / 255,
+	}
+}
+
+func fromOklab(c oklab) color.NRGBA {
+	l := c.l + 0.3963377774*c.a + 0.2158037573*c.b
+	m := c.l - 0.1055613458*c.a - 0.0638541728*c.b
+	s := c.l - 0.0894841775*c.a - 1.2914855480*c.b
+	l, m, s = l*l*l, m*m*m, s*s*s
+	return color.NRGBA{
+		R: fromLinear(4.0767416621*l - 3.3077115913*m + 0.2309699292*s),
+		G: fromLinear(-1.2684380046*l + 2.6097574011*m - 0.3413193965*s),
+		B: fromLinear(-0.0041960863*l - 0.7034186147*m + 1.7076147010*s),
+		A: uint8(math.Round(math.Max(0, math.Min(1, c.alpha)) * 255)),
+	}
+}
+
+// toLinear sRGB 分量转线性
+func toLinear(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// fromLinear 线性分量转 sRGB
+func fromLinear(c float64) uint8 {
+	if c <= 0.0031308 {
+		c *= 12.92
+	} else {
+		c = 1.055*math.Pow(c, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(math.Max(0, math.Min(1, c)) * 255))
+}
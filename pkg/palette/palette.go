@@ -0,0 +1,265 @@
+// Package palette
+// 色带与分级，值到颜色的映射，供各绘图函数使用
+
+package palette
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Stop 色带上的一个节点，Position 在 [0, 1] 内
+type Stop struct {
+	Position float64
+	Color    color.Color
+}
+
+// Ramp 连续色带，节点之间在 Oklab 感知均匀色彩空间中插值
+type Ramp struct {
+	Stops []Stop
+}
+
+// NewRamp 颜色等距分布的色带
+func NewRamp(colors ...color.Color) *Ramp {
+	ramp := &Ramp{Stops: make([]Stop, len(colors))}
+	for i, c := range colors {
+		position := 0.0
+		if len(colors) > 1 {
+			position = float64(i) / float64(len(colors)-1)
+		}
+		ramp.Stops[i] = Stop{Position: position, Color: c}
+	}
+	return ramp
+}
+
+// At 色带在 t 处的颜色，t 超出 [0, 1] 时取两端的颜色
+func (ramp *Ramp) At(t float64) color.Color {
+	stops := ramp.Stops
+	if len(stops) == 0 {
+		return color.Transparent
+	}
+	if math.IsNaN(t) || t <= stops[0].Position {
+		return stops[0].Color
+	}
+	if t >= stops[len(stops)-1].Position {
+		return stops[len(stops)-1].Color
+	}
+	i := sort.Search(len(stops), func(i int) bool { return stops[i].Position >= t })
+	a, b := stops[i-1], stops[i]
+	if b.Position == a.Position {
+		return b.Color
+	}
+	return mix(a.Color, b.Color, (t-a.Position)/(b.Position-a.Position))
+}
+
+// Colors 在色带上等距取 n 个颜色，包含两端
+func (ramp *Ramp) Colors(n int) []color.Color {
+	colors := make([]color.Color, n)
+	for i := range colors {
+		t := 0.5
+		if n > 1 {
+			t = float64(i) / float64(n-1)
+		}
+		colors[i] = ramp.At(t)
+	}
+	return colors
+}
+
+// Reverse 反向的色带
+func (ramp *Ramp) Reverse() *Ramp {
+	reversed := &Ramp{Stops: make([]Stop, len(ramp.Stops))}
+	for i, stop := range ramp.Stops {
+		reversed.Stops[len(ramp.Stops)-1-i] = Stop{Position: 1 - stop.Position, Color: stop.Color}
+	}
+	return reversed
+}
+
+// ParseRamp 解析色带名或用户定义的节点
+// 名称可加 _r 后缀表示反向，如 RdYlBu_r；节点为逗号分隔的颜色 "#2892c7,#fafa64,#e81014"，
+// 或带位置的 "0:#2892c7,0.4:#fafa64,1:#e81014"
+func ParseRamp(value string) (*Ramp, error) {
+	if !strings.ContainsAny(value, "#,:") {
+		return Named(value)
+	}
+	ramp := &Ramp{}
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		position := 0.0
+		if len(parts) > 1 {
+			position = float64(i) / float64(len(parts)-1)
+		}
+		if k := strings.Index(part, ":"); k >= 0 {
+			v, err := strconv.ParseFloat(part[:k], 64)
+			if err != nil {
+				return nil, fmt.Errorf("palette: invalid stop %q", part)
+			}
+			position, part = v, part[k+1:]
+		}
+		c, err := ParseColor(part)
+		if err != nil {
+			return nil, err
+		}
+		ramp.Stops = append(ramp.Stops, Stop{Position: position, Color: c})
+	}
+	sort.SliceStable(ramp.Stops, func(i, j int) bool { return ramp.Stops[i].Position < ramp.Stops[j].Position })
+	return ramp, nil
+}
+
+// ParseColor 解析 #rgb、#rrggbb 或 #rrggbbaa
+func ParseColor(value string) (color.Color, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 8 || err != nil {
+		return nil, fmt.Errorf("palette: invalid color %q", value)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// Palette 值到颜色的映射
+// Breaks 不少于两个时按区间分级着色，否则在 Domain 上连续着色
+type Palette struct {
+	Ramp   *Ramp
+	Domain [2]float64 // 连续着色的值域，映射到色带的两端
+	Breaks []float64  // 分级的分界值，升序
+	// Colors 各分级的颜色，数量与分级数不同时在色带上等距取色
+	Colors []color.Color
+	// Nodata 无数据值与 NaN 的颜色，nil 时为透明
+	Nodata color.Color
+	// Below、Above 低于、高于值域或分级范围的颜色，nil 时取两端的颜色
+	Below color.Color
+	Above color.Color
+}
+
+// New 在 domain 上连续着色
+func New(ramp *Ramp, domain [2]float64) *Palette {
+	return &Palette{Ramp: ramp, Domain: domain}
+}
+
+// NewClassified 按 breaks 分级着色，各级颜色在色带上等距选取
+func NewClassified(ramp *Ramp, breaks []float64) *Palette {
+	return &Palette{Ramp: ramp, Breaks: breaks}
+}
+
+// NewColors 按 breaks 分级着色，colors 为各级颜色
+func NewColors(colors []color.Color, breaks []float64) *Palette {
+	return &Palette{Ramp: NewRamp(colors...), Breaks: breaks, Colors: colors}
+}
+
+// Classified 是否分级着色
+func (p *Palette) Classified() bool {
+	return len(p.Breaks) >= 2
+}
+
+// Color 值 v 的颜色
+func (p *Palette) Color(v float64) color.Color {
+	if math.IsNaN(v) {
+		return p.nodata()
+	}
+	if p.Classified() {
+		last := len(p.Breaks) - 1
+		switch {
+		case v < p.Breaks[0]:
+			if p.Below != nil {
+				return p.Below
+			}
+			return p.ClassColor(0)
+		case v > p.Breaks[last]:
+			if p.Above != nil {
+				return p.Above
+			}
+			return p.ClassColor(last - 1)
+		}
+		return p.ClassColor(p.Class(v))
+	}
+
+	t := 0.5
+	if p.Domain[1] != p.Domain[0] {
+		t = (v - p.Domain[0]) / (p.Domain[1] - p.Domain[0])
+	}
+	if t < 0 && p.Below != nil {
+		return p.Below
+	}
+	if t > 1 && p.Above != nil {
+		return p.Above
+	}
+	return p.ramp().At(t)
+}
+
+// NodataColor 无数据值的颜色
+func (p *Palette) NodataColor() color.Color {
+	return p.nodata()
+}
+
+func (p *Palette) nodata() color.Color {
+	if p.Nodata == nil {
+		return color.Transparent
+	}
+	return p.Nodata
+}
+
+// Class v 所在分级的序号，区间左闭右开，最后一级包含上界；超出范围时为 -1
+func (p *Palette) Class(v float64) int {
+	last := len(p.Breaks) - 1
+	if !p.Classified() || math.IsNaN(v) || v < p.Breaks[0] || v > p.Breaks[last] {
+		return -1
+	}
+	i := sort.SearchFloat64s(p.Breaks, v)
+	if i < len(p.Breaks) && p.Breaks[i] == v {
+		i++
+	}
+	if i > last {
+		i = last
+	}
+	return i - 1
+}
+
+// ClassColor 第 i 级的颜色
+func (p *Palette) ClassColor(i int) color.Color {
+	n := len(p.Breaks) - 1
+	if len(p.Colors) == n && n > 0 {
+		return p.Colors[i]
+	}
+	t := 0.5
+	if n > 1 {
+		t = float64(i) / float64(n-1)
+	}
+	return p.ramp().At(t)
+}
+
+// ClassColors 各分级的颜色
+func (p *Palette) ClassColors() []color.Color {
+	colors := make([]color.Color, 0, len(p.Breaks))
+	for i := 0; i+1 < len(p.Breaks); i++ {
+		colors = append(colors, p.ClassColor(i))
+	}
+	return colors
+}
+
+func (p *Palette) ramp() *Ramp {
+	if p.Ramp == nil {
+		return viridis
+	}
+	return p.Ramp
+}
+
+// Validate 检查分界值是否升序
+func (p *Palette) Validate() error {
+	for i := 1; i < len(p.Breaks); i++ {
+		if !(p.Breaks[i] > p.Breaks[i-1]) {
+			return errors.New("palette: breaks must be strictly increasing")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package palette
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestRamp(t *testing.T) {
+	ramp, err := ParseRamp("0:#000000,1:#ffffff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Oklab 中点的亮度约为 sRGB 的 99，而不是线性 RGB 的 188
+	mid := color.NRGBAModel.Convert(ramp.At(0.5)).(color.NRGBA)
+	if mid.R != mid.G || mid.G != mid.B || mid.R < 90 || mid.R > 110 {
+		t.Fatalf("unexpected middle color %v", mid)
+	}
+	if ramp.At(-1) != ramp.Stops[0].Color || ramp.At(2) != ramp.Stops[1].Color {
+		t.Fatal("out of range positions are not clamped")
+	}
+
+	reversed, err := Named("viridis_r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	viridis, _ := Named("Viridis")
+	if reversed.At(0) != viridis.At(1) || reversed.At(0.3) != viridis.At(0.7) {
+		t.Fatal("reversed ramp does not mirror the ramp")
+	}
+	if _, err := Named("nope"); err == nil {
+		t.Fatal("expected unknown ramp error")
+	}
+	for _, name := range Names() {
+		if _, err := Named(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPalette(t *testing.T) {
+	red, green, blue := color.NRGBA{R: 255, A: 255}, color.NRGBA{G: 255, A: 255}, color.NRGBA{B: 255, A: 255}
+	gray := color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+	p := NewColors([]color.Color{red, green, blue}, []float64{0, 1, 2, 3})
+	for _, c := range []struct {
+		value float64
+		want  color.Color
+	}{
+		{0, red}, {0.99, red}, {1, green}, {3, blue}, {-1, red}, {4, blue},
+	} {
+		if got := p.Color(c.value); got != c.want {
+			t.Fatalf("color of %v is %v, expected %v", c.value, got, c.want)
+		}
+	}
+	if p.Color(math.NaN()) != color.Transparent {
+		t.Fatal("expected transparent nodata")
+	}
+	p.Below, p.Above, p.Nodata = gray, gray, gray
+	if p.Color(-1) != gray || p.Color(4) != gray || p.Color(math.NaN()) != gray {
+		t.Fatal("out of range colors are not used")
+	}
+
+	continuous := New(NewRamp(red, blue), [2]float64{10, 20})
+	if continuous.Color(10) != red || continuous.Color(25) != blue {
+		t.Fatal("continuous palette does not map the domain to the ramp ends")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 10, 11, 12, 13, 30, 31, math.NaN()}
+	breaks, err := Classify(EqualInterval, values, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(breaks) != 4 || breaks[0] != 1 || breaks[1] != 11 || breaks[3] != 31 {
+		t.Fatalf("unexpected equal interval breaks %v", breaks)
+	}
+
+	breaks, err = Classify(Quantile, values, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(breaks) != 3 || breaks[1] != 10.5 {
+		t.Fatalf("unexpected quantile breaks %v", breaks)
+	}
+
+	// 自然断点在三组值之间
+	breaks, err = Classify(Jenks, values, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(breaks) != 4 || breaks[1] != 10 || breaks[2] != 30 {
+		t.Fatalf("unexpected jenks breaks %v", breaks)
+	}
+
+	if _, err := Classify("other", values, 3); err == nil {
+		t.Fatal("expected unknown classification error")
+	}
+}
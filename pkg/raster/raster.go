@@ -0,0 +1,170 @@
+// Package raster
+// 规则格网栅格，作为 GridMatrices、ContourRectangle 与各种栅格格式之间的统一表示
+
+package raster
+
+import (
+	"math"
+	"sync"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+)
+
+// Raster 北向朝上的单波段规则格网
+// Data 按行存储，第一行为最北的一行，每个值代表以像元中心为采样点的像元
+type Raster struct {
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Data        []float64 `json:"data"`
+	X0          float64   `json:"x0"` // 左上角 x
+	Y0          float64   `json:"y0"` // 左上角 y
+	XResolution float64   `json:"xResolution"`
+	YResolution float64   `json:"yResolution"`
+	HasNodata   bool      `json:"hasNodata"`
+	NodataValue float64   `json:"nodataValue"`
+}
+
+// New 创建栅格，左上角为 (x0, y0)
+func New(width, height int, x0, y0, xResolution, yResolution float64) *Raster {
+	return &Raster{
+		Width:       width,
+		Height:      height,
+		Data:        make([]float64, width*height),
+		X0:          x0,
+		Y0:          y0,
+		XResolution: xResolution,
+		YResolution: yResolution,
+	}
+}
+
+// IsGeographicEPSG EPSG 代码是否为地理坐标系，4000-4999 大多为地理坐标系
+// GeoTIFF 与 NetCDF 写入时据此选择地理或投影坐标系的元数据
+func IsGeographicEPSG(epsg int) bool {
+	return epsg >= 4000 && epsg < 5000
+}
+
+// FromGridMatrices 裁剪过的矩阵网格转栅格
+// GridMatrices.Data[i][j] 为 (Xlim[0]+i*Width, Ylim[0]+j*Width) 处的值
+func FromGridMatrices(gridMatrices *ordinarykriging.GridMatrices) *Raster {
+	width := len(gridMatrices.Data)
+	height := 0
+	if width > 0 {
+		height = len(gridMatrices.Data[0])
+	}
+	cell := gridMatrices.Width
+	r := New(width, height,
+		gridMatrices.Xlim[0]-cell/2,
+		gridMatrices.Ylim[0]+float64(height-1)*cell+cell/2,
+		cell, cell)
+	r.HasNodata = true
+	r.NodataValue = gridMatrices.NodataValue
+
+	for i := 0; i < width; i++ {
+		for j := 0; j < height; j++ {
+			r.Data[(height-1-j)*width+i] = gridMatrices.Data[i][j]
+		}
+	}
+	return r
+}
+
+// FromContourRectangle 矩形网格转栅格
+// ContourRectangle.Contour[j*XWidth+k] 为 (Xlim[0]+k*xResolution, Ylim[0]+j*yResolution) 处的值
+// 分辨率由范围与宽高计算，与 ContourWithBBox 一致
+func FromContourRectangle(contourRectangle *ordinarykriging.ContourRectangle) *Raster {
+	width := contourRectangle.XWidth
+	height := contourRectangle.YWidth
+	xResolution := (contourRectangle.Xlim[1] - contourRectangle.Xlim[0]) / float64(width)
+	yResolution := (contourRectangle.Ylim[1] - contourRectangle.Ylim[0]) / float64(height)
+	r := New(width, height,
+		contourRectangle.Xlim[0]-xResolution/2,
+		contourRectangle.Ylim[0]+float64(height-1)*yResolution+yResolution/2,
+		xResolution, yResolution)
+
+	for j := 0; j < height; j++ {
+		copy(r.Data[(height-1-j)*width:(height-j)*width], contourRectangle.Contour[j*width:(j+1)*width])
+	}
+	return r
+}
+
+// At 第 row 行第 col 列的值
+func (r *Raster) At(col, row int) float64 {
+	return r.Data[row*r.Width+col]
+}
+
+// Set 设置第 row 行第 col 列的值
+func (r *Raster) Set(col, row int, value float64) {
+	r.Data[row*r.Width+col] = value
+}
+
+// IsNodata 是否为无数据值
+func (r *Raster) IsNodata(value float64) bool {
+	if math.IsNaN(value) {
+		return true
+	}
+	return r.HasNodata && value == r.NodataValue
+}
+
+// CellCenter 像元中心坐标
+func (r *Raster) CellCenter(col, row int) (float64, float64) {
+	return r.X0 + (float64(col)+0.5)*r.XResolution, r.Y0 - (float64(row)+0.5)*r.YResolution
+}
+
+// BBox 栅格范围 [minX, minY, maxX, maxY]
+func (r *Raster) BBox() [4]float64 {
+	return [4]float64{
+		r.X0,
+		r.Y0 - float64(r.Height)*r.YResolution,
+		r.X0 + float64(r.Width)*r.XResolution,
+		r.Y0,
+	}
+}
+
+// Zlim 有效值的范围
+func (r *Raster) Zlim() [2]float64 {
+	zlim := [2]float64{math.Inf(1), math.Inf(-1)}
+	for _, value := range r.Data {
+		if r.IsNodata(value) {
+			continue
+		}
+		zlim[0] = math.Min(zlim[0], value)
+		zlim[1] = math.Max(zlim[1], value)
+	}
+	return zlim
+}
+
+// Variance 各像元中心的克里金方差，无数据像元保持为无数据值
+// 方差的计算量为 O(N^2)，按行并行计算
+func (r *Raster) Variance(variogram *ordinarykriging.Variogram) *Raster {
+	v := New(r.Width, r.Height, r.X0, r.Y0, r.XResolution, r.YResolution)
+	v.HasNodata = r.HasNodata
+	v.NodataValue = r.NodataValue
+
+	var wg sync.WaitGroup
+	for row := 0; row < r.Height; row++ {
+		wg.Add(1)
+		go func(row int) {
+			defer wg.Done()
+			for col := 0; col < r.Width; col++ {
+				value := r.At(col, row)
+				if r.IsNodata(value) {
+					v.Set(col, row, value)
+					continue
+				}
+				v.Set(col, row, variogram.Variance(r.CellCenter(col, row)))
+			}
+		}(row)
+	}
+	wg.Wait()
+	return v
+}
+
+// Lookup 坐标所在像元的值，超出范围或为无数据值时 ok 为 false
+func (r *Raster) Lookup(x, y float64) (value float64, ok bool) {
+	col := int(math.Floor((x - r.X0) / r.XResolution))
+	row := int(math.Floor((r.Y0 - y) / r.YResolution))
+	if col < 0 || col >= r.Width || row < 0 || row >= r.Height {
+		return math.NaN(), false
+	}
+	value = r.At(col, row)
+	return value, !r.IsNodata(value)
+}
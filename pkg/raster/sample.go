@@ -0,0 +1,129 @@
+package raster
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Method 重采样的插值方法
+type Method string
+
+const (
+	Nearest  Method = "nearest"  // 最邻近像元
+	Bilinear Method = "bilinear" // 相邻 4 个像元中心的双线性插值
+	Bicubic  Method = "bicubic"  // 相邻 16 个像元中心的 Catmull-Rom 双三次插值
+)
+
+// ParseMethod 解析插值方法名
+func ParseMethod(name string) (Method, error) {
+	switch method := Method(strings.ToLower(name)); method {
+	case Nearest, Bilinear, Bicubic:
+		return method, nil
+	}
+	return "", fmt.Errorf("raster: unknown resampling method %q", name)
+}
+
+// Sample 按插值方法取坐标处的值，超出范围或所在像元为无数据值时 ok 为 false
+// 边缘像元向外延伸，相邻像元中的无数据值不参与插值，双三次插值缺少相邻像元时退回双线性插值
+func (r *Raster) Sample(x, y float64, method Method) (value float64, ok bool) {
+	value, ok = r.Lookup(x, y)
+	if !ok || method == Nearest {
+		return value, ok
+	}
+	return r.interpolate(x, y, method)
+}
+
+// SampleExtended 与 Sample 相同，但所在像元为无数据值时仍由相邻的有效像元插值
+// 用于按多边形裁剪边缘的绘制，边缘由裁剪多边形而不是像元决定
+func (r *Raster) SampleExtended(x, y float64, method Method) (value float64, ok bool) {
+	if method == Nearest {
+		return r.Lookup(x, y)
+	}
+	bbox := r.BBox()
+	if x < bbox[0] || x >= bbox[2] || y <= bbox[1] || y > bbox[3] {
+		return math.NaN(), false
+	}
+	return r.interpolate(x, y, method)
+}
+
+func (r *Raster) interpolate(x, y float64, method Method) (float64, bool) {
+	// 以像元中心为整数的行列坐标
+	fx := (x-r.X0)/r.XResolution - 0.5
+	fy := (r.Y0-y)/r.YResolution - 0.5
+	col, row := int(math.Floor(fx)), int(math.Floor(fy))
+	tx, ty := fx-float64(col), fy-float64(row)
+
+	if method == Bicubic {
+		if value, ok := r.bicubic(col, row, tx, ty); ok {
+			return value, true
+		}
+	}
+
+	var sum, weights, mean float64
+	var n int
+	for k := 0; k < 4; k++ {
+		dc, dr := k%2, k/2
+		value := r.clampedAt(col+dc, row+dr)
+		if r.IsNodata(value) {
+			continue
+		}
+		w := (1 - math.Abs(float64(dc)-tx)) * (1 - math.Abs(float64(dr)-ty))
+		sum += w * value
+		weights += w
+		mean += value
+		n++
+	}
+	switch {
+	case weights > 0:
+		return sum / weights, true
+	case n > 0:
+		// 恰好位于无数据像元中心时取有效像元的平均
+		return mean / float64(n), true
+	}
+	return math.NaN(), false
+}
+
+// bicubic 相邻 16 个像元均有效时的 Catmull-Rom 插值
+func (r *Raster) bicubic(col, row int, tx, ty float64) (float64, bool) {
+	wx, wy := catmullRom(tx), catmullRom(ty)
+	var value float64
+	for j := 0; j < 4; j++ {
+		var rowValue float64
+		for i := 0; i < 4; i++ {
+			v := r.clampedAt(col+i-1, row+j-1)
+			if r.IsNodata(v) {
+				return math.NaN(), false
+			}
+			rowValue += wx[i] * v
+		}
+		value += wy[j] * rowValue
+	}
+	return value, true
+}
+
+// clampedAt 行列超出范围时取最近的边缘像元
+func (r *Raster) clampedAt(col, row int) float64 {
+	if col < 0 {
+		col = 0
+	} else if col >= r.Width {
+		col = r.Width - 1
+	}
+	if row < 0 {
+		row = 0
+	} else if row >= r.Height {
+		row = r.Height - 1
+	}
+	return r.At(col, row)
+}
+
+// catmullRom t 处相邻 4 个采样点的权重
+func catmullRom(t float64) [4]float64 {
+	t2, t3 := t*t, t*t*t
+	return [4]float64{
+		(-t3 + 2*t2 - t) / 2,
+		(3*t3 - 5*t2 + 2) / 2,
+		(-3*t3 + 4*t2 + t) / 2,
+		(t3 - t2) / 2,
+	}
+}
@@ -0,0 +1,109 @@
+package raster
+
+import (
+	"math"
+)
+
+// metersPerDegree 赤道上一度经度的长度
+const metersPerDegree = 111320.0
+
+// TerrainOptions 坡度、坡向与山体阴影的选项
+type TerrainOptions struct {
+	// Azimuth 光源方位角，单位度，正北为 0 顺时针，0 时为 315（西北），正北用 360
+	Azimuth float64
+	// Altitude 光源高度角，单位度，0 时为 45
+	Altitude float64
+	// ZFactor 垂直夸大倍数，0 时为 1
+	ZFactor float64
+	// Geographic 坐标为经纬度，像元大小按所在行的纬度换算为米；否则坐标与值的单位相同
+	Geographic bool
+}
+
+func (opt *TerrainOptions) withDefaults() TerrainOptions {
+	o := TerrainOptions{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.Azimuth == 0 {
+		o.Azimuth = 315
+	}
+	if o.Altitude == 0 {
+		o.Altitude = 45
+	}
+	if o.ZFactor == 0 {
+		o.ZFactor = 1
+	}
+	return o
+}
+
+// Slope 坡度，单位度
+func (r *Raster) Slope(opt *TerrainOptions) *Raster {
+	return r.terrain(opt, func(gx, gy float64, o *TerrainOptions) float64 {
+		return math.Atan(math.Hypot(gx, gy)) * 180 / math.Pi
+	})
+}
+
+// Aspect 坡向，即下坡方向的方位角，单位度，正北为 0 顺时针，平地为 -1
+func (r *Raster) Aspect(opt *TerrainOptions) *Raster {
+	return r.terrain(opt, func(gx, gy float64, o *TerrainOptions) float64 {
+		if gx == 0 && gy == 0 {
+			return -1
+		}
+		aspect := math.Atan2(-gx, -gy) * 180 / math.Pi
+		if aspect < 0 {
+			aspect += 360
+		}
+		return aspect
+	})
+}
+
+// Hillshade 山体阴影，为 0（背光）到 1（正对光源）的光照强度，平地为光源高度角的正弦
+func (r *Raster) Hillshade(opt *TerrainOptions) *Raster {
+	return r.terrain(opt, func(gx, gy float64, o *TerrainOptions) float64 {
+		azimuth, altitude := o.Azimuth*math.Pi/180, o.Altitude*math.Pi/180
+		// 地表法向量与指向光源的单位向量的点积
+		shade := (-gx*math.Sin(azimuth)*math.Cos(altitude) - gy*math.Cos(azimuth)*math.Cos(altitude) + math.Sin(altitude)) /
+			math.Sqrt(gx*gx+gy*gy+1)
+		return math.Max(shade, 0)
+	})
+}
+
+// terrain 按 Horn 方法由 3x3 邻域计算各像元向东、向北的梯度，再由 f 换算
+// 超出范围或为无数据值的邻域像元取中心像元的值，中心像元为无数据值时结果为无数据值
+func (r *Raster) terrain(opt *TerrainOptions, f func(gx, gy float64, o *TerrainOptions) float64) *Raster {
+	o := opt.withDefaults()
+	t := New(r.Width, r.Height, r.X0, r.Y0, r.XResolution, r.YResolution)
+	t.HasNodata = r.HasNodata
+	t.NodataValue = r.NodataValue
+
+	for row := 0; row < r.Height; row++ {
+		xSize, ySize := r.XResolution, r.YResolution
+		if o.Geographic {
+			_, lat := r.CellCenter(0, row)
+			xSize *= metersPerDegree * math.Cos(lat*math.Pi/180)
+			ySize *= metersPerDegree
+		}
+		for col := 0; col < r.Width; col++ {
+			center := r.At(col, row)
+			if r.IsNodata(center) {
+				t.Set(col, row, center)
+				continue
+			}
+			z := func(dc, dr int) float64 {
+				c, rr := col+dc, row+dr
+				if c < 0 || c >= r.Width || rr < 0 || rr >= r.Height {
+					return center
+				}
+				if value := r.At(c, rr); !r.IsNodata(value) {
+					return value
+				}
+				return center
+			}
+			// 第一行为北
+			gx := ((z(1, -1) + 2*z(1, 0) + z(1, 1)) - (z(-1, -1) + 2*z(-1, 0) + z(-1, 1))) / (8 * xSize)
+			gy := ((z(-1, -1) + 2*z(0, -1) + z(1, -1)) - (z(-1, 1) + 2*z(0, 1) + z(1, 1))) / (8 * ySize)
+			t.Set(col, row, f(gx*o.ZFactor, gy*o.ZFactor, &o))
+		}
+	}
+	return t
+}
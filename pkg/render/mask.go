@@ -0,0 +1,66 @@
+package render
+
+import (
+	"math"
+	"sort"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+)
+
+// coverage 多面对每个像素的覆盖比例，按奇偶规则
+// 每个像素取 samples 条水平扫描线，扫描线上的覆盖长度按交点精确计算
+func coverage(clip ordinarykriging.MultiPolygonCoordinates, width, height int, xlim, ylim [2]float64, samples int) []float64 {
+	type edge struct{ x0, y0, x1, y1 float64 }
+	var edges []edge
+	for _, polygon := range clip {
+		for _, ring := range polygon {
+			for i := range ring {
+				a, b := ring[i], ring[(i+1)%len(ring)]
+				edges = append(edges, edge{
+					x0: float64(width) * (a[0] - xlim[0]) / (xlim[1] - xlim[0]),
+					y0: float64(height) * (1 - (a[1]-ylim[0])/(ylim[1]-ylim[0])),
+					x1: float64(width) * (b[0] - xlim[0]) / (xlim[1] - xlim[0]),
+					y1: float64(height) * (1 - (b[1]-ylim[0])/(ylim[1]-ylim[0])),
+				})
+			}
+		}
+	}
+
+	mask := make([]float64, width*height)
+	weight := 1 / float64(samples)
+	var xs []float64
+	for py := 0; py < height; py++ {
+		row := mask[py*width : (py+1)*width]
+		for s := 0; s < samples; s++ {
+			y := float64(py) + (float64(s)+0.5)*weight
+			xs = xs[:0]
+			for _, e := range edges {
+				if (e.y0 > y) != (e.y1 > y) {
+					xs = append(xs, e.x0+(y-e.y0)*(e.x1-e.x0)/(e.y1-e.y0))
+				}
+			}
+			sort.Float64s(xs)
+			for i := 0; i+1 < len(xs); i += 2 {
+				addSpan(row, math.Max(xs[i], 0), math.Min(xs[i+1], float64(width)), weight)
+			}
+		}
+	}
+	return mask
+}
+
+// addSpan 将扫描线上 [x0, x1) 的覆盖长度累加到所在像素
+func addSpan(row []float64, x0, x1, weight float64) {
+	if x1 <= x0 {
+		return
+	}
+	first, last := int(math.Floor(x0)), int(math.Ceil(x1))-1
+	if first == last {
+		row[first] += (x1 - x0) * weight
+		return
+	}
+	row[first] += (float64(first+1) - x0) * weight
+	for px := first + 1; px < last; px++ {
+		row[px] += weight
+	}
+	row[last] += (x1 - float64(last)) * weight
+}
@@ -0,0 +1,159 @@
+// Package render
+// 重采样绘制：将输出图片的每个像素换算回栅格坐标插值后着色，按裁剪多边形做抗锯齿的边缘
+
+package render
+
+import (
+	"errors"
+	"image"
+	"math"
+	"sync"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/palette"
+	"github.com/lvisei/go-kriging/pkg/raster"
+)
+
+// Options 绘制选项
+type Options struct {
+	Width  int
+	Height int
+	// Xlim、Ylim 输出图片的范围，与 Plot 的 xlim、ylim 相同，为 0 时为栅格范围
+	Xlim [2]float64
+	Ylim [2]float64
+	// Method 插值方法，为空时为 raster.Bilinear
+	Method raster.Method
+	// Palette 着色，无数据像素使用 palette 的无数据颜色
+	Palette *palette.Palette
+	// Clip 裁剪多面，一般为 GridMultiPolygon 使用的多面
+	// 不为空时多面外透明，边缘按像素的覆盖比例抗锯齿，多面内像元中心之外的部分由相邻像元插值补齐
+	Clip ordinarykriging.MultiPolygonCoordinates
+	// Samples 计算覆盖比例时每个像素的扫描线数，0 时为 4
+	Samples int
+	// Hillshade 不为 nil 时按山体阴影调暗颜色，用于地下水位、高程等类似地形的变量
+	Hillshade *raster.TerrainOptions
+	// ShadeStrength 阴影的强度，0 到 1，为 1 时颜色乘以光照强度，0 时为 0.6
+	ShadeStrength float64
+}
+
+func (opt *Options) withDefaults() Options {
+	o := Options{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.Method == "" {
+		o.Method = raster.Bilinear
+	}
+	if o.Samples <= 0 {
+		o.Samples = 4
+	}
+	if o.ShadeStrength <= 0 {
+		o.ShadeStrength = 0.6
+	} else if o.ShadeStrength > 1 {
+		o.ShadeStrength = 1
+	}
+	return o
+}
+
+// GridMatrices 绘制裁剪过的矩阵网格
+func GridMatrices(gridMatrices *ordinarykriging.GridMatrices, opt *Options) (*image.RGBA, error) {
+	return Render(raster.FromGridMatrices(gridMatrices), opt)
+}
+
+// ContourRectangle 绘制 ContourWithBBox 的矩形网格
+func ContourRectangle(contourRectangle *ordinarykriging.ContourRectangle, opt *Options) (*image.RGBA, error) {
+	return Render(raster.FromContourRectangle(contourRectangle), opt)
+}
+
+// Render 按像素中心重采样绘制栅格，按行并行计算
+func Render(r *raster.Raster, opt *Options) (*image.RGBA, error) {
+	o := opt.withDefaults()
+	if o.Width <= 0 || o.Height <= 0 {
+		return nil, errors.New("render: invalid image size")
+	}
+	if o.Palette == nil {
+		return nil, errors.New("render: missing palette")
+	}
+	if r == nil || r.Width < 1 || r.Height < 1 || len(r.Data) != r.Width*r.Height {
+		return nil, errors.New("render: empty raster")
+	}
+	if _, err := raster.ParseMethod(string(o.Method)); err != nil {
+		return nil, err
+	}
+	xlim, ylim := o.Xlim, o.Ylim
+	if xlim[0] == xlim[1] || ylim[0] == ylim[1] {
+		bbox := r.BBox()
+		xlim, ylim = [2]float64{bbox[0], bbox[2]}, [2]float64{bbox[1], bbox[3]}
+	}
+	if !(xlim[1] > xlim[0]) || !(ylim[1] > ylim[0]) {
+		return nil, errors.New("render: empty extent")
+	}
+
+	var mask []float64
+	if len(o.Clip) > 0 {
+		mask = coverage(o.Clip, o.Width, o.Height, xlim, ylim, o.Samples)
+	}
+	var shade *raster.Raster
+	if o.Hillshade != nil {
+		shade = r.Hillshade(o.Hillshade)
+	}
+	nodataR, nodataG, nodataB, nodataA := o.Palette.NodataColor().RGBA()
+	xResolution := (xlim[1] - xlim[0]) / float64(o.Width)
+	yResolution := (ylim[1] - ylim[0]) / float64(o.Height)
+
+	img := image.NewRGBA(image.Rect(0, 0, o.Width, o.Height))
+	var wg sync.WaitGroup
+	for py := 0; py < o.Height; py++ {
+		wg.Add(1)
+		go func(py int) {
+			defer wg.Done()
+			y := ylim[1] - (float64(py)+0.5)*yResolution
+			for px := 0; px < o.Width; px++ {
+				alpha := 1.0
+				if mask != nil {
+					if alpha = math.Min(mask[py*o.Width+px], 1); alpha <= 0 {
+						continue
+					}
+				}
+				x := xlim[0] + (float64(px)+0.5)*xResolution
+				sample := r.Sample
+				if mask != nil {
+					sample = r.SampleExtended
+				}
+				value, ok := sample(x, y, o.Method)
+				cr, cg, cb, ca := nodataR, nodataG, nodataB, nodataA
+				light := 1.0
+				if ok {
+					cr, cg, cb, ca = o.Palette.Color(value).RGBA()
+					if shade != nil {
+						if s, ok := sampleShade(shade, x, y, o.Method, mask != nil); ok {
+							light = 1 - o.ShadeStrength*(1-s)
+						}
+					}
+				}
+				i := img.PixOffset(px, py)
+				img.Pix[i] = channel(cr, alpha*light)
+				img.Pix[i+1] = channel(cg, alpha*light)
+				img.Pix[i+2] = channel(cb, alpha*light)
+				img.Pix[i+3] = channel(ca, alpha)
+			}
+		}(py)
+	}
+	wg.Wait()
+	return img, nil
+}
+
+// channel 16 位预乘颜色分量乘以 f 后的 8 位值
+func channel(c uint32, f float64) uint8 {
+	return uint8(math.Round(float64(c>>8) * f))
+}
+
+// sampleShade 山体阴影栅格上的光照强度，双三次插值可能超出 0 到 1
+func sampleShade(shade *raster.Raster, x, y float64, method raster.Method, extended bool) (float64, bool) {
+	sample := shade.Sample
+	if extended {
+		sample = shade.SampleExtended
+	}
+	s, ok := sample(x, y, method)
+	return math.Max(0, math.Min(s, 1)), ok
+}
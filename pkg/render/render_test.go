@@ -0,0 +1,134 @@
+package render
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/palette"
+	"github.com/lvisei/go-kriging/pkg/raster"
+)
+
+// gradient 值等于 x 坐标的栅格，像元中心为 0.5、1.5 ...
+func gradient(width, height int) *raster.Raster {
+	r := raster.New(width, height, 0, float64(height), 1, 1)
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			r.Set(col, row, float64(col)+0.5)
+		}
+	}
+	return r
+}
+
+func TestSample(t *testing.T) {
+	r := gradient(6, 6)
+	for _, c := range []struct {
+		method raster.Method
+		x      float64
+		want   float64
+	}{
+		{raster.Nearest, 2.2, 2.5},
+		{raster.Bilinear, 2.2, 2.2},
+		{raster.Bicubic, 2.2, 2.2},
+		// 边缘像元向外延伸
+		{raster.Bilinear, 0.2, 0.5},
+	} {
+		value, ok := r.Sample(c.x, 3, c.method)
+		if !ok || math.Abs(value-c.want) > 1e-9 {
+			t.Fatalf("%s sample at %v is %v, expected %v", c.method, c.x, value, c.want)
+		}
+	}
+
+	r.HasNodata, r.NodataValue = true, -1
+	r.Set(3, 2, -1)
+	if _, ok := r.Sample(3.5, 3.5, raster.Bilinear); ok {
+		t.Fatal("expected nodata in a nodata cell")
+	}
+	// 无数据像元不参与插值，双三次插值退回双线性插值
+	value, ok := r.Sample(2.8, 3.5, raster.Bicubic)
+	if !ok || value != 2.5 {
+		t.Fatalf("unexpected sample %v next to a nodata cell", value)
+	}
+	if value, ok := r.SampleExtended(3.5, 3.2, raster.Bilinear); !ok || math.Abs(value-3.5) > 1e-9 {
+		t.Fatalf("unexpected extended sample %v", value)
+	}
+	if _, err := raster.ParseMethod("lanczos"); err == nil {
+		t.Fatal("expected unknown method error")
+	}
+}
+
+func TestRender(t *testing.T) {
+	p := palette.New(palette.NewRamp(color.Black, color.White), [2]float64{0, 4})
+	r := gradient(4, 4)
+	img, err := Render(r, &Options{Width: 16, Height: 4, Palette: p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 双线性插值在像元之间连续变化，没有色块
+	previous := -1
+	for px := 2; px < 14; px++ {
+		gray := int(img.RGBAAt(px, 1).R)
+		if gray <= previous {
+			t.Fatalf("pixel %d is not brighter than its left neighbour", px)
+		}
+		previous = gray
+	}
+
+	// 裁剪到左半边，第 8 列像素被覆盖一半
+	clip := ordinarykriging.MultiPolygonCoordinates{{{{0, 0}, {2.125, 0}, {2.125, 4}, {0, 4}}}}
+	img, err = Render(r, &Options{Width: 16, Height: 4, Palette: p, Method: raster.Nearest, Clip: clip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := img.RGBAAt(4, 1).A; a != 255 {
+		t.Fatalf("inside pixel alpha is %d", a)
+	}
+	if a := img.RGBAAt(8, 1).A; a < 120 || a > 135 {
+		t.Fatalf("edge pixel alpha is %d, expected about 128", a)
+	}
+	if a := img.RGBAAt(12, 1).A; a != 0 {
+		t.Fatalf("outside pixel alpha is %d", a)
+	}
+
+	if _, err := Render(r, &Options{Width: 16, Height: 4, Palette: p, Method: "other"}); err == nil {
+		t.Fatal("expected unknown method error")
+	}
+}
+
+func TestHillshade(t *testing.T) {
+	// 向东升高 45 度的斜面
+	r := gradient(5, 5)
+	if slope := r.Slope(nil).At(2, 2); math.Abs(slope-45) > 1e-9 {
+		t.Fatalf("slope is %v, expected 45", slope)
+	}
+	if aspect := r.Aspect(nil).At(2, 2); math.Abs(aspect-270) > 1e-9 {
+		t.Fatalf("aspect is %v, expected 270 (facing west)", aspect)
+	}
+	if shade := r.Hillshade(&raster.TerrainOptions{Azimuth: 270, Altitude: 45}).At(2, 2); math.Abs(shade-1) > 1e-9 {
+		t.Fatalf("shade facing the sun is %v, expected 1", shade)
+	}
+	if shade := r.Hillshade(&raster.TerrainOptions{Azimuth: 90, Altitude: 45}).At(2, 2); shade > 1e-9 {
+		t.Fatalf("shade facing away from the sun is %v, expected 0", shade)
+	}
+	// 垂直夸大使斜面更陡
+	if slope := r.Slope(&raster.TerrainOptions{ZFactor: 3}).At(2, 2); math.Abs(slope-math.Atan(3)*180/math.Pi) > 1e-9 {
+		t.Fatalf("exaggerated slope is %v", slope)
+	}
+
+	white := palette.NewColors([]color.Color{color.White}, []float64{0, 10})
+	lit, err := Render(r, &Options{Width: 5, Height: 5, Palette: white, Method: raster.Nearest, Hillshade: &raster.TerrainOptions{Azimuth: 270}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dark, err := Render(r, &Options{Width: 5, Height: 5, Palette: white, Method: raster.Nearest, Hillshade: &raster.TerrainOptions{Azimuth: 90}, ShadeStrength: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := lit.RGBAAt(2, 2); c.R != 255 || c.A != 255 {
+		t.Fatalf("lit pixel is %v", c)
+	}
+	if c := dark.RGBAAt(2, 2); c.R != 0 || c.A != 255 {
+		t.Fatalf("shaded pixel is %v", c)
+	}
+}
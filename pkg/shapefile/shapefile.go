@@ -0,0 +1,466 @@
+// Package shapefile
+// ESRI Shapefile 读取（.shp 几何与 .dbf 属性），输出点样本与面裁剪边界
+
+package shapefile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/geojson"
+)
+
+// ShapeType 几何类型
+type ShapeType int32
+
+const (
+	Null        ShapeType = 0
+	Point       ShapeType = 1
+	PolyLine    ShapeType = 3
+	Polygon     ShapeType = 5
+	MultiPoint  ShapeType = 8
+	PointZ      ShapeType = 11
+	PolyLineZ   ShapeType = 13
+	PolygonZ    ShapeType = 15
+	MultiPointZ ShapeType = 18
+	PointM      ShapeType = 21
+	PolyLineM   ShapeType = 23
+	PolygonM    ShapeType = 25
+	MultiPointM ShapeType = 28
+	MultiPatch  ShapeType = 31
+)
+
+// base Z、M 类型对应的二维类型，Z、M 值被忽略
+func (t ShapeType) base() ShapeType {
+	switch t {
+	case PointZ, PointM:
+		return Point
+	case PolyLineZ, PolyLineM:
+		return PolyLine
+	case PolygonZ, PolygonM:
+		return Polygon
+	case MultiPointZ, MultiPointM:
+		return MultiPoint
+	}
+	return t
+}
+
+func (t ShapeType) String() string {
+	switch t {
+	case Null:
+		return "Null"
+	case Point:
+		return "Point"
+	case PolyLine:
+		return "PolyLine"
+	case Polygon:
+		return "Polygon"
+	case MultiPoint:
+		return "MultiPoint"
+	case PointZ:
+		return "PointZ"
+	case PolyLineZ:
+		return "PolyLineZ"
+	case PolygonZ:
+		return "PolygonZ"
+	case MultiPointZ:
+		return "MultiPointZ"
+	case PointM:
+		return "PointM"
+	case PolyLineM:
+		return "PolyLineM"
+	case PolygonM:
+		return "PolygonM"
+	case MultiPointM:
+		return "MultiPointM"
+	case MultiPatch:
+		return "MultiPatch"
+	}
+	return fmt.Sprintf("ShapeType(%d)", int32(t))
+}
+
+const fileCode = 9994
+
+// Record 一条记录
+type Record struct {
+	Shape      ShapeType
+	Parts      []int // 每个部分在 Points 中的起始位置
+	Points     []ordinarykriging.Point
+	Attributes map[string]interface{} // DBF 属性，数值为 float64，逻辑值为 bool，空值为 nil，其它为 string
+	Deleted    bool                   // DBF 中被标记删除
+}
+
+// Shapefile 读取结果
+type Shapefile struct {
+	Shape   ShapeType
+	BBox    [4]float64 // minX, minY, maxX, maxY
+	Fields  []Field    // DBF 字段
+	Records []*Record
+}
+
+// Open 读取 .shp 文件及同名的 .dbf 文件，.dbf 不存在时记录没有属性
+func Open(path string) (*Shapefile, error) {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	shp, err := os.Open(base + ".shp")
+	if err != nil {
+		return nil, err
+	}
+	defer shp.Close()
+
+	var dbf io.Reader
+	if f, err := os.Open(base + ".dbf"); err == nil {
+		defer f.Close()
+		dbf = f
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	shapefile, err := Decode(shp, dbf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return shapefile, nil
+}
+
+// Decode 解码 .shp 与 .dbf，dbf 可以为 nil
+func Decode(shp, dbf io.Reader) (*Shapefile, error) {
+	content, err := ioutil.ReadAll(shp)
+	if err != nil {
+		return nil, err
+	}
+	shapefile, err := decodeShp(content)
+	if err != nil {
+		return nil, err
+	}
+	if dbf == nil {
+		return shapefile, nil
+	}
+
+	fields, rows, err := decodeDbf(dbf)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) != len(shapefile.Records) {
+		return nil, fmt.Errorf("shapefile: %d shapes but %d dbf records", len(shapefile.Records), len(rows))
+	}
+	shapefile.Fields = fields
+	for i, row := range rows {
+		shapefile.Records[i].Attributes = row.attributes
+		shapefile.Records[i].Deleted = row.deleted
+	}
+	return shapefile, nil
+}
+
+func decodeShp(content []byte) (*Shapefile, error) {
+	if len(content) < 100 {
+		return nil, errors.New("shapefile: header too short")
+	}
+	if code := binary.BigEndian.Uint32(content[0:4]); code != fileCode {
+		return nil, fmt.Errorf("shapefile: invalid file code %d", code)
+	}
+	shapefile := &Shapefile{Shape: ShapeType(binary.LittleEndian.Uint32(content[32:36]))}
+	for i := range shapefile.BBox {
+		shapefile.BBox[i] = math.Float64frombits(binary.LittleEndian.Uint64(content[36+8*i:]))
+	}
+
+	for offset := 100; offset+8 <= len(content); {
+		number := binary.BigEndian.Uint32(content[offset:])
+		length := int(binary.BigEndian.Uint32(content[offset+4:])) * 2
+		offset += 8
+		if length < 4 || offset+length > len(content) {
+			return nil, fmt.Errorf("shapefile: record %d truncated", number)
+		}
+		record, err := decodeRecord(content[offset : offset+length])
+		if err != nil {
+			return nil, fmt.Errorf("shapefile: record %d: %v", number, err)
+		}
+		shapefile.Records = append(shapefile.Records, record)
+		offset += length
+	}
+	return shapefile, nil
+}
+
+// decodeRecord 解码一条记录的几何
+func decodeRecord(b []byte) (*Record, error) {
+	record := &Record{Shape: ShapeType(binary.LittleEndian.Uint32(b))}
+	b = b[4:]
+	float := func(i int) float64 {
+		return math.Float64frombits(binary.LittleEndian.Uint64(b[8*i:]))
+	}
+
+	switch record.Shape.base() {
+	case Null:
+	case Point:
+		if len(b) < 16 {
+			return nil, errors.New("point truncated")
+		}
+		record.Points = []ordinarykriging.Point{{float(0), float(1)}}
+	case MultiPoint:
+		if len(b) < 36 {
+			return nil, errors.New("multipoint truncated")
+		}
+		numPoints := int(binary.LittleEndian.Uint32(b[32:]))
+		b = b[36:]
+		if len(b) < numPoints*16 {
+			return nil, errors.New("multipoint truncated")
+		}
+		record.Points = readPoints(b, numPoints)
+	case PolyLine, Polygon:
+		if len(b) < 40 {
+			return nil, errors.New("polygon truncated")
+		}
+		numParts := int(binary.LittleEndian.Uint32(b[32:]))
+		numPoints := int(binary.LittleEndian.Uint32(b[36:]))
+		b = b[40:]
+		if len(b) < numParts*4+numPoints*16 {
+			return nil, errors.New("polygon truncated")
+		}
+		record.Parts = make([]int, numParts)
+		for i := range record.Parts {
+			record.Parts[i] = int(binary.LittleEndian.Uint32(b[4*i:]))
+			if record.Parts[i] >= numPoints || (i > 0 && record.Parts[i] < record.Parts[i-1]) {
+				return nil, fmt.Errorf("invalid part index %d", record.Parts[i])
+			}
+		}
+		record.Points = readPoints(b[numParts*4:], numPoints)
+	default:
+		return nil, fmt.Errorf("unsupported shape type %v", record.Shape)
+	}
+	return record, nil
+}
+
+func readPoints(b []byte, n int) []ordinarykriging.Point {
+	points := make([]ordinarykriging.Point, n)
+	for i := range points {
+		points[i][0] = math.Float64frombits(binary.LittleEndian.Uint64(b[16*i:]))
+		points[i][1] = math.Float64frombits(binary.LittleEndian.Uint64(b[16*i+8:]))
+	}
+	return points
+}
+
+// Rings 各部分的坐标
+func (r *Record) Rings() []ordinarykriging.Ring {
+	rings := make([]ordinarykriging.Ring, len(r.Parts))
+	for i, start := range r.Parts {
+		end := len(r.Points)
+		if i+1 < len(r.Parts) {
+			end = r.Parts[i+1]
+		}
+		rings[i] = ordinarykriging.Ring(r.Points[start:end])
+	}
+	return rings
+}
+
+// MultiPolygon 面记录的多面坐标
+// Shapefile 中顺时针的环为外环，逆时针的环为内环，内环归入包含它的外环
+func (r *Record) MultiPolygon() ordinarykriging.MultiPolygonCoordinates {
+	if r.Shape.base() != Polygon {
+		return nil
+	}
+
+	var multiPolygon ordinarykriging.MultiPolygonCoordinates
+	var holes []ordinarykriging.Ring
+	for _, ring := range r.Rings() {
+		if len(ring) < 3 {
+			continue
+		}
+		if signedArea(ring) <= 0 {
+			multiPolygon = append(multiPolygon, ordinarykriging.PolygonCoordinates{ring})
+		} else {
+			holes = append(holes, ring)
+		}
+	}
+
+	for _, hole := range holes {
+		owner := -1
+		for i, polygon := range multiPolygon {
+			if (ordinarykriging.MultiPolygonCoordinates{{polygon[0]}}).Contains(hole[0][0], hole[0][1]) {
+				owner = i
+				break
+			}
+		}
+		if owner < 0 {
+			// 没有外环包含的逆时针环按外环处理
+			multiPolygon = append(multiPolygon, ordinarykriging.PolygonCoordinates{hole})
+			continue
+		}
+		multiPolygon[owner] = append(multiPolygon[owner], hole)
+	}
+	return multiPolygon
+}
+
+// signedArea 环的有向面积，逆时针为正
+func signedArea(ring ordinarykriging.Ring) float64 {
+	area := 0.0
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		area += ring[j][0]*ring[i][1] - ring[i][0]*ring[j][1]
+	}
+	return area / 2
+}
+
+// MultiPolygon 所有未删除的面记录合并为一个多面，用于 GridMultiPolygon 裁剪
+func (s *Shapefile) MultiPolygon() (ordinarykriging.MultiPolygonCoordinates, error) {
+	var multiPolygon ordinarykriging.MultiPolygonCoordinates
+	for _, record := range s.Records {
+		if !record.Deleted {
+			multiPolygon = append(multiPolygon, record.MultiPolygon()...)
+		}
+	}
+	if len(multiPolygon) == 0 {
+		return nil, fmt.Errorf("shapefile: no polygon in %v shapefile", s.Shape)
+	}
+	return multiPolygon, nil
+}
+
+// FeatureCollection 转换为 GeoJSON 要素集合，DBF 属性作为要素属性，被删除的记录被忽略
+// 点样本可以通过 dataset.FromFeatureCollection 读取
+func (s *Shapefile) FeatureCollection() *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, record := range s.Records {
+		if record.Deleted {
+			continue
+		}
+		fc.Features = append(fc.Features, geojson.NewFeature(record.geometry(), record.Attributes))
+	}
+	return fc
+}
+
+// geometry 记录的 GeoJSON 几何，坐标为解码 JSON 后的嵌套 []interface{} 形式
+func (r *Record) geometry() *geojson.Geometry {
+	position := func(point ordinarykriging.Point) interface{} {
+		return []interface{}{point[0], point[1]}
+	}
+	line := func(points []ordinarykriging.Point) interface{} {
+		coordinates := make([]interface{}, len(points))
+		for i, point := range points {
+			coordinates[i] = position(point)
+		}
+		return coordinates
+	}
+
+	switch r.Shape.base() {
+	case Point:
+		return &geojson.Geometry{Type: geojson.TypePoint, Coordinates: position(r.Points[0])}
+	case MultiPoint:
+		return &geojson.Geometry{Type: geojson.TypeMultiPoint, Coordinates: line(r.Points)}
+	case PolyLine:
+		rings := r.Rings()
+		coordinates := make([]interface{}, len(rings))
+		for i, ring := range rings {
+			coordinates[i] = line(ring)
+		}
+		return &geojson.Geometry{Type: geojson.TypeMultiLineString, Coordinates: coordinates}
+	case Polygon:
+		multiPolygon := r.MultiPolygon()
+		coordinates := make([]interface{}, len(multiPolygon))
+		for i, polygon := range multiPolygon {
+			rings := make([]interface{}, len(polygon))
+			for j, ring := range polygon {
+				rings[j] = line(ring)
+			}
+			coordinates[i] = rings
+		}
+		return &geojson.Geometry{Type: geojson.TypeMultiPolygon, Coordinates: coordinates}
+	}
+	return nil
+}
+
+// Field DBF 字段
+type Field struct {
+	Name     string
+	Type     byte // C 字符，N、F 数值，L 逻辑，D 日期
+	Length   int
+	Decimals int
+}
+
+type dbfRow struct {
+	attributes map[string]interface{}
+	deleted    bool
+}
+
+// decodeDbf 解码 dBASE III 属性表，字符串按原始字节返回，非 UTF-8 编码（.cpg）不做转换
+func decodeDbf(r io.Reader) ([]Field, []dbfRow, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(content) < 32 {
+		return nil, nil, errors.New("dbf: header too short")
+	}
+	numRecords := int(binary.LittleEndian.Uint32(content[4:]))
+	headerLength := int(binary.LittleEndian.Uint16(content[8:]))
+	recordLength := int(binary.LittleEndian.Uint16(content[10:]))
+	if headerLength > len(content) || recordLength < 1 {
+		return nil, nil, errors.New("dbf: invalid header")
+	}
+
+	var fields []Field
+	for offset := 32; offset+32 <= headerLength && content[offset] != 0x0d; offset += 32 {
+		descriptor := content[offset : offset+32]
+		name := descriptor[:11]
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+		fields = append(fields, Field{
+			Name:     strings.TrimSpace(string(name)),
+			Type:     descriptor[11],
+			Length:   int(descriptor[16]),
+			Decimals: int(descriptor[17]),
+		})
+	}
+
+	rows := make([]dbfRow, 0, numRecords)
+	for i := 0; i < numRecords; i++ {
+		offset := headerLength + i*recordLength
+		if offset+recordLength > len(content) {
+			return nil, nil, fmt.Errorf("dbf: record %d truncated", i)
+		}
+		record := content[offset : offset+recordLength]
+		row := dbfRow{attributes: make(map[string]interface{}, len(fields)), deleted: record[0] == '*'}
+		position := 1
+		for _, field := range fields {
+			if position+field.Length > len(record) {
+				return nil, nil, fmt.Errorf("dbf: record %d field %s truncated", i, field.Name)
+			}
+			row.attributes[field.Name] = field.parse(record[position : position+field.Length])
+			position += field.Length
+		}
+		rows = append(rows, row)
+	}
+	return fields, rows, nil
+}
+
+// parse 解析字段值，空值与无法解析的数值返回 nil
+func (f Field) parse(b []byte) interface{} {
+	value := strings.TrimSpace(string(bytes.TrimRight(b, "\x00")))
+	switch f.Type {
+	case 'N', 'F':
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil
+		}
+		return number
+	case 'L':
+		switch value {
+		case "T", "t", "Y", "y":
+			return true
+		case "F", "f", "N", "n":
+			return false
+		}
+		return nil
+	}
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,158 @@
+package shapefile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+)
+
+// encodeShp 按规范写出 .shp，只用于测试
+func encodeShp(shape ShapeType, contents [][]byte) []byte {
+	var body bytes.Buffer
+	for i, content := range contents {
+		binary.Write(&body, binary.BigEndian, int32(i+1))
+		binary.Write(&body, binary.BigEndian, int32(len(content)/2))
+		body.Write(content)
+	}
+	header := make([]byte, 100)
+	binary.BigEndian.PutUint32(header[0:], fileCode)
+	binary.BigEndian.PutUint32(header[24:], uint32((100+body.Len())/2))
+	binary.LittleEndian.PutUint32(header[28:], 1000)
+	binary.LittleEndian.PutUint32(header[32:], uint32(shape))
+	return append(header, body.Bytes()...)
+}
+
+func pointContent(x, y float64) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, int32(Point))
+	binary.Write(&b, binary.LittleEndian, [2]float64{x, y})
+	return b.Bytes()
+}
+
+func polygonContent(rings ...ordinarykriging.Ring) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, int32(Polygon))
+	binary.Write(&b, binary.LittleEndian, [4]float64{})
+	numPoints := 0
+	for _, ring := range rings {
+		numPoints += len(ring)
+	}
+	binary.Write(&b, binary.LittleEndian, int32(len(rings)))
+	binary.Write(&b, binary.LittleEndian, int32(numPoints))
+	start := 0
+	for _, ring := range rings {
+		binary.Write(&b, binary.LittleEndian, int32(start))
+		start += len(ring)
+	}
+	for _, ring := range rings {
+		binary.Write(&b, binary.LittleEndian, ring)
+	}
+	return b.Bytes()
+}
+
+// encodeDbf 写出只有 NAME(C) 与 TEM(N) 两个字段的 .dbf
+func encodeDbf(names []string, values []string, deleted []bool) []byte {
+	fields := []Field{{Name: "NAME", Type: 'C', Length: 10}, {Name: "TEM", Type: 'N', Length: 8, Decimals: 2}}
+	headerLength := 32 + 32*len(fields) + 1
+	recordLength := 1 + 10 + 8
+
+	var b bytes.Buffer
+	header := make([]byte, 32)
+	header[0] = 3
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(names)))
+	binary.LittleEndian.PutUint16(header[8:], uint16(headerLength))
+	binary.LittleEndian.PutUint16(header[10:], uint16(recordLength))
+	b.Write(header)
+	for _, field := range fields {
+		descriptor := make([]byte, 32)
+		copy(descriptor, field.Name)
+		descriptor[11] = field.Type
+		descriptor[16] = byte(field.Length)
+		descriptor[17] = byte(field.Decimals)
+		b.Write(descriptor)
+	}
+	b.WriteByte(0x0d)
+	for i := range names {
+		if deleted[i] {
+			b.WriteByte('*')
+		} else {
+			b.WriteByte(' ')
+		}
+		b.WriteString(pad(names[i], 10, false))
+		b.WriteString(pad(values[i], 8, true))
+	}
+	b.WriteByte(0x1a)
+	return b.Bytes()
+}
+
+func pad(s string, n int, left bool) string {
+	for len(s) < n {
+		if left {
+			s = " " + s
+		} else {
+			s += " "
+		}
+	}
+	return s
+}
+
+func TestDecode_Points(t *testing.T) {
+	shp := encodeShp(Point, [][]byte{pointContent(102.68, 25.95), pointContent(102.88, 26.04), pointContent(1, 2)})
+	dbf := encodeDbf([]string{"a", "b", "c"}, []string{"9.60", "", "1.00"}, []bool{false, false, true})
+	shapefile, err := Decode(bytes.NewReader(shp), bytes.NewReader(dbf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shapefile.Shape != Point || len(shapefile.Records) != 3 || len(shapefile.Fields) != 2 {
+		t.Fatalf("unexpected shapefile %+v", shapefile)
+	}
+	record := shapefile.Records[0]
+	if record.Points[0] != (ordinarykriging.Point{102.68, 25.95}) || record.Attributes["NAME"] != "a" || record.Attributes["TEM"] != 9.6 {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	if shapefile.Records[1].Attributes["TEM"] != nil || !shapefile.Records[2].Deleted {
+		t.Fatalf("unexpected records %+v %+v", shapefile.Records[1], shapefile.Records[2])
+	}
+
+	fc := shapefile.FeatureCollection()
+	if len(fc.Features) != 2 {
+		t.Fatalf("unexpected features %d", len(fc.Features))
+	}
+	point, err := fc.Features[1].Geometry.Point()
+	if err != nil || point != (ordinarykriging.Point{102.88, 26.04}) {
+		t.Fatalf("unexpected point %v %v", point, err)
+	}
+}
+
+func TestRecord_MultiPolygon(t *testing.T) {
+	// 顺时针外环与逆时针内环，以及另一个顺时针外环
+	outer := ordinarykriging.Ring{{0, 0}, {0, 10}, {10, 10}, {10, 0}, {0, 0}}
+	hole := ordinarykriging.Ring{{4, 4}, {6, 4}, {6, 6}, {4, 6}, {4, 4}}
+	island := ordinarykriging.Ring{{20, 0}, {20, 10}, {30, 10}, {30, 0}, {20, 0}}
+	shp := encodeShp(Polygon, [][]byte{polygonContent(outer, island, hole)})
+	shapefile, err := Decode(bytes.NewReader(shp), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	multiPolygon, err := shapefile.MultiPolygon()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(multiPolygon) != 2 || len(multiPolygon[0]) != 2 || len(multiPolygon[1]) != 1 {
+		t.Fatalf("unexpected multi polygon %v", multiPolygon)
+	}
+	for _, c := range []struct {
+		x, y     float64
+		contains bool
+	}{{1, 1, true}, {5, 5, false}, {25, 5, true}, {15, 5, false}} {
+		if multiPolygon.Contains(c.x, c.y) != c.contains {
+			t.Fatalf("Contains(%v, %v) != %v", c.x, c.y, c.contains)
+		}
+	}
+
+	if _, err := Decode(bytes.NewReader(shp[:len(shp)-8]), nil); err == nil {
+		t.Fatal("expected error for truncated record")
+	}
+}
@@ -0,0 +1,233 @@
+package vector
+
+import (
+	"errors"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/contour"
+	"github.com/lvisei/go-kriging/pkg/palette"
+)
+
+// Map 由等值面、等值线、样本点、标题与图例组成的矢量地图
+type Map struct {
+	Width  float64 // 页面宽，SVG 为像素，PDF 为点
+	Height float64 // 页面高
+	Margin float64 // 页边距，0 时为 20
+
+	Xlim [2]float64 // 地图范围，为 0 时使用等值面的范围
+	Ylim [2]float64
+
+	Title    string
+	Subtitle string
+
+	Isobands []*contour.Isoband
+	// Colors 等值面的颜色，与 Isobands 一一对应，数量不同时按等值面的序号在 Colors 中等距取色
+	Colors []color.Color
+	// Palette 不为 nil 时等值面取其区间中值的颜色，优先于 Colors
+	Palette *palette.Palette
+
+	Isolines  []*contour.Isoline
+	LineColor color.Color // 等值线颜色，nil 时为深灰
+
+	Samples []ordinarykriging.Point // 样本点
+
+	Legend      bool
+	LegendTitle string
+}
+
+const (
+	titleSize  = 16.0
+	textSize   = 10.0
+	legendBox  = 12.0
+	legendGap  = 4.0
+	sampleSize = 2.5
+)
+
+// EncodeSVG 将地图写为 SVG
+func EncodeSVG(w io.Writer, m *Map) error {
+	svg := NewSVG(w, m.Width, m.Height)
+	if err := m.Draw(svg); err != nil {
+		return err
+	}
+	return svg.Close()
+}
+
+// EncodePDF 将地图写为单页 PDF
+func EncodePDF(w io.Writer, m *Map) error {
+	pdf := NewPDF(w, m.Width, m.Height)
+	if err := m.Draw(pdf); err != nil {
+		return err
+	}
+	return pdf.Close()
+}
+
+// Draw 绘制地图，标题在上方，图例在右侧，地图按等比例缩放到剩余区域
+func (m *Map) Draw(d Drawer) error {
+	if m.Width <= 0 || m.Height <= 0 {
+		return errors.New("vector: invalid page size")
+	}
+	xlim, ylim := m.Xlim, m.Ylim
+	if xlim[0] == xlim[1] || ylim[0] == ylim[1] {
+		xlim, ylim = m.extent()
+	}
+	if !(xlim[1] > xlim[0]) || !(ylim[1] > ylim[0]) {
+		return errors.New("vector: empty map extent")
+	}
+
+	margin := m.Margin
+	if margin <= 0 {
+		margin = 20
+	}
+	left, top := margin, margin
+	right, bottom := m.Width-margin, m.Height-margin
+	if m.Title != "" {
+		d.Text(m.Width/2, top+titleSize, m.Title, titleSize, AnchorMiddle, color.Black)
+		top += titleSize * 1.6
+	}
+	if m.Subtitle != "" {
+		d.Text(m.Width/2, top+textSize, m.Subtitle, textSize, AnchorMiddle, color.Gray{Y: 80})
+		top += textSize * 2
+	}
+	labels := m.legendLabels()
+	if m.Legend && len(labels) > 0 {
+		right -= m.legendWidth(labels) + margin
+	}
+	if right <= left || bottom <= top {
+		return errors.New("vector: page too small")
+	}
+
+	// 等比例缩放并在区域内居中
+	scale := math.Min((right-left)/(xlim[1]-xlim[0]), (bottom-top)/(ylim[1]-ylim[0]))
+	ox := left + ((right-left)-scale*(xlim[1]-xlim[0]))/2
+	oy := top + ((bottom-top)-scale*(ylim[1]-ylim[0]))/2
+	project := func(point ordinarykriging.Point) [2]float64 {
+		return [2]float64{ox + (point[0]-xlim[0])*scale, oy + (ylim[1]-point[1])*scale}
+	}
+
+	for i, isoband := range m.Isobands {
+		c := m.bandColor(i)
+		for _, polygon := range isoband.MultiPolygon {
+			subpaths := make([][][2]float64, len(polygon))
+			for k, ring := range polygon {
+				subpaths[k] = projectRing(ring, project)
+			}
+			// 同色细描边盖住相邻等值面之间的抗锯齿缝隙
+			d.Path(subpaths, true, Style{Fill: c, Stroke: c, StrokeWidth: 0.3})
+		}
+	}
+
+	lineColor := m.LineColor
+	if lineColor == nil {
+		lineColor = color.Gray{Y: 64}
+	}
+	for _, isoline := range m.Isolines {
+		d.Path([][][2]float64{projectRing(isoline.Points, project)}, false, Style{Stroke: lineColor, StrokeWidth: 0.6})
+	}
+
+	for _, sample := range m.Samples {
+		p := project(sample)
+		d.Circle(p[0], p[1], sampleSize, Style{Fill: color.White, Stroke: color.Black, StrokeWidth: 0.8})
+	}
+
+	d.Path(Rect(ox, oy, scale*(xlim[1]-xlim[0]), scale*(ylim[1]-ylim[0])), true, Style{Stroke: color.Black, StrokeWidth: 0.5})
+
+	if m.Legend && len(labels) > 0 {
+		m.drawLegend(d, labels, right+margin, top)
+	}
+	return nil
+}
+
+// extent 等值面、等值线与样本点的范围
+func (m *Map) extent() ([2]float64, [2]float64) {
+	xlim := [2]float64{math.Inf(1), math.Inf(-1)}
+	ylim := xlim
+	add := func(point ordinarykriging.Point) {
+		xlim[0], xlim[1] = math.Min(xlim[0], point[0]), math.Max(xlim[1], point[0])
+		ylim[0], ylim[1] = math.Min(ylim[0], point[1]), math.Max(ylim[1], point[1])
+	}
+	for _, isoband := range m.Isobands {
+		for _, polygon := range isoband.MultiPolygon {
+			for _, ring := range polygon {
+				for _, point := range ring {
+					add(point)
+				}
+			}
+		}
+	}
+	for _, isoline := range m.Isolines {
+		for _, point := range isoline.Points {
+			add(point)
+		}
+	}
+	for _, point := range m.Samples {
+		add(point)
+	}
+	return xlim, ylim
+}
+
+// bandColor 第 i 个等值面的颜色
+func (m *Map) bandColor(i int) color.Color {
+	if m.Palette != nil {
+		return m.Palette.Color((m.Isobands[i].Lower + m.Isobands[i].Upper) / 2)
+	}
+	if len(m.Colors) == 0 {
+		return color.Gray{Y: 200}
+	}
+	if len(m.Colors) == len(m.Isobands) || len(m.Isobands) < 2 {
+		return m.Colors[i%len(m.Colors)]
+	}
+	return m.Colors[int(math.Round(float64(i)*float64(len(m.Colors)-1)/float64(len(m.Isobands)-1)))]
+}
+
+// legendLabels 非空等值面的序号与区间文字
+func (m *Map) legendLabels() map[int]string {
+	labels := map[int]string{}
+	for i, isoband := range m.Isobands {
+		if len(isoband.MultiPolygon) > 0 {
+			labels[i] = formatValue(isoband.Lower) + " - " + formatValue(isoband.Upper)
+		}
+	}
+	return labels
+}
+
+func (m *Map) legendWidth(labels map[int]string) float64 {
+	width := TextWidth(m.LegendTitle, textSize)
+	for _, label := range labels {
+		width = math.Max(width, legendBox+legendGap+TextWidth(label, textSize))
+	}
+	return width
+}
+
+// drawLegend 在 (x, y) 处绘制分级图例，高值在上
+func (m *Map) drawLegend(d Drawer, labels map[int]string, x, y float64) {
+	if m.LegendTitle != "" {
+		d.Text(x, y+textSize, m.LegendTitle, textSize, AnchorStart, color.Black)
+		y += textSize * 1.8
+	}
+	for i := len(m.Isobands) - 1; i >= 0; i-- {
+		label, ok := labels[i]
+		if !ok {
+			continue
+		}
+		d.Path(Rect(x, y, legendBox, legendBox), true, Style{Fill: m.bandColor(i), Stroke: color.Gray{Y: 120}, StrokeWidth: 0.5})
+		d.Text(x+legendBox+legendGap, y+legendBox/2+textSize*0.35, label, textSize, AnchorStart, color.Black)
+		y += legendBox + legendGap
+	}
+}
+
+func projectRing(points []ordinarykriging.Point, project func(ordinarykriging.Point) [2]float64) [][2]float64 {
+	projected := make([][2]float64, len(points))
+	for i, point := range points {
+		projected[i] = project(point)
+	}
+	return projected
+}
+
+// formatValue 图例数值，最多四位有效数字
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
@@ -0,0 +1,226 @@
+package vector
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PDF 单页 PDF 文档，内容在 Close 时写出
+// 文字使用内置的 Helvetica 与 WinAnsiEncoding，Latin-1 以外的字符显示为 ?
+type PDF struct {
+	w             io.Writer
+	width, height float64
+	content       bytes.Buffer
+	alphas        []float64
+}
+
+// NewPDF 创建 width x height 的单页 PDF，单位为点（1/72 英寸）
+func NewPDF(w io.Writer, width, height float64) *PDF {
+	pdf := &PDF{w: w, width: width, height: height}
+	// 翻转 y 轴，使原点在左上角
+	fmt.Fprintf(&pdf.content, "1 0 0 -1 0 %s cm 1 j 1 J\n", pdfNumber(height))
+	return pdf
+}
+
+// Path 见 Drawer
+func (pdf *PDF) Path(subpaths [][][2]float64, closed bool, style Style) {
+	if !closed {
+		style.Fill = nil
+	}
+	operator := pdf.begin(style)
+	if operator == "" {
+		return
+	}
+	empty := true
+	for _, points := range subpaths {
+		if len(points) < 2 {
+			continue
+		}
+		empty = false
+		for i, point := range points {
+			op := "l"
+			if i == 0 {
+				op = "m"
+			}
+			fmt.Fprintf(&pdf.content, "%s %s %s\n", pdfNumber(point[0]), pdfNumber(point[1]), op)
+		}
+		if closed {
+			pdf.content.WriteString("h\n")
+		}
+	}
+	if empty {
+		pdf.content.WriteString("n\n")
+	} else {
+		pdf.content.WriteString(operator + "\n")
+	}
+	pdf.content.WriteString("Q\n")
+}
+
+// Circle 见 Drawer，以四段贝塞尔曲线近似
+func (pdf *PDF) Circle(x, y, r float64, style Style) {
+	operator := pdf.begin(style)
+	if operator == "" {
+		return
+	}
+	const k = 0.5522847498
+	c := r * k
+	fmt.Fprintf(&pdf.content, "%s %s m\n", pdfNumber(x+r), pdfNumber(y))
+	for _, curve := range [4][6]float64{
+		{x + r, y + c, x + c, y + r, x, y + r},
+		{x - c, y + r, x - r, y + c, x - r, y},
+		{x - r, y - c, x - c, y - r, x, y - r},
+		{x + c, y - r, x + r, y - c, x + r, y},
+	} {
+		fmt.Fprintf(&pdf.content, "%s %s %s %s %s %s c\n",
+			pdfNumber(curve[0]), pdfNumber(curve[1]), pdfNumber(curve[2]),
+			pdfNumber(curve[3]), pdfNumber(curve[4]), pdfNumber(curve[5]))
+	}
+	pdf.content.WriteString("h " + operator + "\nQ\n")
+}
+
+// Text 见 Drawer
+func (pdf *PDF) Text(x, y float64, text string, size float64, anchor Anchor, c color.Color) {
+	switch anchor {
+	case AnchorMiddle:
+		x -= TextWidth(text, size) / 2
+	case AnchorEnd:
+		x -= TextWidth(text, size)
+	}
+	r, g, b, alpha := rgba(c)
+	pdf.content.WriteString("q\n")
+	pdf.alpha(alpha)
+	// 文字矩阵再次翻转 y 轴，使文字正向显示
+	fmt.Fprintf(&pdf.content, "%s rg BT /F1 %s Tf 1 0 0 -1 %s %s Tm (%s) Tj ET\nQ\n",
+		pdfColor(r, g, b), pdfNumber(size), pdfNumber(x), pdfNumber(y), pdfString(text))
+}
+
+// begin 设置样式，返回绘制路径的操作符，不填充也不描边时返回空
+func (pdf *PDF) begin(style Style) string {
+	stroke := style.Stroke != nil && style.StrokeWidth > 0
+	if style.Fill == nil && !stroke {
+		return ""
+	}
+	pdf.content.WriteString("q\n")
+	alpha := 1.0
+	operator := ""
+	if style.Fill != nil {
+		r, g, b, a := rgba(style.Fill)
+		fmt.Fprintf(&pdf.content, "%s rg\n", pdfColor(r, g, b))
+		alpha = a
+		operator = "f*"
+	}
+	if stroke {
+		r, g, b, a := rgba(style.Stroke)
+		fmt.Fprintf(&pdf.content, "%s RG %s w\n", pdfColor(r, g, b), pdfNumber(style.StrokeWidth))
+		if style.Fill == nil {
+			alpha = a
+			operator = "S"
+		} else {
+			operator = "B*"
+		}
+	}
+	pdf.alpha(alpha)
+	return operator
+}
+
+// alpha 不透明度小于 1 时引用对应的 ExtGState
+func (pdf *PDF) alpha(alpha float64) {
+	if alpha >= 1 {
+		return
+	}
+	index := -1
+	for i, a := range pdf.alphas {
+		if a == alpha {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		index = len(pdf.alphas)
+		pdf.alphas = append(pdf.alphas, alpha)
+	}
+	fmt.Fprintf(&pdf.content, "/GS%d gs\n", index)
+}
+
+// Close 写出全部对象与交叉引用表
+func (pdf *PDF) Close() error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(pdf.content.Bytes()); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	var extGState strings.Builder
+	for i := range pdf.alphas {
+		fmt.Fprintf(&extGState, "/GS%d %d 0 R ", i, 6+i)
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> /ExtGState << %s>> >> >>",
+			pdfNumber(pdf.width), pdfNumber(pdf.height), extGState.String()),
+		fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+	for _, alpha := range pdf.alphas {
+		a := strconv.FormatFloat(alpha, 'f', 3, 64)
+		objects = append(objects, fmt.Sprintf("<< /Type /ExtGState /ca %s /CA %s >>", a, a))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	_, err := pdf.w.Write(buf.Bytes())
+	return err
+}
+
+func pdfColor(r, g, b uint8) string {
+	return pdfNumber(float64(r)/255) + " " + pdfNumber(float64(g)/255) + " " + pdfNumber(float64(b)/255)
+}
+
+// pdfNumber 保留三位小数，去掉末尾的 0
+func pdfNumber(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 3, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// pdfString 转义为 WinAnsiEncoding 的字符串字面量
+func pdfString(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r <= 126:
+			b.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,119 @@
+package vector
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SVG 逐个元素写出的 SVG 文档
+type SVG struct {
+	w   *bufio.Writer
+	err error
+}
+
+// NewSVG 创建 width x height 的 SVG 文档，单位为像素
+func NewSVG(w io.Writer, width, height float64) *SVG {
+	svg := &SVG{w: bufio.NewWriter(w)}
+	svg.printf(`<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s">`+"\n",
+		svgNumber(width), svgNumber(height), svgNumber(width), svgNumber(height))
+	return svg
+}
+
+func (svg *SVG) printf(format string, args ...interface{}) {
+	if svg.err == nil {
+		_, svg.err = fmt.Fprintf(svg.w, format, args...)
+	}
+}
+
+// Path 见 Drawer
+func (svg *SVG) Path(subpaths [][][2]float64, closed bool, style Style) {
+	var d strings.Builder
+	for _, points := range subpaths {
+		if len(points) < 2 {
+			continue
+		}
+		for i, point := range points {
+			if i == 0 {
+				d.WriteByte('M')
+			} else {
+				d.WriteByte('L')
+			}
+			d.WriteString(svgNumber(point[0]))
+			d.WriteByte(' ')
+			d.WriteString(svgNumber(point[1]))
+		}
+		if closed {
+			d.WriteByte('Z')
+		}
+	}
+	if d.Len() == 0 {
+		return
+	}
+	if !closed {
+		style.Fill = nil
+	}
+	svg.printf(`<path d="%s"%s/>`+"\n", d.String(), svgStyle(style))
+}
+
+// Circle 见 Drawer
+func (svg *SVG) Circle(x, y, r float64, style Style) {
+	svg.printf(`<circle cx="%s" cy="%s" r="%s"%s/>`+"\n", svgNumber(x), svgNumber(y), svgNumber(r), svgStyle(style))
+}
+
+// Text 见 Drawer
+func (svg *SVG) Text(x, y float64, text string, size float64, anchor Anchor, c color.Color) {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(text))
+	svg.printf(`<text x="%s" y="%s" font-family="Helvetica, Arial, sans-serif" font-size="%s" text-anchor="%s"%s>%s</text>`+"\n",
+		svgNumber(x), svgNumber(y), svgNumber(size), [...]string{"start", "middle", "end"}[anchor],
+		svgColor("fill", c), escaped.String())
+}
+
+// Close 结束文档
+func (svg *SVG) Close() error {
+	svg.printf("</svg>\n")
+	if svg.err != nil {
+		return svg.err
+	}
+	return svg.w.Flush()
+}
+
+func svgStyle(style Style) string {
+	s := ""
+	if style.Fill == nil {
+		s += ` fill="none"`
+	} else {
+		s += svgColor("fill", style.Fill) + ` fill-rule="evenodd"`
+	}
+	if style.Stroke != nil && style.StrokeWidth > 0 {
+		s += svgColor("stroke", style.Stroke) +
+			fmt.Sprintf(` stroke-width="%s" stroke-linejoin="round" stroke-linecap="round"`, svgNumber(style.StrokeWidth))
+	}
+	return s
+}
+
+// svgColor fill 或 stroke 的颜色与不透明度属性
+func svgColor(attribute string, c color.Color) string {
+	r, g, b, alpha := rgba(c)
+	s := fmt.Sprintf(` %s="#%02x%02x%02x"`, attribute, r, g, b)
+	if alpha < 1 {
+		s += fmt.Sprintf(` %s-opacity="%s"`, attribute, strconv.FormatFloat(alpha, 'f', 3, 64))
+	}
+	return s
+}
+
+// svgNumber 保留两位小数，去掉末尾的 0
+func svgNumber(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
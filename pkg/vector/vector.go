@@ -0,0 +1,73 @@
+// Package vector
+// 矢量地图输出（SVG、PDF），等值面、等值线、图例、标题与样本点打印时不会出现锯齿
+
+package vector
+
+import (
+	"image/color"
+	"unicode/utf8"
+)
+
+// Anchor 文字的水平对齐
+type Anchor int
+
+const (
+	AnchorStart Anchor = iota
+	AnchorMiddle
+	AnchorEnd
+)
+
+// Style 填充与描边，颜色为 nil 时不填充或不描边
+type Style struct {
+	Fill        color.Color
+	Stroke      color.Color
+	StrokeWidth float64
+}
+
+// Drawer 矢量绘图接口，坐标原点在左上角，y 向下
+type Drawer interface {
+	// Path 绘制由多个子路径组成的路径，closed 时闭合各子路径并按奇偶规则填充，洞不被填充
+	Path(subpaths [][][2]float64, closed bool, style Style)
+	// Circle 绘制圆
+	Circle(x, y, r float64, style Style)
+	// Text 绘制一行文字，y 为基线
+	Text(x, y float64, text string, size float64, anchor Anchor, c color.Color)
+	// Close 写出剩余内容
+	Close() error
+}
+
+// Rect 矩形的子路径
+func Rect(x, y, w, h float64) [][][2]float64 {
+	return [][][2]float64{{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}}
+}
+
+// helveticaWidths Helvetica 中 ASCII 32 到 126 的字宽，单位为字号的千分之一
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// TextWidth 按 Helvetica 估算文字宽度，非 ASCII 字符按一个字号宽
+func TextWidth(text string, size float64) float64 {
+	width := 0
+	for _, r := range text {
+		if r >= 32 && r <= 126 {
+			width += helveticaWidths[r-32]
+		} else if r >= utf8.RuneSelf {
+			width += 1000
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) * size / 1000
+}
+
+// rgba 颜色的 8 位分量与不透明度
+func rgba(c color.Color) (r, g, b uint8, alpha float64) {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return nrgba.R, nrgba.G, nrgba.B, float64(nrgba.A) / 255
+}
@@ -0,0 +1,108 @@
+package vector
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"image/color"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/contour"
+)
+
+func testMap() *Map {
+	square := func(x0, y0, size float64) ordinarykriging.Ring {
+		return ordinarykriging.Ring{{x0, y0}, {x0 + size, y0}, {x0 + size, y0 + size}, {x0, y0 + size}, {x0, y0}}
+	}
+	return &Map{
+		Width:  400,
+		Height: 300,
+		Title:  "T < 30 (°C)",
+		Isobands: []*contour.Isoband{
+			{Lower: 0, Upper: 5, MultiPolygon: ordinarykriging.MultiPolygonCoordinates{{square(0, 0, 10), square(4, 4, 2)}}},
+			{Lower: 5, Upper: 10, MultiPolygon: ordinarykriging.MultiPolygonCoordinates{{square(4, 4, 2)}}},
+		},
+		Colors:   []color.Color{color.RGBA{R: 255, A: 255}, color.NRGBA{B: 255, A: 128}},
+		Isolines: []*contour.Isoline{{Level: 5, Points: []ordinarykriging.Point{{4, 4}, {6, 4}, {6, 6}}}},
+		Samples:  []ordinarykriging.Point{{1, 1}},
+		Legend:   true,
+	}
+}
+
+func TestEncodeSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeSVG(&buf, testMap()); err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	decoder := xml.NewDecoder(&buf)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			counts[start.Name.Local]++
+		}
+	}
+	// 两个等值面、一条等值线、图框与两个图例色块
+	if counts["svg"] != 1 || counts["path"] != 6 || counts["circle"] != 1 || counts["text"] != 3 {
+		t.Fatalf("unexpected elements %v", counts)
+	}
+
+	buf.Reset()
+	EncodeSVG(&buf, testMap())
+	if !strings.Contains(buf.String(), "T &lt; 30 (°C)") || !strings.Contains(buf.String(), `fill="#0000ff" fill-opacity="0.502"`) {
+		t.Fatalf("unexpected svg %s", buf.String())
+	}
+}
+
+func TestEncodePDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodePDF(&buf, testMap()); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// 交叉引用表中的偏移指向各对象
+	match := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(data)
+	if match == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	lines := strings.Split(string(data[xref:]), "\n")
+	if lines[0] != "xref" {
+		t.Fatalf("startxref %d does not point to xref", xref)
+	}
+	count, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	if count != 7 {
+		t.Fatalf("expected 6 objects and one ExtGState, got %d entries", count)
+	}
+	for i := 1; i < count; i++ {
+		offset, _ := strconv.Atoi(lines[2+i][:10])
+		if !bytes.HasPrefix(data[offset:], []byte(strconv.Itoa(i)+" 0 obj")) {
+			t.Fatalf("object %d not at offset %d", i, offset)
+		}
+	}
+
+	start := bytes.Index(data, []byte("stream\n")) + len("stream\n")
+	end := bytes.Index(data, []byte("\nendstream"))
+	zr, err := zlib.NewReader(bytes.NewReader(data[start:end]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"(T < 30 \\(\\260C\\)) Tj", "/GS0 gs", "B*", " c\n"} {
+		if !bytes.Contains(content, []byte(want)) {
+			t.Fatalf("content stream has no %q", want)
+		}
+	}
+}
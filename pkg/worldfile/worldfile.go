@@ -0,0 +1,133 @@
+// Package worldfile
+// ESRI World File（.pgw、.jgw、.tfw 等），为 Plot、PlotRectangleGrid、PlotPng 输出的图片提供地理参考
+
+package worldfile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+	"github.com/lvisei/go-kriging/pkg/raster"
+)
+
+// WorldFile 六个仿射参数，按文件中的行顺序
+// 像元 (col, row) 中心的坐标为 x = A*col + B*row + C，y = D*col + E*row + F
+type WorldFile struct {
+	A float64 // x 方向像元大小
+	D float64 // 行旋转
+	B float64 // 列旋转
+	E float64 // y 方向像元大小，北向朝上的图片为负
+	C float64 // 左上角像元中心 x
+	F float64 // 左上角像元中心 y
+}
+
+// New 北向朝上、覆盖 xlim、ylim 的 width x height 图片
+// 与传给 Plot、PlotRectangleGrid 的 xlim、ylim 及画布宽高一致时，即为其输出图片的地理参考
+func New(xlim, ylim [2]float64, width, height int) *WorldFile {
+	xResolution := (xlim[1] - xlim[0]) / float64(width)
+	yResolution := (ylim[1] - ylim[0]) / float64(height)
+	return &WorldFile{
+		A: xResolution,
+		E: -yResolution,
+		C: xlim[0] + xResolution/2,
+		F: ylim[1] - yResolution/2,
+	}
+}
+
+// FromRaster 栅格的地理参考，每个像元对应一个栅格像元
+func FromRaster(r *raster.Raster) *WorldFile {
+	return &WorldFile{
+		A: r.XResolution,
+		E: -r.YResolution,
+		C: r.X0 + r.XResolution/2,
+		F: r.Y0 - r.YResolution/2,
+	}
+}
+
+// FromGridMatrices 像元与 GridMatrices 的格网一一对应的北向朝上图片
+func FromGridMatrices(gridMatrices *ordinarykriging.GridMatrices) *WorldFile {
+	return FromRaster(raster.FromGridMatrices(gridMatrices))
+}
+
+// FromContourRectangle PlotPng 输出图片的地理参考
+// PlotPng 的第一行为 Ylim[0]，图片南向朝上，E 为正
+func FromContourRectangle(contourRectangle *ordinarykriging.ContourRectangle) *WorldFile {
+	xResolution := (contourRectangle.Xlim[1] - contourRectangle.Xlim[0]) / float64(contourRectangle.XWidth)
+	yResolution := (contourRectangle.Ylim[1] - contourRectangle.Ylim[0]) / float64(contourRectangle.YWidth)
+	return &WorldFile{
+		A: xResolution,
+		E: yResolution,
+		C: contourRectangle.Xlim[0],
+		F: contourRectangle.Ylim[0],
+	}
+}
+
+// Path 图片对应的 world file 路径，如 a.png 为 a.pgw，a.tiff 为 a.tfw
+func Path(imagePath string) string {
+	ext := filepath.Ext(imagePath)
+	suffix := ".wld"
+	if len(ext) >= 3 {
+		suffix = ext[:2] + ext[len(ext)-1:] + "w"
+	}
+	return strings.TrimSuffix(imagePath, ext) + suffix
+}
+
+// Encode 写入六行参数
+func (wf *WorldFile) Encode(w io.Writer) error {
+	for _, v := range []float64{wf.A, wf.D, wf.B, wf.E, wf.C, wf.F} {
+		if _, err := fmt.Fprintln(w, strconv.FormatFloat(v, 'f', -1, 64)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFile 写入图片对应的 world file，返回其路径
+func (wf *WorldFile) WriteFile(imagePath string) (string, error) {
+	path := Path(imagePath)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := wf.Encode(f); err != nil {
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// Decode 读取六行参数
+func Decode(r io.Reader) (*WorldFile, error) {
+	var values []float64
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() && len(values) < 6 {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(line, 64)
+		if err != nil {
+			return nil, fmt.Errorf("worldfile: invalid line %q", line)
+		}
+		values = append(values, v)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(values) < 6 {
+		return nil, errors.New("worldfile: expected 6 lines")
+	}
+	return &WorldFile{A: values[0], D: values[1], B: values[2], E: values[3], C: values[4], F: values[5]}, nil
+}
+
+// Coordinates 像元 (col, row) 中心的坐标
+func (wf *WorldFile) Coordinates(col, row float64) (float64, float64) {
+	return wf.A*col + wf.B*row + wf.C, wf.D*col + wf.E*row + wf.F
+}
@@ -0,0 +1,42 @@
+package worldfile
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lvisei/go-kriging/ordinarykriging"
+)
+
+func TestNew(t *testing.T) {
+	wf := New([2]float64{100, 110}, [2]float64{20, 25}, 100, 50)
+	if x, y := wf.Coordinates(0, 0); x != 100.05 || y != 24.95 {
+		t.Fatalf("unexpected upper-left %v %v", x, y)
+	}
+	if x, y := wf.Coordinates(99.5, 49.5); x != 110 || y != 20 {
+		t.Fatalf("unexpected lower-right %v %v", x, y)
+	}
+
+	var buf bytes.Buffer
+	if err := wf.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Decode(&buf)
+	if err != nil || *decoded != *wf {
+		t.Fatalf("unexpected world file %+v %v", decoded, err)
+	}
+}
+
+func TestFromContourRectangle(t *testing.T) {
+	wf := FromContourRectangle(&ordinarykriging.ContourRectangle{
+		XWidth: 4, YWidth: 2, Xlim: [2]float64{0, 8}, Ylim: [2]float64{10, 12},
+	})
+	// PlotPng 的第二行、第三列为 (Xlim[0]+2*2, Ylim[0]+1*1)
+	if x, y := wf.Coordinates(2, 1); x != 4 || y != 11 {
+		t.Fatalf("unexpected coordinates %v %v", x, y)
+	}
+	for image, world := range map[string]string{"a.png": "a.pgw", "b/c.tiff": "b/c.tfw", "d.jpg": "d.jgw"} {
+		if Path(image) != world {
+			t.Fatalf("Path(%q) = %q", image, Path(image))
+		}
+	}
+}